@@ -0,0 +1,340 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/auth"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// DefaultBufferSize is the default number of records buffered by an `Auditor` before they are written.
+const DefaultBufferSize = 1024
+
+// ErrAuditorClosed is the error logged for the records of the calls that finish after the `Auditor` was closed.
+var ErrAuditorClosed = errors.New("audit: auditor is closed")
+
+// Auditor writes one chained `Record` per call to a `Sink`. Its unary and stream interceptors share the same chain.
+//
+// Records are buffered and chained, then written to the sink one at a time, by a background goroutine, so that calls
+// do not wait for the sink. Calls only block when the buffer is full: audit records are never dropped. Use Close to
+// write the buffered records when shutting down.
+type Auditor struct {
+	sink Sink
+	opts *options
+
+	mu     sync.RWMutex
+	closed bool
+	queue  chan pendingRecord
+	done   chan struct{}
+
+	// seq and prevHash are the head of the chain, only used by the goroutine writing the records.
+	seq      uint64
+	prevHash string
+}
+
+type pendingRecord struct {
+	ctx context.Context
+	r   *Record
+}
+
+type options struct {
+	resourcePaths []string
+	identityFunc  func(ctx context.Context) string
+	errorLogger   logging.Logger
+	seq           uint64
+	prevHash      string
+	hmacKey       []byte
+	bufferSize    int
+	now           func() time.Time
+}
+
+// Option configures an `Auditor`.
+type Option func(*options)
+
+// WithResourceFields records the fields of the request at the given paths in the Resource of records, e.g. "name" or
+// "parent.id", the dot separated names of the fields from the request to the field. For streams, the fields of the
+// first received message are recorded. Paths through repeated and map fields are ignored.
+func WithResourceFields(paths ...string) Option {
+	return func(o *options) {
+		o.resourcePaths = append(o.resourcePaths, paths...)
+	}
+}
+
+// WithIdentityFunc sets the function returning the identity of the caller of a call from its context. By default, it
+// is the subject of the `auth.Identity` put in the context by the auth interceptor, see `auth.InjectIdentity`. When it
+// returns an empty identity, the subject of the identity injected by the interceptors chained after the audit ones is
+// recorded instead.
+func WithIdentityFunc(f func(ctx context.Context) string) Option {
+	return func(o *options) {
+		o.identityFunc = f
+	}
+}
+
+// WithErrorLogger logs the records the sink failed to write with logger, at the error level. Such records are not part
+// of the chain: the next record follows the last written one.
+func WithErrorLogger(logger logging.Logger) Option {
+	return func(o *options) {
+		o.errorLogger = logger
+	}
+}
+
+// WithHMACKey chains the records with HMAC-SHA256 hashes keyed with key, instead of plain SHA-256 hashes. Without the
+// key, records cannot be forged or edited without breaking the chain, even by someone able to rewrite all of them, see
+// `VerifyHMAC`. Keep the key away from the storage of the records, e.g. in a secret manager.
+func WithHMACKey(key []byte) Option {
+	return func(o *options) {
+		o.hmacKey = key
+	}
+}
+
+// WithBufferSize sets the number of records buffered before they are written to the sink, `DefaultBufferSize` by
+// default.
+func WithBufferSize(n int) Option {
+	return func(o *options) {
+		o.bufferSize = n
+	}
+}
+
+// WithChainHead continues the chain after the record with the given sequence number and hash, e.g. those of
+// `FileSink.Head` when restarting. By default, the chain starts with sequence number 1 and an empty previous hash.
+func WithChainHead(seq uint64, hash string) Option {
+	return func(o *options) {
+		o.seq, o.prevHash = seq, hash
+	}
+}
+
+func identityFromAuth(ctx context.Context) string {
+	if id, ok := auth.IdentityFromContext(ctx); ok {
+		return id.Subject()
+	}
+	return ""
+}
+
+// NewAuditor returns an `Auditor` writing records to sink, from a background goroutine stopped by Close.
+func NewAuditor(sink Sink, opts ...Option) *Auditor {
+	o := &options{identityFunc: identityFromAuth, bufferSize: DefaultBufferSize, now: time.Now}
+	for _, opt := range opts {
+		opt(o)
+	}
+	a := &Auditor{
+		sink:     sink,
+		opts:     o,
+		queue:    make(chan pendingRecord, max(o.bufferSize, 0)),
+		done:     make(chan struct{}),
+		seq:      o.seq,
+		prevHash: o.prevHash,
+	}
+	go a.run()
+	return a
+}
+
+// Close stops accepting records and waits until the buffered ones are written, or until ctx is done. The records of
+// the calls finishing afterwards are not written, but logged with the error logger, see `WithErrorLogger`.
+func (a *Auditor) Close(ctx context.Context) error {
+	a.mu.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.queue)
+	}
+	a.mu.Unlock()
+
+	select {
+	case <-a.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// UnaryServerInterceptor returns a new unary server interceptor that writes an audit record for every call.
+// Chain it before the auth interceptor, so that the calls it rejects are audited too. The identity injected by the
+// auth interceptor is still recorded, see `auth.RecordIdentity`.
+func (a *Auditor) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return interceptors.UnaryServerInterceptor(interceptors.CommonReportableFunc(a.reportable))
+}
+
+// StreamServerInterceptor returns a new stream server interceptor that writes an audit record for every call.
+// Chain it before the auth interceptor, so that the calls it rejects are audited too. The identity injected by the
+// auth interceptor is still recorded, see `auth.RecordIdentity`.
+func (a *Auditor) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return interceptors.StreamServerInterceptor(interceptors.CommonReportableFunc(a.reportable))
+}
+
+func (a *Auditor) reportable(ctx context.Context, c interceptors.CallMeta) (interceptors.Reporter, context.Context) {
+	r := &reporter{a: a, ctx: ctx, CallMeta: c}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		r.peer = p.Addr.String()
+	}
+	ctx, r.recordedIdentity = auth.RecordIdentity(ctx)
+	return r, ctx
+}
+
+// enqueue buffers rec to be written. The cancellation of ctx is ignored, as the record is written after the call
+// finished.
+func (a *Auditor) enqueue(ctx context.Context, rec *Record) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		a.logError(ctx, rec, ErrAuditorClosed)
+		return
+	}
+	a.queue <- pendingRecord{ctx: context.WithoutCancel(ctx), r: rec}
+}
+
+func (a *Auditor) run() {
+	defer close(a.done)
+	for p := range a.queue {
+		a.write(p.ctx, p.r)
+	}
+}
+
+// write chains rec to the previous record and writes it to the sink. It is only called by run, one record at a time.
+func (a *Auditor) write(ctx context.Context, rec *Record) {
+	rec.Sequence, rec.PrevHash = a.seq+1, a.prevHash
+	hash, err := rec.computeHash(a.opts.hmacKey)
+	if err == nil {
+		rec.Hash = hash
+		err = a.sink.Write(ctx, rec)
+	}
+	if err != nil {
+		a.logError(ctx, rec, err)
+		return
+	}
+	a.seq, a.prevHash = rec.Sequence, rec.Hash
+}
+
+func (a *Auditor) logError(ctx context.Context, rec *Record, err error) {
+	if a.opts.errorLogger == nil {
+		return
+	}
+	a.opts.errorLogger.Log(ctx, logging.LevelError, "failed to write audit record",
+		"audit.service", rec.Service, "audit.method", rec.Method, "audit.identity", rec.Identity, "error", err.Error())
+}
+
+type reporter struct {
+	interceptors.CallMeta
+
+	a        *Auditor
+	ctx      context.Context
+	peer     string
+	resource map[string]string
+	received bool
+	// recordedIdentity returns the identity injected by the interceptors chained after the audit ones.
+	recordedIdentity func() (auth.Identity, bool)
+}
+
+// identity returns the identity of the caller, from the context of the call or the interceptors chained after.
+func (r *reporter) identity() string {
+	if id := r.a.opts.identityFunc(r.ctx); id != "" {
+		return id
+	}
+	if id, ok := r.recordedIdentity(); ok {
+		return id.Subject()
+	}
+	return ""
+}
+
+func (r *reporter) PostCall(err error, duration time.Duration) {
+	code := status.Code(err)
+	decision := DecisionAllow
+	if code == codes.Unauthenticated || code == codes.PermissionDenied {
+		decision = DecisionDeny
+	}
+	r.a.enqueue(r.ctx, &Record{
+		Version:    SchemaVersion,
+		Time:       r.a.opts.now().UTC(),
+		Identity:   r.identity(),
+		Peer:       r.peer,
+		Service:    r.Service,
+		Method:     r.Method,
+		MethodType: string(r.Typ),
+		Resource:   r.resource,
+		Decision:   decision,
+		Code:       code.String(),
+		DurationMs: float64(duration.Microseconds()) / 1000,
+	})
+}
+
+func (*reporter) PostMsgSend(any, error, time.Duration) {}
+
+func (r *reporter) PostMsgReceive(msg any, err error, _ time.Duration) {
+	if r.received || err != nil || len(r.a.opts.resourcePaths) == 0 {
+		return
+	}
+	r.received = true
+	m, ok := msg.(proto.Message)
+	if !ok {
+		return
+	}
+	for _, p := range r.a.opts.resourcePaths {
+		if v, found := fieldValue(m.ProtoReflect(), p); found {
+			if r.resource == nil {
+				r.resource = map[string]string{}
+			}
+			r.resource[p] = v
+		}
+	}
+}
+
+// fieldValue returns the value of the populated field of m at path, formatted as a string.
+func fieldValue(m protoreflect.Message, path string) (string, bool) {
+	names := strings.Split(path, ".")
+	for i, name := range names {
+		fd := m.Descriptor().Fields().ByName(protoreflect.Name(name))
+		if fd == nil || fd.IsList() || fd.IsMap() || !m.Has(fd) {
+			return "", false
+		}
+		v := m.Get(fd)
+		if i < len(names)-1 {
+			if fd.Message() == nil {
+				return "", false
+			}
+			m = v.Message()
+			continue
+		}
+		switch {
+		case fd.Message() != nil:
+			b, err := protojson.Marshal(v.Message().Interface())
+			if err != nil {
+				return "", false
+			}
+			return string(b), true
+		case fd.Enum() != nil:
+			if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+				return string(ev.Name()), true
+			}
+			return fmt.Sprint(v.Enum()), true
+		case fd.Kind() == protoreflect.BytesKind:
+			return fmt.Sprintf("%x", v.Bytes()), true
+		default:
+			return v.String(), true
+		}
+	}
+	return "", false
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,454 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/auth"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/testing/testpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+type subject string
+
+func (s subject) Subject() string { return string(s) }
+
+type memorySink struct {
+	mu      sync.Mutex
+	records []*Record
+	// err is returned by the first failures writes.
+	err      error
+	failures int
+}
+
+func (s *memorySink) Write(_ context.Context, r *Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failures > 0 {
+		s.failures--
+		return s.err
+	}
+	s.records = append(s.records, r)
+	return nil
+}
+
+func (s *memorySink) Records() []*Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*Record(nil), s.records...)
+}
+
+func encode(t *testing.T, records []*Record) *bytes.Buffer {
+	t.Helper()
+
+	buf := &bytes.Buffer{}
+	for _, r := range records {
+		b, err := json.Marshal(r)
+		require.NoError(t, err)
+		buf.Write(append(b, '\n'))
+	}
+	return buf
+}
+
+func closeAuditor(t *testing.T, a *Auditor) {
+	t.Helper()
+	require.NoError(t, a.Close(context.Background()))
+}
+
+func callUnary(t *testing.T, interceptor grpc.UnaryServerInterceptor, ctx context.Context, req any, handlerErr error) {
+	t.Helper()
+
+	info := &grpc.UnaryServerInfo{FullMethod: testpb.TestServiceFullName + "/Ping"}
+	_, err := interceptor(ctx, req, info, func(context.Context, any) (any, error) {
+		return &testpb.PingResponse{}, handlerErr
+	})
+	require.ErrorIs(t, err, handlerErr)
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	sink := &memorySink{}
+	a := NewAuditor(sink, WithResourceFields("value", "error_code_returned", "unknown"))
+	a.opts.now = func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.Local) }
+	interceptor := a.UnaryServerInterceptor()
+
+	ctx := auth.InjectIdentity(context.Background(), subject("alice"))
+	ctx = peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 1234}})
+	callUnary(t, interceptor, ctx, &testpb.PingRequest{Value: "resource-1"}, nil)
+	callUnary(t, interceptor, context.Background(), &testpb.PingRequest{}, status.Error(codes.PermissionDenied, "no"))
+	closeAuditor(t, a)
+
+	records := sink.Records()
+	require.Len(t, records, 2)
+	first := records[0]
+	assert.Equal(t, SchemaVersion, first.Version)
+	assert.Equal(t, uint64(1), first.Sequence)
+	assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.Local).UTC(), first.Time)
+	assert.Equal(t, "alice", first.Identity)
+	assert.Equal(t, "10.0.0.1:1234", first.Peer)
+	assert.Equal(t, testpb.TestServiceFullName, first.Service)
+	assert.Equal(t, "Ping", first.Method)
+	assert.Equal(t, "unary", first.MethodType)
+	assert.Equal(t, map[string]string{"value": "resource-1"}, first.Resource)
+	assert.Equal(t, DecisionAllow, first.Decision)
+	assert.Equal(t, "OK", first.Code)
+	assert.Empty(t, first.PrevHash)
+	assert.NotEmpty(t, first.Hash)
+
+	second := records[1]
+	assert.Equal(t, uint64(2), second.Sequence)
+	assert.Empty(t, second.Identity)
+	assert.Nil(t, second.Resource)
+	assert.Equal(t, DecisionDeny, second.Decision)
+	assert.Equal(t, "PermissionDenied", second.Code)
+	assert.Equal(t, first.Hash, second.PrevHash)
+
+	n, err := Verify(encode(t, records))
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+}
+
+func TestUnaryServerInterceptor_ChainedBeforeAuth(t *testing.T) {
+	sink := &memorySink{}
+	a := NewAuditor(sink)
+	authFunc := func(ctx context.Context) (context.Context, error) {
+		token, err := auth.AuthFromMD(ctx, "bearer")
+		if err != nil {
+			return nil, err
+		}
+		return auth.InjectIdentity(ctx, subject(token)), nil
+	}
+	// Denies everyone but alice once authenticated, like an authorization interceptor would.
+	authz := func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if id, _ := auth.IdentityFromContext(ctx); id.Subject() != "alice" {
+			return nil, status.Error(codes.PermissionDenied, "denied")
+		}
+		return handler(ctx, req)
+	}
+	chain := func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		return a.UnaryServerInterceptor()(ctx, req, info, func(ctx context.Context, req any) (any, error) {
+			return auth.UnaryServerInterceptor(authFunc)(ctx, req, info, func(ctx context.Context, req any) (any, error) {
+				return authz(ctx, req, info, handler)
+			})
+		})
+	}
+	withToken := func(token string) context.Context {
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "bearer "+token))
+	}
+
+	info := &grpc.UnaryServerInfo{FullMethod: testpb.TestServiceFullName + "/Ping"}
+	handler := func(context.Context, any) (any, error) { return &testpb.PingResponse{}, nil }
+	_, err := chain(withToken("alice"), &testpb.PingRequest{}, info, handler)
+	require.NoError(t, err)
+	_, err = chain(context.Background(), &testpb.PingRequest{}, info, handler)
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = chain(withToken("bob"), &testpb.PingRequest{}, info, handler)
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	closeAuditor(t, a)
+
+	records := sink.Records()
+	require.Len(t, records, 3)
+	assert.Equal(t, "alice", records[0].Identity)
+	assert.Equal(t, DecisionAllow, records[0].Decision)
+	assert.Empty(t, records[1].Identity, "the caller was not authenticated")
+	assert.Equal(t, DecisionDeny, records[1].Decision)
+	assert.Equal(t, "Unauthenticated", records[1].Code)
+	assert.Equal(t, "bob", records[2].Identity, "the identity should be recorded even if the call is denied later")
+	assert.Equal(t, DecisionDeny, records[2].Decision)
+	assert.Equal(t, "PermissionDenied", records[2].Code)
+}
+
+type recvStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	msgs []*testpb.PingStreamRequest
+}
+
+func (s *recvStream) Context() context.Context { return s.ctx }
+
+func (s *recvStream) RecvMsg(m any) error {
+	if len(s.msgs) == 0 {
+		return errors.New("EOF")
+	}
+	proto.Merge(m.(proto.Message), s.msgs[0])
+	s.msgs = s.msgs[1:]
+	return nil
+}
+
+func TestStreamServerInterceptor_SharesChain(t *testing.T) {
+	sink := &memorySink{}
+	a := NewAuditor(sink, WithResourceFields("value"))
+
+	callUnary(t, a.UnaryServerInterceptor(), context.Background(), &testpb.PingRequest{Value: "unary"}, nil)
+
+	stream := &recvStream{ctx: context.Background(), msgs: []*testpb.PingStreamRequest{{Value: "first"}, {Value: "second"}}}
+	info := &grpc.StreamServerInfo{FullMethod: testpb.TestServiceFullName + "/PingStream", IsClientStream: true, IsServerStream: true}
+	err := a.StreamServerInterceptor()(nil, stream, info, func(_ any, ss grpc.ServerStream) error {
+		for range 2 {
+			if err := ss.RecvMsg(&testpb.PingStreamRequest{}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	closeAuditor(t, a)
+
+	records := sink.Records()
+	require.Len(t, records, 2)
+	assert.Equal(t, "bidi_stream", records[1].MethodType)
+	assert.Equal(t, map[string]string{"value": "first"}, records[1].Resource)
+	assert.Equal(t, records[0].Hash, records[1].PrevHash)
+}
+
+func TestAuditor_SinkError(t *testing.T) {
+	sink := &memorySink{err: errors.New("disk full"), failures: 1}
+	buf := &bytes.Buffer{}
+	errLogger := logging.LoggerFunc(func(_ context.Context, lvl logging.Level, msg string, fields ...any) {
+		buf.WriteString(slog.Level(lvl).String() + " " + msg + " " + strings.Join(toStrings(fields), " "))
+	})
+	a := NewAuditor(sink, WithErrorLogger(errLogger), WithChainHead(41, "abc"))
+	interceptor := a.UnaryServerInterceptor()
+
+	callUnary(t, interceptor, context.Background(), &testpb.PingRequest{}, nil)
+	callUnary(t, interceptor, context.Background(), &testpb.PingRequest{}, nil)
+	closeAuditor(t, a)
+	assert.Contains(t, buf.String(), "ERROR failed to write audit record")
+	assert.Contains(t, buf.String(), "disk full")
+
+	records := sink.Records()
+	require.Len(t, records, 1)
+	assert.Equal(t, uint64(42), records[0].Sequence, "a record that failed to be written must not break the chain")
+	assert.Equal(t, "abc", records[0].PrevHash)
+}
+
+func TestAuditor_DoesNotWaitForSink(t *testing.T) {
+	release := make(chan struct{})
+	sink := &memorySink{}
+	slow := SinkFunc(func(ctx context.Context, r *Record) error {
+		<-release
+		return sink.Write(ctx, r)
+	})
+	a := NewAuditor(slow, WithBufferSize(10))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 5 {
+			callUnary(t, a.UnaryServerInterceptor(), context.Background(), &testpb.PingRequest{}, nil)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("calls should not wait for the sink")
+	}
+	close(release)
+	closeAuditor(t, a)
+
+	records := sink.Records()
+	require.Len(t, records, 5)
+	n, err := Verify(encode(t, records))
+	require.NoError(t, err)
+	assert.Equal(t, 5, n)
+}
+
+func TestAuditor_Closed(t *testing.T) {
+	sink := &memorySink{}
+	buf := &bytes.Buffer{}
+	errLogger := logging.LoggerFunc(func(_ context.Context, _ logging.Level, msg string, fields ...any) {
+		buf.WriteString(msg + " " + strings.Join(toStrings(fields), " "))
+	})
+	a := NewAuditor(sink, WithErrorLogger(errLogger))
+	closeAuditor(t, a)
+	closeAuditor(t, a)
+
+	callUnary(t, a.UnaryServerInterceptor(), context.Background(), &testpb.PingRequest{}, nil)
+	assert.Empty(t, sink.Records())
+	assert.Contains(t, buf.String(), ErrAuditorClosed.Error())
+}
+
+func TestAuditor_HMACKey(t *testing.T) {
+	key := []byte("secret")
+	sink := &memorySink{}
+	a := NewAuditor(sink, WithHMACKey(key))
+	for range 3 {
+		callUnary(t, a.UnaryServerInterceptor(), context.Background(), &testpb.PingRequest{}, nil)
+	}
+	closeAuditor(t, a)
+	records := sink.Records()
+
+	n, err := VerifyHMAC(encode(t, records), key)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	_, err = VerifyHMAC(encode(t, records), []byte("other"))
+	require.Error(t, err, "records should not verify with another key")
+	_, err = Verify(encode(t, records))
+	require.Error(t, err, "records should not verify without the key")
+
+	// Rewriting the chain from an edited record is detected without the key.
+	forged := make([]*Record, len(records))
+	prevHash := ""
+	for i, r := range records {
+		c := *r
+		if i == 1 {
+			c.Code = "PermissionDenied"
+		}
+		c.PrevHash = prevHash
+		c.Hash, err = c.computeHash(nil)
+		require.NoError(t, err)
+		prevHash = c.Hash
+		forged[i] = &c
+	}
+	n, err = VerifyHMAC(encode(t, forged), key)
+	require.Error(t, err)
+	assert.Equal(t, 0, n)
+}
+
+func toStrings(fields []any) []string {
+	s := make([]string, 0, len(fields))
+	for _, f := range fields {
+		s = append(s, f.(string))
+	}
+	return s
+}
+
+func TestVerify(t *testing.T) {
+	sink := &memorySink{}
+	a := NewAuditor(sink)
+	for range 4 {
+		callUnary(t, a.UnaryServerInterceptor(), context.Background(), &testpb.PingRequest{}, nil)
+	}
+	closeAuditor(t, a)
+	records := sink.Records()
+
+	for _, tcase := range []struct {
+		name    string
+		records func() []*Record
+		n       int
+		err     string
+	}{
+		{
+			name:    "intact",
+			records: func() []*Record { return records },
+			n:       4,
+		},
+		{
+			name:    "rotated",
+			records: func() []*Record { return records[2:] },
+			n:       2,
+		},
+		{
+			name: "edited",
+			records: func() []*Record {
+				edited := *records[1]
+				edited.Code = "PermissionDenied"
+				return []*Record{records[0], &edited, records[2], records[3]}
+			},
+			n:   1,
+			err: "audit: record with seq 2 was modified",
+		},
+		{
+			name:    "deleted",
+			records: func() []*Record { return []*Record{records[0], records[2], records[3]} },
+			n:       1,
+			err:     "audit: record with seq 3 does not follow record with seq 1",
+		},
+		{
+			name:    "reordered",
+			records: func() []*Record { return []*Record{records[0], records[2], records[1], records[3]} },
+			n:       1,
+			err:     "audit: record with seq 3 does not follow record with seq 1",
+		},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			n, err := Verify(encode(t, tcase.records()))
+			assert.Equal(t, tcase.n, n)
+			if tcase.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tcase.err)
+		})
+	}
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	sink, err := NewFileSink(path, WithFsync())
+	require.NoError(t, err)
+	seq, hash := sink.Head()
+	assert.Equal(t, uint64(0), seq)
+	assert.Empty(t, hash)
+
+	a := NewAuditor(sink)
+	callUnary(t, a.UnaryServerInterceptor(), context.Background(), &testpb.PingRequest{}, nil)
+	closeAuditor(t, a)
+	require.NoError(t, sink.Close())
+	require.ErrorIs(t, sink.Write(context.Background(), &Record{}), ErrSinkClosed)
+
+	// Continue the chain of the file after a restart.
+	sink, err = NewFileSink(path)
+	require.NoError(t, err)
+	seq, hash = sink.Head()
+	assert.Equal(t, uint64(1), seq)
+	assert.NotEmpty(t, hash)
+	a = NewAuditor(sink, WithChainHead(sink.Head()))
+	callUnary(t, a.UnaryServerInterceptor(), context.Background(), &testpb.PingRequest{}, nil)
+	closeAuditor(t, a)
+	require.NoError(t, sink.Close())
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+	n, err := Verify(f)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+}
+
+func TestLoggerSink(t *testing.T) {
+	var got []any
+	logger := logging.LoggerFunc(func(_ context.Context, lvl logging.Level, msg string, fields ...any) {
+		assert.Equal(t, logging.LevelInfo, lvl)
+		assert.Equal(t, "audit record", msg)
+		got = fields
+	})
+	a := NewAuditor(LoggerSink(logger), WithResourceFields("value"))
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.MD{})
+	callUnary(t, a.UnaryServerInterceptor(), ctx, &testpb.PingRequest{Value: "r"}, nil)
+	closeAuditor(t, a)
+
+	fields := map[string]any{}
+	i := logging.Fields(got).Iterator()
+	for i.Next() {
+		k, v := i.At()
+		fields[k] = v
+	}
+	assert.Equal(t, "1", fields["audit.seq"])
+	assert.Equal(t, "Ping", fields["audit.method"])
+	assert.Equal(t, "r", fields["audit.resource.value"])
+	assert.Equal(t, "allow", fields["audit.decision"])
+	assert.NotEmpty(t, fields["audit.hash"])
+}
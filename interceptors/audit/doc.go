@@ -0,0 +1,34 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+/*
+Package audit is a middleware that keeps a tamper-evident audit trail of gRPC calls.
+
+# Server Side Audit Middleware
+
+An `Auditor` writes one `Record` per call to a `Sink`, when the call finishes. Records have a stable JSON schema:
+who made the call (the identity put in the context by the auth interceptor, see `auth.InjectIdentity`), the peer
+address, the service and method, the chosen resource fields of the request (see `WithResourceFields`), the
+decision (calls failing with the Unauthenticated or PermissionDenied codes are denied) and the outcome.
+
+Records are hash-chained: each record holds the hash of the previous one, and its own hash covers all its fields.
+`Verify` detects records that were edited, deleted or reordered.
+
+The threat model matters: plain SHA-256 hashes detect corruption and changes made by someone who cannot rewrite all
+the following records, but anyone able to write the whole file can recompute a valid chain. With `WithHMACKey`,
+records are chained with HMAC-SHA256 hashes instead, which cannot be recomputed without the key: an attacker with
+write access to the storage, but not to the key, cannot forge, edit or reorder records unnoticed (see `VerifyHMAC`).
+In both cases, the removal of the last records is only detected by comparing the head of the chain with a copy kept
+elsewhere.
+
+Records are written by a background goroutine, so that calls never wait for the sink, and `Auditor.Close` writes the
+buffered ones when shutting down. The `FileSink` appends records to a local file as JSON lines, and `LoggerSink` logs
+them with a logging.Logger. Any other storage can implement `Sink`.
+
+Chain the interceptors before the auth interceptor, so that the calls it rejects are audited as denied too. The
+identity it injects is recorded all the same, see `auth.RecordIdentity`. Use the selector interceptor to only audit
+sensitive methods.
+
+Please see examples for simple examples of use.
+*/
+package audit
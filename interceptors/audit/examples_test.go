@@ -0,0 +1,76 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package audit_test
+
+import (
+	"context"
+	"log"
+	"os"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/audit"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/auth"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
+	"google.golang.org/grpc"
+)
+
+type user string
+
+func (u user) Subject() string { return string(u) }
+
+// exampleAuthFunc authenticates callers and puts their identity in the context, where the audit interceptor chained
+// before the auth one records it.
+func exampleAuthFunc(ctx context.Context) (context.Context, error) {
+	token, err := auth.AuthFromMD(ctx, "bearer")
+	if err != nil {
+		return nil, err
+	}
+	// Verify the token here.
+	return auth.InjectIdentity(ctx, user(token)), nil
+}
+
+// Simple example of server initialization code, writing records chained with an HMAC key to a local file.
+func Example_serverConfig() {
+	file, err := audit.NewFileSink("/var/log/grpc/audit.log")
+	if err != nil {
+		log.Fatal(err)
+	}
+	auditor := audit.NewAuditor(file,
+		audit.WithChainHead(file.Head()),
+		audit.WithHMACKey([]byte(os.Getenv("AUDIT_HMAC_KEY"))),
+		audit.WithResourceFields("name"),
+		audit.WithErrorLogger(logging.LoggerFunc(func(_ context.Context, _ logging.Level, msg string, fields ...any) {
+			log.Println(append([]any{msg}, fields...)...)
+		})),
+	)
+	defer func() {
+		// Write the buffered records before closing the file.
+		_ = auditor.Close(context.Background())
+		_ = file.Close()
+	}()
+
+	_ = grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			auditor.UnaryServerInterceptor(),
+			auth.UnaryServerInterceptor(exampleAuthFunc),
+		),
+		grpc.ChainStreamInterceptor(
+			auditor.StreamServerInterceptor(),
+			auth.StreamServerInterceptor(exampleAuthFunc),
+		),
+	)
+}
+
+// Example of checking the chain of an audit file.
+func ExampleVerify() {
+	f, err := os.Open("/var/log/grpc/audit.log")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+
+	n, err := audit.VerifyHMAC(f, []byte(os.Getenv("AUDIT_HMAC_KEY")))
+	if err != nil {
+		log.Fatalf("audit trail was tampered with after %d records: %v", n, err)
+	}
+}
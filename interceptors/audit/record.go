@@ -0,0 +1,120 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package audit
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// SchemaVersion is the version of the schema of `Record`, incremented on any incompatible change.
+const SchemaVersion = 1
+
+// Decision is whether a call was allowed to proceed.
+type Decision string
+
+const (
+	// DecisionAllow is the decision of calls that were not denied.
+	DecisionAllow Decision = "allow"
+	// DecisionDeny is the decision of calls that failed with the Unauthenticated or PermissionDenied codes.
+	DecisionDeny Decision = "deny"
+)
+
+// Record is the audit record of a call. Its JSON encoding is stable: fields are only added with a new
+// `SchemaVersion`.
+//
+// Records are chained: the Hash of a record covers all its other fields, including PrevHash, the Hash of the
+// previous record. Editing or deleting a record, or reordering records, breaks the chain, see `Verify` and
+// `VerifyHMAC`.
+type Record struct {
+	Version    int               `json:"version"`
+	Sequence   uint64            `json:"seq"`
+	Time       time.Time         `json:"time"`
+	Identity   string            `json:"identity,omitempty"`
+	Peer       string            `json:"peer,omitempty"`
+	Service    string            `json:"service"`
+	Method     string            `json:"method"`
+	MethodType string            `json:"method_type"`
+	Resource   map[string]string `json:"resource,omitempty"`
+	Decision   Decision          `json:"decision"`
+	Code       string            `json:"code"`
+	DurationMs float64           `json:"duration_ms"`
+	PrevHash   string            `json:"prev_hash"`
+	Hash       string            `json:"hash,omitempty"`
+}
+
+// computeHash returns the hex encoded SHA-256 hash of the JSON encoding of r without its Hash, or its HMAC-SHA256
+// if key is not nil.
+func (r *Record) computeHash(key []byte) (string, error) {
+	c := *r
+	c.Hash = ""
+	b, err := json.Marshal(&c)
+	if err != nil {
+		return "", err
+	}
+	if key == nil {
+		sum := sha256.Sum256(b)
+		return hex.EncodeToString(sum[:]), nil
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(b)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// Verify reads the records written as JSON lines to r, e.g. by a `FileSink`, and checks that they form an unbroken
+// chain: each record must have the expected hash, the hash of the previous record and the next sequence number. The
+// first record is trusted as the start of the chain, so that logs rotated away can be verified independently.
+//
+// It returns the number of verified records, and an error describing the first break in the chain, if any. Note
+// that a chain cannot reveal the removal of its last records on its own: compare the last sequence number and hash
+// with a copy kept elsewhere for that.
+//
+// Plain hashes only protect against changes made by someone who cannot rewrite all the following records: anyone able
+// to write the file can recompute the whole chain. Use `WithHMACKey` and `VerifyHMAC` otherwise.
+func Verify(r io.Reader) (int, error) {
+	return VerifyHMAC(r, nil)
+}
+
+// VerifyHMAC is like `Verify`, for the records chained with the HMAC key of `WithHMACKey`.
+func VerifyHMAC(r io.Reader, key []byte) (int, error) {
+	var (
+		prev *Record
+		n    int
+	)
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		rec := &Record{}
+		if err := json.Unmarshal(sc.Bytes(), rec); err != nil {
+			return n, fmt.Errorf("audit: record %d is not valid: %w", n+1, err)
+		}
+		hash, err := rec.computeHash(key)
+		if err != nil {
+			return n, err
+		}
+		if hash != rec.Hash {
+			return n, fmt.Errorf("audit: record with seq %d was modified: expected hash %s, got %s", rec.Sequence, hash, rec.Hash)
+		}
+		if prev != nil {
+			if rec.PrevHash != prev.Hash {
+				return n, fmt.Errorf("audit: record with seq %d does not follow record with seq %d: expected prev_hash %s, got %s", rec.Sequence, prev.Sequence, prev.Hash, rec.PrevHash)
+			}
+			if rec.Sequence != prev.Sequence+1 {
+				return n, fmt.Errorf("audit: records are missing between seq %d and %d", prev.Sequence, rec.Sequence)
+			}
+		}
+		prev = rec
+		n++
+	}
+	return n, sc.Err()
+}
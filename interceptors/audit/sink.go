@@ -0,0 +1,165 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
+)
+
+// ErrSinkClosed is returned when writing to a sink that was closed.
+var ErrSinkClosed = errors.New("audit: sink is closed")
+
+// Sink stores audit records.
+//
+// Write is called with the records of an `Auditor` one at a time, in the order of the chain, so a Sink must store
+// them in the order it receives them.
+type Sink interface {
+	Write(ctx context.Context, r *Record) error
+}
+
+// SinkFunc is a function that also implements the Sink interface.
+type SinkFunc func(ctx context.Context, r *Record) error
+
+func (f SinkFunc) Write(ctx context.Context, r *Record) error {
+	return f(ctx, r)
+}
+
+// FileSink appends records as JSON lines to a local file, that can be checked with `Verify`.
+type FileSink struct {
+	mu       sync.Mutex
+	f        *os.File
+	sync     bool
+	lastSeq  uint64
+	lastHash string
+}
+
+// FileSinkOption configures a `FileSink`.
+type FileSinkOption func(*FileSink)
+
+// WithFsync makes the `FileSink` flush every record to stable storage before Write returns.
+func WithFsync() FileSinkOption {
+	return func(s *FileSink) {
+		s.sync = true
+	}
+}
+
+// NewFileSink opens the file at path, creating it if needed, to append records to it. If the file already has
+// records, the last one is returned by `Head`, to continue its chain with `WithChainHead`.
+func NewFileSink(path string, opts ...FileSinkOption) (*FileSink, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	s := &FileSink{f: f}
+	for _, o := range opts {
+		o(s)
+	}
+	last, err := lastRecord(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("audit: reading the last record of %s: %w", path, err)
+	}
+	if last != nil {
+		s.lastSeq, s.lastHash = last.Sequence, last.Hash
+	}
+	return s, nil
+}
+
+// lastRecord returns the last record of r, or nil if it has none.
+func lastRecord(r io.Reader) (*Record, error) {
+	var last []byte
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		if len(sc.Bytes()) > 0 {
+			last = append(last[:0], sc.Bytes()...)
+		}
+	}
+	if err := sc.Err(); err != nil || last == nil {
+		return nil, err
+	}
+	rec := &Record{}
+	if err := json.Unmarshal(last, rec); err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+// Head returns the sequence number and hash of the last record of the file.
+func (s *FileSink) Head() (seq uint64, hash string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastSeq, s.lastHash
+}
+
+func (s *FileSink) Write(_ context.Context, r *Record) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f == nil {
+		return ErrSinkClosed
+	}
+	// A single write, so that a record is never interleaved with another writer of the file.
+	if _, err = s.f.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	if s.sync {
+		if err = s.f.Sync(); err != nil {
+			return err
+		}
+	}
+	s.lastSeq, s.lastHash = r.Sequence, r.Hash
+	return nil
+}
+
+// Close closes the file.
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f == nil {
+		return nil
+	}
+	err := s.f.Close()
+	s.f = nil
+	return err
+}
+
+// LoggerSink returns a Sink logging every record with logger at the info level, with the "audit.<field>" fields.
+func LoggerSink(logger logging.Logger) Sink {
+	return SinkFunc(func(ctx context.Context, r *Record) error {
+		fields := logging.Fields{
+			"audit.version", strconv.Itoa(r.Version),
+			"audit.seq", strconv.FormatUint(r.Sequence, 10),
+			"audit.time", r.Time.Format(time.RFC3339Nano),
+			"audit.identity", r.Identity,
+			"audit.peer", r.Peer,
+			"audit.service", r.Service,
+			"audit.method", r.Method,
+			"audit.method_type", r.MethodType,
+			"audit.decision", string(r.Decision),
+			"audit.code", r.Code,
+			"audit.duration_ms", strconv.FormatFloat(r.DurationMs, 'f', -1, 64),
+		}
+		for _, k := range sortedKeys(r.Resource) {
+			fields = append(fields, "audit.resource."+k, r.Resource[k])
+		}
+		fields = append(fields, "audit.prev_hash", r.PrevHash, "audit.hash", r.Hash)
+		logger.Log(ctx, logging.LevelInfo, "audit record", fields...)
+		return nil
+	})
+}
@@ -0,0 +1,269 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/selector"
+	"golang.org/x/oauth2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// DefaultScheme is the scheme of tokens without one.
+	DefaultScheme = "Bearer"
+	// DefaultRefreshBefore is how long before their expiry cached tokens are refreshed.
+	DefaultRefreshBefore = time.Minute
+)
+
+// Token is a credential attached to the authorization header of client calls, as "<Scheme> <Value>".
+type Token struct {
+	// Scheme is the authorization scheme, e.g. "Bearer" or "Basic", `DefaultScheme` if empty.
+	Scheme string
+	Value  string
+	// Expiry is the time the token expires at, or zero if it does not expire.
+	Expiry time.Time
+}
+
+// TokenSource provides the tokens of client calls.
+type TokenSource interface {
+	Token(ctx context.Context) (*Token, error)
+}
+
+// TokenSourceFunc is a function implementing `TokenSource`.
+type TokenSourceFunc func(ctx context.Context) (*Token, error)
+
+// Token calls f(ctx).
+func (f TokenSourceFunc) Token(ctx context.Context) (*Token, error) {
+	return f(ctx)
+}
+
+// OAuth2TokenSource returns a TokenSource providing the access tokens of ts, with their type as scheme.
+//
+// Note that a token rejected with the Unauthenticated code is only replaced once ts returns a new one. The token
+// sources reusing their tokens until they expire, such as oauth2.ReuseTokenSource and the token sources of
+// oauth2.Config and clientcredentials.Config, return the rejected token again until then.
+func OAuth2TokenSource(ts oauth2.TokenSource) TokenSource {
+	return TokenSourceFunc(func(context.Context) (*Token, error) {
+		t, err := ts.Token()
+		if err != nil {
+			return nil, err
+		}
+		return &Token{Scheme: t.Type(), Value: t.AccessToken, Expiry: t.Expiry}, nil
+	})
+}
+
+// CachedTokenSource returns a TokenSource reusing the tokens of ts until refreshBefore before their expiry. Pass the
+// same cached source to the unary and stream client interceptors for them to share tokens.
+//
+// Tokens are fetched from ts by a single call at a time, which is not cancelled when the calls waiting for it are, so
+// ts should bound the time it takes. Calls only wait for it when there is no valid token: a token about to expire is
+// still used while it is refreshed in the background, or if the refresh fails.
+func CachedTokenSource(ts TokenSource, refreshBefore time.Duration) TokenSource {
+	return &tokenCache{source: ts, refreshBefore: refreshBefore}
+}
+
+// newTokenCache returns ts if it is a `CachedTokenSource`, or caches its tokens otherwise.
+func newTokenCache(ts TokenSource) *tokenCache {
+	if c, ok := ts.(*tokenCache); ok {
+		return c
+	}
+	return &tokenCache{source: ts, refreshBefore: DefaultRefreshBefore}
+}
+
+type tokenCache struct {
+	source        TokenSource
+	refreshBefore time.Duration
+
+	mu    sync.Mutex
+	token *Token
+	// refresh is the fetch of a new token in progress, if any.
+	refresh *tokenRefresh
+}
+
+// tokenRefresh is the fetch of a new token, shared by the calls waiting for it.
+type tokenRefresh struct {
+	done  chan struct{}
+	token *Token
+	err   error
+}
+
+func (c *tokenCache) Token(ctx context.Context) (*Token, error) {
+	now := time.Now()
+	c.mu.Lock()
+	t := c.token
+	if t != nil && (t.Expiry.IsZero() || now.Add(c.refreshBefore).Before(t.Expiry)) {
+		c.mu.Unlock()
+		return t, nil
+	}
+	r := c.refresh
+	if r == nil {
+		r = &tokenRefresh{done: make(chan struct{})}
+		c.refresh = r
+		go c.fetch(context.WithoutCancel(ctx), r)
+	}
+	c.mu.Unlock()
+
+	if t != nil && now.Before(t.Expiry) {
+		return t, nil
+	}
+	select {
+	case <-r.done:
+		if r.err != nil {
+			return nil, r.err
+		}
+		return r.token, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// fetch gets a new token from the source for r, and caches it.
+func (c *tokenCache) fetch(ctx context.Context, r *tokenRefresh) {
+	t, err := c.source.Token(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err == nil {
+		c.token = t
+	}
+	c.refresh = nil
+	r.token, r.err = t, err
+	close(r.done)
+}
+
+// invalidate drops t from the cache, unless it was already replaced, e.g. by a concurrent call rejecting it too, so
+// that the next call fetches a new token.
+func (c *tokenCache) invalidate(t *Token) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token == t {
+		c.token = nil
+	}
+}
+
+type clientOptions struct {
+	matcher selector.Matcher
+}
+
+// ClientOption is an option of the client interceptors.
+type ClientOption func(*clientOptions)
+
+// WithMatcher attaches tokens only to the calls matched by m, e.g. to the methods of a given service. By default,
+// tokens are attached to all calls.
+func WithMatcher(m selector.Matcher) ClientOption {
+	return func(o *clientOptions) {
+		o.matcher = m
+	}
+}
+
+func evaluateClientOpt(opts []ClientOption) *clientOptions {
+	o := &clientOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// UnaryClientInterceptor returns a new unary client interceptor that sets the authorization header of calls to the
+// tokens of ts. Unless ts is a `CachedTokenSource`, tokens are cached and refreshed `DefaultRefreshBefore` before
+// they expire.
+// A call failing with the Unauthenticated code is retried once with a new token.
+//
+// Unlike grpc.WithPerRPCCredentials, the interceptor attaches tokens to calls over insecure connections too.
+func UnaryClientInterceptor(ts TokenSource, opts ...ClientOption) grpc.UnaryClientInterceptor {
+	o := evaluateClientOpt(opts)
+	cache := newTokenCache(ts)
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if o.matcher != nil && !o.matcher.Match(ctx, interceptors.NewClientCallMeta(method, nil, req)) {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		t, err := cache.Token(ctx)
+		if err != nil {
+			return status.Errorf(codes.Unauthenticated, "getting auth token: %v", err)
+		}
+		err = invoker(withToken(ctx, t), method, req, reply, cc, opts...)
+		if status.Code(err) != codes.Unauthenticated {
+			return err
+		}
+		cache.invalidate(t)
+		if t, err = cache.Token(ctx); err != nil {
+			return status.Errorf(codes.Unauthenticated, "getting auth token: %v", err)
+		}
+		err = invoker(withToken(ctx, t), method, req, reply, cc, opts...)
+		if status.Code(err) == codes.Unauthenticated {
+			cache.invalidate(t)
+		}
+		return err
+	}
+}
+
+// StreamClientInterceptor returns a new stream client interceptor that sets the authorization header of calls to the
+// tokens of ts. Unless ts is a `CachedTokenSource`, tokens are cached and refreshed `DefaultRefreshBefore` before
+// they expire.
+// A stream failing to be created with the Unauthenticated code is retried once with a new token. As messages may have
+// been sent already, a stream failing later is not retried, but its token is not reused.
+func StreamClientInterceptor(ts TokenSource, opts ...ClientOption) grpc.StreamClientInterceptor {
+	o := evaluateClientOpt(opts)
+	cache := newTokenCache(ts)
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		if o.matcher != nil && !o.matcher.Match(ctx, interceptors.NewClientCallMeta(method, desc, nil)) {
+			return streamer(ctx, desc, cc, method, opts...)
+		}
+		t, err := cache.Token(ctx)
+		if err != nil {
+			return nil, status.Errorf(codes.Unauthenticated, "getting auth token: %v", err)
+		}
+		stream, err := streamer(withToken(ctx, t), desc, cc, method, opts...)
+		if status.Code(err) == codes.Unauthenticated {
+			cache.invalidate(t)
+			if t, err = cache.Token(ctx); err != nil {
+				return nil, status.Errorf(codes.Unauthenticated, "getting auth token: %v", err)
+			}
+			stream, err = streamer(withToken(ctx, t), desc, cc, method, opts...)
+			if status.Code(err) == codes.Unauthenticated {
+				cache.invalidate(t)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &tokenClientStream{ClientStream: stream, cache: cache, token: t}, nil
+	}
+}
+
+func withToken(ctx context.Context, t *Token) context.Context {
+	scheme := t.Scheme
+	if scheme == "" {
+		scheme = DefaultScheme
+	}
+	md, _ := metadata.FromOutgoingContext(ctx)
+	md = md.Copy()
+	md.Set(headerAuthorize, scheme+" "+t.Value)
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+// tokenClientStream invalidates its token if the stream fails with the Unauthenticated code.
+type tokenClientStream struct {
+	grpc.ClientStream
+	cache *tokenCache
+	token *Token
+}
+
+func (s *tokenClientStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	if status.Code(err) == codes.Unauthenticated {
+		s.cache.invalidate(s.token)
+	}
+	return err
+}
@@ -0,0 +1,304 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package auth
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/selector"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// countingTokenSource returns "token-<n>" on its n-th call, valid for ttl.
+type countingTokenSource struct {
+	ttl   time.Duration
+	calls int
+}
+
+func (s *countingTokenSource) Token(context.Context) (*Token, error) {
+	s.calls++
+	return &Token{Value: "token-" + strconv.Itoa(s.calls), Expiry: time.Now().Add(s.ttl)}, nil
+}
+
+// recordingInvoker records the authorization headers of calls, and fails the calls with the codes, in order.
+type recordingInvoker struct {
+	headers []string
+	codes   []codes.Code
+}
+
+func (r *recordingInvoker) invoke(ctx context.Context, _ string, _, _ any, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	r.headers = append(r.headers, md.Get(headerAuthorize)...)
+	if len(r.codes) == 0 {
+		return nil
+	}
+	c := r.codes[0]
+	r.codes = r.codes[1:]
+	return status.Error(c, "failed")
+}
+
+func TestUnaryClientInterceptor_CachesTokens(t *testing.T) {
+	src := &countingTokenSource{ttl: time.Hour}
+	inv := &recordingInvoker{}
+	interceptor := UnaryClientInterceptor(src)
+
+	for range 3 {
+		require.NoError(t, interceptor(context.Background(), "/my.Service/Method", nil, nil, nil, inv.invoke))
+	}
+	assert.Equal(t, 1, src.calls)
+	assert.Equal(t, []string{"Bearer token-1", "Bearer token-1", "Bearer token-1"}, inv.headers)
+}
+
+// waitRefresh waits for the refresh of c in progress, if any.
+func (c *tokenCache) waitRefresh() {
+	c.mu.Lock()
+	r := c.refresh
+	c.mu.Unlock()
+	if r != nil {
+		<-r.done
+	}
+}
+
+func TestUnaryClientInterceptor_RefreshesBeforeExpiry(t *testing.T) {
+	src := &countingTokenSource{ttl: DefaultRefreshBefore / 2}
+	cache := CachedTokenSource(src, DefaultRefreshBefore).(*tokenCache)
+	inv := &recordingInvoker{}
+	interceptor := UnaryClientInterceptor(cache)
+
+	// The token about to expire is used while it is refreshed in the background.
+	require.NoError(t, interceptor(context.Background(), "/my.Service/Method", nil, nil, nil, inv.invoke))
+	require.NoError(t, interceptor(context.Background(), "/my.Service/Method", nil, nil, nil, inv.invoke))
+	cache.waitRefresh()
+	require.NoError(t, interceptor(context.Background(), "/my.Service/Method", nil, nil, nil, inv.invoke))
+	assert.Equal(t, []string{"Bearer token-1", "Bearer token-1", "Bearer token-2"}, inv.headers)
+	cache.waitRefresh()
+	assert.Equal(t, 3, src.calls)
+}
+
+func TestCachedTokenSource_SlowRefresh(t *testing.T) {
+	release := make(chan struct{})
+	calls := 0
+	cache := CachedTokenSource(TokenSourceFunc(func(context.Context) (*Token, error) {
+		calls++
+		if calls > 1 {
+			<-release
+		}
+		return &Token{Value: "token-" + strconv.Itoa(calls), Expiry: time.Now().Add(DefaultRefreshBefore / 2)}, nil
+	}), DefaultRefreshBefore).(*tokenCache)
+
+	tok, err := cache.Token(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "token-1", tok.Value)
+
+	// The still valid token must be returned without waiting for the slow refresh.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	tok, err = cache.Token(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", tok.Value)
+
+	close(release)
+	cache.waitRefresh()
+	tok, err = cache.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token-2", tok.Value)
+	cache.waitRefresh()
+}
+
+func TestUnaryClientInterceptor_RefreshFailure(t *testing.T) {
+	fail := false
+	cache := CachedTokenSource(TokenSourceFunc(func(context.Context) (*Token, error) {
+		if fail {
+			return nil, errors.New("no network")
+		}
+		return &Token{Value: "token", Expiry: time.Now().Add(DefaultRefreshBefore / 2)}, nil
+	}), DefaultRefreshBefore).(*tokenCache)
+	interceptor := UnaryClientInterceptor(cache)
+
+	// A token about to expire is still used if it cannot be refreshed.
+	inv := &recordingInvoker{}
+	require.NoError(t, interceptor(context.Background(), "/my.Service/Method", nil, nil, nil, inv.invoke))
+	fail = true
+	require.NoError(t, interceptor(context.Background(), "/my.Service/Method", nil, nil, nil, inv.invoke))
+	cache.waitRefresh()
+	require.NoError(t, interceptor(context.Background(), "/my.Service/Method", nil, nil, nil, inv.invoke))
+	cache.waitRefresh()
+	assert.Equal(t, []string{"Bearer token", "Bearer token", "Bearer token"}, inv.headers)
+
+	// An expired one is not.
+	expiredFail := false
+	interceptor = UnaryClientInterceptor(TokenSourceFunc(func(context.Context) (*Token, error) {
+		if expiredFail {
+			return nil, errors.New("no network")
+		}
+		return &Token{Value: "token", Expiry: time.Now().Add(-time.Second)}, nil
+	}))
+	require.NoError(t, interceptor(context.Background(), "/my.Service/Method", nil, nil, nil, inv.invoke))
+	expiredFail = true
+	err := interceptor(context.Background(), "/my.Service/Method", nil, nil, nil, inv.invoke)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	assert.ErrorContains(t, err, "no network")
+}
+
+func TestCachedTokenSource_CancelledCaller(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	calls := 0
+	cache := CachedTokenSource(TokenSourceFunc(func(ctx context.Context) (*Token, error) {
+		calls++
+		close(started)
+		<-release
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return &Token{Value: "token"}, nil
+	}), DefaultRefreshBefore)
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	errc := make(chan error)
+	go func() {
+		_, err := cache.Token(cancelled)
+		errc <- err
+	}()
+	<-started
+	tokc := make(chan *Token)
+	go func() {
+		tok, err := cache.Token(context.Background())
+		assert.NoError(t, err)
+		tokc <- tok
+	}()
+
+	// The first caller giving up must not fail the refresh for the others.
+	cancel()
+	require.ErrorIs(t, <-errc, context.Canceled)
+	close(release)
+	assert.Equal(t, "token", (<-tokc).Value)
+	assert.Equal(t, 1, calls, "concurrent callers must share the refresh")
+}
+
+// oauth2TokenSourceFunc is a function implementing oauth2.TokenSource.
+type oauth2TokenSourceFunc func() (*oauth2.Token, error)
+
+func (f oauth2TokenSourceFunc) Token() (*oauth2.Token, error) { return f() }
+
+func TestUnaryClientInterceptor_OAuth2Refresh(t *testing.T) {
+	calls := 0
+	interceptor := UnaryClientInterceptor(OAuth2TokenSource(oauth2TokenSourceFunc(func() (*oauth2.Token, error) {
+		calls++
+		return &oauth2.Token{AccessToken: "token-" + strconv.Itoa(calls), TokenType: "Bearer", Expiry: time.Now().Add(time.Hour)}, nil
+	})))
+
+	inv := &recordingInvoker{codes: []codes.Code{codes.Unauthenticated}}
+	require.NoError(t, interceptor(context.Background(), "/my.Service/Method", nil, nil, nil, inv.invoke))
+	assert.Equal(t, []string{"Bearer token-1", "Bearer token-2"}, inv.headers, "a rejected token must be fetched again")
+}
+
+func TestUnaryClientInterceptor_ReplacesHeader(t *testing.T) {
+	inv := &recordingInvoker{}
+	ctx := metadata.AppendToOutgoingContext(context.Background(), headerAuthorize, "Bearer stale", "x-other", "kept")
+	require.NoError(t, UnaryClientInterceptor(&countingTokenSource{ttl: time.Hour})(ctx, "/my.Service/Method", nil, nil, nil,
+		func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			md, _ := metadata.FromOutgoingContext(ctx)
+			assert.Equal(t, []string{"kept"}, md.Get("x-other"))
+			return inv.invoke(ctx, method, req, reply, cc, opts...)
+		}))
+	assert.Equal(t, []string{"Bearer token-1"}, inv.headers)
+}
+
+func TestUnaryClientInterceptor_RetriesUnauthenticatedOnce(t *testing.T) {
+	src := &countingTokenSource{ttl: time.Hour}
+	interceptor := UnaryClientInterceptor(src)
+
+	inv := &recordingInvoker{codes: []codes.Code{codes.Unauthenticated}}
+	require.NoError(t, interceptor(context.Background(), "/my.Service/Method", nil, nil, nil, inv.invoke))
+	assert.Equal(t, []string{"Bearer token-1", "Bearer token-2"}, inv.headers)
+
+	inv = &recordingInvoker{codes: []codes.Code{codes.Unauthenticated, codes.Unauthenticated}}
+	err := interceptor(context.Background(), "/my.Service/Method", nil, nil, nil, inv.invoke)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	assert.Equal(t, []string{"Bearer token-2", "Bearer token-3"}, inv.headers)
+
+	inv = &recordingInvoker{codes: []codes.Code{codes.PermissionDenied}}
+	err = interceptor(context.Background(), "/my.Service/Method", nil, nil, nil, inv.invoke)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	assert.Equal(t, []string{"Bearer token-4"}, inv.headers, "only Unauthenticated calls must be retried")
+}
+
+func TestUnaryClientInterceptor_Matcher(t *testing.T) {
+	src := &countingTokenSource{ttl: time.Hour}
+	inv := &recordingInvoker{}
+	interceptor := UnaryClientInterceptor(src, WithMatcher(selector.MatchFunc(func(_ context.Context, c interceptors.CallMeta) bool {
+		return c.Service == "my.Private"
+	})))
+
+	require.NoError(t, interceptor(context.Background(), "/my.Public/Method", nil, nil, nil, inv.invoke))
+	assert.Empty(t, inv.headers)
+	assert.Equal(t, 0, src.calls)
+	require.NoError(t, interceptor(context.Background(), "/my.Private/Method", nil, nil, nil, inv.invoke))
+	assert.Equal(t, []string{"Bearer token-1"}, inv.headers)
+}
+
+func TestUnaryClientInterceptor_TokenSources(t *testing.T) {
+	inv := &recordingInvoker{}
+	require.NoError(t, UnaryClientInterceptor(OAuth2TokenSource(oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "abc", TokenType: "bearer"})))(
+		context.Background(), "/my.Service/Method", nil, nil, nil, inv.invoke))
+	require.NoError(t, UnaryClientInterceptor(TokenSourceFunc(func(context.Context) (*Token, error) {
+		return &Token{Scheme: "Basic", Value: "dXNlcjpwYXNz"}, nil
+	}))(context.Background(), "/my.Service/Method", nil, nil, nil, inv.invoke))
+	assert.Equal(t, []string{"Bearer abc", "Basic dXNlcjpwYXNz"}, inv.headers)
+
+	err := UnaryClientInterceptor(TokenSourceFunc(func(context.Context) (*Token, error) {
+		return nil, errors.New("no network")
+	}))(context.Background(), "/my.Service/Method", nil, nil, nil, func(context.Context, string, any, any, *grpc.ClientConn, ...grpc.CallOption) error {
+		t.Fatal("calls must not be invoked without token")
+		return nil
+	})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	assert.ErrorContains(t, err, "no network")
+}
+
+type failingClientStream struct {
+	grpc.ClientStream
+	code codes.Code
+}
+
+func (s *failingClientStream) RecvMsg(any) error { return status.Error(s.code, "failed") }
+
+func TestStreamClientInterceptor(t *testing.T) {
+	src := CachedTokenSource(&countingTokenSource{ttl: time.Hour}, DefaultRefreshBefore)
+	unary := UnaryClientInterceptor(src)
+	interceptor := StreamClientInterceptor(src)
+
+	var headers []string
+	fails := 1
+	streamer := func(ctx context.Context, _ *grpc.StreamDesc, _ *grpc.ClientConn, _ string, _ ...grpc.CallOption) (grpc.ClientStream, error) {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		headers = append(headers, md.Get(headerAuthorize)...)
+		if fails > 0 {
+			fails--
+			return nil, status.Error(codes.Unauthenticated, "failed")
+		}
+		return &failingClientStream{code: codes.Unauthenticated}, nil
+	}
+
+	stream, err := interceptor(context.Background(), &grpc.StreamDesc{ServerStreams: true}, nil, "/my.Service/Method", streamer)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Bearer token-1", "Bearer token-2"}, headers)
+
+	// The stream failing afterwards must not be retried, but its token must not be reused.
+	assert.Equal(t, codes.Unauthenticated, status.Code(stream.RecvMsg(nil)))
+	inv := &recordingInvoker{}
+	require.NoError(t, unary(context.Background(), "/my.Service/Method", nil, nil, nil, inv.invoke))
+	assert.Equal(t, []string{"Bearer token-3"}, inv.headers, "the stream and unary interceptors must share the cached source")
+}
@@ -2,7 +2,7 @@
 // Licensed under the Apache License 2.0.
 
 /*
-Package auth is a middleware that authenticates incoming gRPC requests.
+Package auth is a middleware that authenticates incoming gRPC requests, and attaches credentials to outgoing ones.
 
 `auth` a generic server-side auth middleware for gRPC.
 
@@ -15,8 +15,20 @@ The middleware takes a user-customizable `AuthFunc`, which can be customized to
 auth information from the request. The extracted information can be put in the `context.Context` of
 handlers downstream for retrieval.
 
+An `AuthFunc` can put the authenticated caller in the context with `InjectIdentity`, so that interceptors chained
+after it can retrieve it with `IdentityFromContext`. Interceptors chained before it, such as audit, can learn it
+once the call is done with `RecordIdentity`.
+
 It also allows for per-service implementation overrides of `AuthFunc`. See `ServiceAuthFuncOverride`.
 
+# Client Side Auth Middleware
+
+The client interceptors set the `authorization` header of calls to the tokens of a `TokenSource`, such as an
+oauth2.TokenSource wrapped with `OAuth2TokenSource`. Tokens are cached and refreshed in the background ahead of their
+expiry, the cached token being used until it expires if the refresh fails, and a call rejected with the
+Unauthenticated code is retried once with a new token. `WithMatcher` restricts the calls the header is attached to,
+with a selector.Matcher.
+
 Please see examples for simple examples of use.
 */
 package auth
@@ -5,13 +5,18 @@ package auth_test
 
 import (
 	"context"
+	"crypto/tls"
 	"log"
 
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors"
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/auth"
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/selector"
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/testing/testpb"
+	"golang.org/x/oauth2/clientcredentials"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/status"
 )
 
@@ -90,3 +95,23 @@ func Example_serverConfigWithAuthOverride() {
 		testpb.RegisterTestServiceServer(server, &gRPCServerAuthenticated{})
 	}
 }
+
+// Simple example of client initialization code, attaching the tokens of an OAuth2 client credentials flow to the calls
+// of a service.
+func Example_clientConfig() {
+	cfg := clientcredentials.Config{
+		ClientID:     "billing-client",
+		ClientSecret: "secret",
+		TokenURL:     "https://auth.example.com/oauth2/token",
+	}
+	tokens := auth.CachedTokenSource(auth.OAuth2TokenSource(cfg.TokenSource(context.Background())), auth.DefaultRefreshBefore)
+	billingOnly := auth.WithMatcher(selector.MatchFunc(func(_ context.Context, c interceptors.CallMeta) bool {
+		return c.Service == "my.Billing"
+	}))
+	_, _ = grpc.NewClient(
+		"billing.example.com:443",
+		grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})),
+		grpc.WithChainUnaryInterceptor(auth.UnaryClientInterceptor(tokens, billingOnly)),
+		grpc.WithChainStreamInterceptor(auth.StreamClientInterceptor(tokens, billingOnly)),
+	)
+}
@@ -0,0 +1,63 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package auth
+
+import (
+	"context"
+	"sync"
+)
+
+// Identity is the authenticated caller of a call. An `AuthFunc` can put it in the context with `InjectIdentity`, so
+// that the interceptors chained after the auth interceptor (e.g. audit) and handlers know who the caller is.
+type Identity interface {
+	// Subject returns the unique name of the caller, e.g. the subject of a token or the SPIFFE ID of a certificate.
+	Subject() string
+}
+
+type (
+	identityCtxMarker         struct{}
+	identityRecorderCtxMarker struct{}
+)
+
+var (
+	identityCtxMarkerKey         = &identityCtxMarker{}
+	identityRecorderCtxMarkerKey = &identityRecorderCtxMarker{}
+)
+
+// identityRecorder keeps the last identity injected in the children of a context, see `RecordIdentity`.
+type identityRecorder struct {
+	mu sync.Mutex
+	id Identity
+}
+
+// InjectIdentity returns a new context carrying id. The identity is also recorded for the interceptors that called
+// `RecordIdentity` on a parent of ctx.
+func InjectIdentity(ctx context.Context, id Identity) context.Context {
+	if r, ok := ctx.Value(identityRecorderCtxMarkerKey).(*identityRecorder); ok {
+		r.mu.Lock()
+		r.id = id
+		r.mu.Unlock()
+	}
+	return context.WithValue(ctx, identityCtxMarkerKey, id)
+}
+
+// RecordIdentity returns a new context recording the identity injected with `InjectIdentity` into it or its children,
+// and a function returning the last identity recorded, if any.
+//
+// This lets interceptors chained before the auth interceptor, such as audit, know the caller once the call is done,
+// including when the call is then denied, e.g. by an authorization interceptor chained after the auth one.
+func RecordIdentity(ctx context.Context) (context.Context, func() (Identity, bool)) {
+	r := &identityRecorder{}
+	return context.WithValue(ctx, identityRecorderCtxMarkerKey, r), func() (Identity, bool) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		return r.id, r.id != nil
+	}
+}
+
+// IdentityFromContext returns the identity put in ctx with `InjectIdentity`, if any.
+func IdentityFromContext(ctx context.Context) (Identity, bool) {
+	id, ok := ctx.Value(identityCtxMarkerKey).(Identity)
+	return id, ok
+}
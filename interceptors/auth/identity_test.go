@@ -0,0 +1,43 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type subject string
+
+func (s subject) Subject() string { return string(s) }
+
+func TestIdentityFromContext(t *testing.T) {
+	_, ok := IdentityFromContext(context.Background())
+	assert.False(t, ok)
+
+	id, ok := IdentityFromContext(InjectIdentity(context.Background(), subject("alice")))
+	require.True(t, ok)
+	assert.Equal(t, "alice", id.Subject())
+}
+
+func TestRecordIdentity(t *testing.T) {
+	ctx, recorded := RecordIdentity(context.Background())
+	_, ok := recorded()
+	assert.False(t, ok)
+
+	// Identities injected in children of the context are recorded, the last one wins.
+	child, cancel := context.WithCancel(ctx)
+	defer cancel()
+	child = InjectIdentity(child, subject("alice"))
+	_ = InjectIdentity(child, subject("bob"))
+	id, ok := recorded()
+	require.True(t, ok)
+	assert.Equal(t, "bob", id.Subject())
+
+	_, ok = IdentityFromContext(ctx)
+	assert.False(t, ok, "the recording context itself should not carry the identity")
+}
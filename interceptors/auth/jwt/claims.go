@@ -0,0 +1,131 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package jwt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/auth"
+)
+
+var _ auth.Identity = &Claims{}
+
+// Claims are the verified claims of a token. They implement auth.Identity, with the "sub" claim as subject.
+type Claims struct {
+	Issuer    string
+	Audience  []string
+	ExpiresAt time.Time
+	NotBefore time.Time
+	IssuedAt  time.Time
+	ID        string
+	// Raw holds all the claims of the token, including the registered ones above, as decoded from JSON with numbers
+	// kept as json.Number.
+	Raw map[string]any
+
+	subject string
+}
+
+// Subject returns the "sub" claim.
+func (c *Claims) Subject() string {
+	return c.subject
+}
+
+// StringClaim returns the value of the claim name if it is a string.
+func (c *Claims) StringClaim(name string) (string, bool) {
+	s, ok := c.Raw[name].(string)
+	return s, ok
+}
+
+// StringListClaim returns the values of the claim name, if it is a string or an array of strings. A string is split
+// on spaces, as for the "scope" claim (RFC 8693).
+func (c *Claims) StringListClaim(name string) []string {
+	switch v := c.Raw[name].(type) {
+	case string:
+		return strings.Fields(v)
+	case []any:
+		s := make([]string, 0, len(v))
+		for _, e := range v {
+			if es, ok := e.(string); ok {
+				s = append(s, es)
+			}
+		}
+		return s
+	default:
+		return nil
+	}
+}
+
+// Attributes returns all the claims, e.g. for the conditions of authz policies.
+func (c *Claims) Attributes() map[string]any {
+	return c.Raw
+}
+
+// parseClaims decodes the claims of a token payload.
+func parseClaims(payload []byte) (*Claims, error) {
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	c := &Claims{}
+	if err := dec.Decode(&c.Raw); err != nil {
+		return nil, fmt.Errorf("jwt: invalid claims: %w", err)
+	}
+	var ok bool
+	for name, dst := range map[string]*string{"iss": &c.Issuer, "sub": &c.subject, "jti": &c.ID} {
+		if v, exists := c.Raw[name]; exists {
+			if *dst, ok = v.(string); !ok {
+				return nil, fmt.Errorf("jwt: claim %q is not a string", name)
+			}
+		}
+	}
+	for name, dst := range map[string]*time.Time{"exp": &c.ExpiresAt, "nbf": &c.NotBefore, "iat": &c.IssuedAt} {
+		if v, exists := c.Raw[name]; exists {
+			n, isNumber := v.(json.Number)
+			if !isNumber {
+				return nil, fmt.Errorf("jwt: claim %q is not a number", name)
+			}
+			f, err := n.Float64()
+			if err != nil {
+				return nil, fmt.Errorf("jwt: claim %q is not a number: %w", name, err)
+			}
+			sec, frac := math.Modf(f)
+			*dst = time.Unix(int64(sec), int64(frac*1e9))
+		}
+	}
+	switch aud := c.Raw["aud"].(type) {
+	case nil:
+	case string:
+		c.Audience = []string{aud}
+	case []any:
+		for _, a := range aud {
+			s, isString := a.(string)
+			if !isString {
+				return nil, fmt.Errorf("jwt: claim \"aud\" is not an array of strings")
+			}
+			c.Audience = append(c.Audience, s)
+		}
+	default:
+		return nil, fmt.Errorf("jwt: claim \"aud\" is not a string or an array of strings")
+	}
+	return c, nil
+}
+
+type claimsCtxMarker struct{}
+
+var claimsCtxMarkerKey = &claimsCtxMarker{}
+
+// ClaimsFromContext returns the verified claims put in the context by the `AuthFunc`, if any.
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	c, ok := ctx.Value(claimsCtxMarkerKey).(*Claims)
+	return c, ok
+}
+
+// InjectClaims returns a new context carrying c, both as claims and as the auth.Identity of the caller.
+func InjectClaims(ctx context.Context, c *Claims) context.Context {
+	return auth.InjectIdentity(context.WithValue(ctx, claimsCtxMarkerKey, c), c)
+}
@@ -0,0 +1,19 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+/*
+Package jwt is an auth.AuthFunc authenticating calls with JSON Web Tokens (RFC 7519) in bearer authorization headers.
+
+Tokens signed with RS256, ES256 and EdDSA (and HS256 if enabled with `WithAlgorithms`) are verified against a
+`KeySet`: a `StaticKeySet`, a JWKS document parsed with `ParseJWKS`, or a `JWKSFile` periodically reloaded from disk to
+rotate keys. The "exp", "nbf" and "iat" claims are checked with a clock skew tolerance, and the "iss" claim against
+the issuers set with `WithIssuer`.
+
+The "aud" claim must contain one of the audiences set with `WithAudience`. Without it, all tokens are rejected, unless
+`WithAnyAudience` explicitly accepts the tokens issued for any service.
+
+The verified claims are put in the context of handlers, see `ClaimsFromContext`, and implement auth.Identity.
+
+Please see examples for simple examples of use.
+*/
+package jwt
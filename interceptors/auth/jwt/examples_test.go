@@ -0,0 +1,46 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package jwt_test
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/auth"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/auth/jwt"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/testing/testpb"
+	"google.golang.org/grpc"
+)
+
+type server struct {
+	testpb.UnimplementedTestServiceServer
+}
+
+// Ping replies with the subject of the verified token of the caller.
+func (s *server) Ping(ctx context.Context, _ *testpb.PingRequest) (*testpb.PingResponse, error) {
+	claims, _ := jwt.ClaimsFromContext(ctx)
+	return &testpb.PingResponse{Value: claims.Subject()}, nil
+}
+
+// Simple example of server initialization code, verifying tokens with the keys of a JWKS file reloaded every minute.
+func Example_serverConfig() {
+	keys, err := jwt.NewJWKSFile("/etc/grpc/jwks.json", time.Minute, func(err error) {
+		log.Printf("failed to reload JWKS: %v", err)
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer keys.Close()
+
+	authFunc := jwt.AuthFunc(keys,
+		jwt.WithIssuer("https://issuer.example.com"),
+		jwt.WithAudience("my-service"),
+	)
+	srv := grpc.NewServer(
+		grpc.StreamInterceptor(auth.StreamServerInterceptor(authFunc)),
+		grpc.UnaryInterceptor(auth.UnaryServerInterceptor(authFunc)),
+	)
+	testpb.RegisterTestServiceServer(srv, &server{})
+}
@@ -0,0 +1,252 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Supported signature algorithms.
+const (
+	RS256 = "RS256"
+	ES256 = "ES256"
+	EdDSA = "EdDSA"
+	HS256 = "HS256"
+)
+
+// DefaultClockSkew is the default tolerance of the time based claims, see `WithClockSkew`.
+const DefaultClockSkew = time.Minute
+
+var (
+	// ErrMalformed is returned for tokens that are not well-formed JWTs.
+	ErrMalformed = errors.New("jwt: malformed token")
+	// ErrInvalidSignature is returned for tokens whose signature cannot be verified with any key of the key set.
+	ErrInvalidSignature = errors.New("jwt: invalid signature")
+	// ErrExpired is returned for tokens past their "exp" claim, or without one.
+	ErrExpired = errors.New("jwt: token is expired")
+	// ErrNotValidYet is returned for tokens before their "nbf" or "iat" claims.
+	ErrNotValidYet = errors.New("jwt: token is not valid yet")
+	// ErrInvalidIssuer is returned for tokens whose "iss" claim is not an expected issuer.
+	ErrInvalidIssuer = errors.New("jwt: invalid issuer")
+	// ErrInvalidAudience is returned for tokens whose "aud" claim has no expected audience.
+	ErrInvalidAudience = errors.New("jwt: invalid audience")
+)
+
+type options struct {
+	algorithms  []string
+	issuers     []string
+	audiences   []string
+	anyAudience bool
+	clockSkew   time.Duration
+	now         func() time.Time
+}
+
+// Option configures the verification of tokens.
+type Option func(*options)
+
+// WithAlgorithms sets the accepted signature algorithms, the asymmetric RS256, ES256 and EdDSA by default. HS256 must
+// be enabled explicitly, e.g. WithAlgorithms(jwt.HS256), as anyone able to verify tokens with its shared secret can
+// also sign them.
+func WithAlgorithms(algs ...string) Option {
+	return func(o *options) {
+		o.algorithms = algs
+	}
+}
+
+// WithIssuer only accepts tokens with one of the given issuers in the "iss" claim.
+func WithIssuer(issuers ...string) Option {
+	return func(o *options) {
+		o.issuers = append(o.issuers, issuers...)
+	}
+}
+
+// WithAudience only accepts tokens with one of the given audiences in the "aud" claim. Either this option or
+// `WithAnyAudience` is required: without them, all tokens are rejected with `ErrInvalidAudience`.
+func WithAudience(audiences ...string) Option {
+	return func(o *options) {
+		o.audiences = append(o.audiences, audiences...)
+	}
+}
+
+// WithAnyAudience accepts tokens whatever their "aud" claim, when no audience is set with `WithAudience`. Only use it
+// if the keys only sign tokens meant for this service, otherwise the tokens issued for other services are accepted.
+func WithAnyAudience() Option {
+	return func(o *options) {
+		o.anyAudience = true
+	}
+}
+
+// WithClockSkew sets the tolerance applied to the "exp", "nbf" and "iat" claims, `DefaultClockSkew` by default.
+func WithClockSkew(d time.Duration) Option {
+	return func(o *options) {
+		o.clockSkew = d
+	}
+}
+
+// Verifier verifies tokens and their claims.
+type Verifier struct {
+	keys KeySet
+	opts *options
+}
+
+// NewVerifier returns a Verifier of tokens signed with the keys of keys.
+func NewVerifier(keys KeySet, opts ...Option) *Verifier {
+	o := &options{
+		algorithms: []string{RS256, ES256, EdDSA},
+		clockSkew:  DefaultClockSkew,
+		now:        time.Now,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return &Verifier{keys: keys, opts: o}
+}
+
+// Verify verifies the signature of token and its claims, and returns them. Tokens must have an "exp" claim.
+func (v *Verifier) Verify(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformed
+	}
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err = json.Unmarshal(headerJSON, &header); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+	if !slices.Contains(v.opts.algorithms, header.Alg) {
+		return nil, fmt.Errorf("jwt: algorithm %q is not accepted", header.Alg)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+	if !v.verifySignature(header.Alg, header.Kid, parts[0]+"."+parts[1], sig) {
+		return nil, ErrInvalidSignature
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+	claims, err := parseClaims(payload)
+	if err != nil {
+		return nil, err
+	}
+	if err = v.validate(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func (v *Verifier) verifySignature(alg, kid, signingInput string, sig []byte) bool {
+	for _, k := range v.keys.Keys() {
+		if (k.ID != "" && kid != "" && k.ID != kid) || (k.Algorithm != "" && k.Algorithm != alg) {
+			continue
+		}
+		if verify(alg, k.Key, signingInput, sig) {
+			return true
+		}
+	}
+	return false
+}
+
+// verify reports whether sig is the signature of signingInput with alg and key. The type of key must match alg,
+// so that a public key can never be used as an HMAC secret.
+func verify(alg string, key any, signingInput string, sig []byte) bool {
+	digest := sha256.Sum256([]byte(signingInput))
+	switch alg {
+	case RS256:
+		k, ok := key.(*rsa.PublicKey)
+		return ok && rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], sig) == nil
+	case ES256:
+		k, ok := key.(*ecdsa.PublicKey)
+		if !ok || k.Curve != elliptic.P256() || len(sig) != 64 {
+			return false
+		}
+		r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+		return ecdsa.Verify(k, digest[:], r, s)
+	case EdDSA:
+		k, ok := key.(ed25519.PublicKey)
+		return ok && len(k) == ed25519.PublicKeySize && ed25519.Verify(k, []byte(signingInput), sig)
+	case HS256:
+		k, ok := key.([]byte)
+		if !ok || len(k) == 0 {
+			return false
+		}
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(signingInput))
+		return hmac.Equal(mac.Sum(nil), sig)
+	default:
+		return false
+	}
+}
+
+func (v *Verifier) validate(c *Claims) error {
+	now := v.opts.now()
+	if c.ExpiresAt.IsZero() || !now.Before(c.ExpiresAt.Add(v.opts.clockSkew)) {
+		return ErrExpired
+	}
+	if !c.NotBefore.IsZero() && now.Add(v.opts.clockSkew).Before(c.NotBefore) {
+		return ErrNotValidYet
+	}
+	if !c.IssuedAt.IsZero() && now.Add(v.opts.clockSkew).Before(c.IssuedAt) {
+		return ErrNotValidYet
+	}
+	if len(v.opts.issuers) > 0 && !slices.Contains(v.opts.issuers, c.Issuer) {
+		return ErrInvalidIssuer
+	}
+	if len(v.opts.audiences) == 0 {
+		if !v.opts.anyAudience {
+			return fmt.Errorf("%w: no audience is expected, see WithAudience", ErrInvalidAudience)
+		}
+	} else if !slices.ContainsFunc(c.Audience, func(a string) bool {
+		return slices.Contains(v.opts.audiences, a)
+	}) {
+		return ErrInvalidAudience
+	}
+	return nil
+}
+
+// AuthFunc returns an auth.AuthFunc verifying the bearer token of calls with keys, see `Verifier`. The verified
+// claims are put in the context of the call, see `ClaimsFromContext`, along with the auth.Identity of the caller.
+// Calls without a valid token fail with the Unauthenticated code.
+func AuthFunc(keys KeySet, opts ...Option) auth.AuthFunc {
+	v := NewVerifier(keys, opts...)
+	return func(ctx context.Context) (context.Context, error) {
+		token, err := auth.AuthFromMD(ctx, "bearer")
+		if err != nil {
+			return nil, err
+		}
+		claims, err := v.Verify(token)
+		if err != nil {
+			return nil, status.Errorf(codes.Unauthenticated, "invalid auth token: %v", err)
+		}
+		return InjectClaims(ctx, claims), nil
+	}
+}
@@ -0,0 +1,326 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var now = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+type testKeys struct {
+	rsa     *rsa.PrivateKey
+	ecdsa   *ecdsa.PrivateKey
+	ed25519 ed25519.PrivateKey
+	secret  []byte
+}
+
+func newTestKeys(t *testing.T) *testKeys {
+	t.Helper()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	return &testKeys{rsa: rsaKey, ecdsa: ecKey, ed25519: edKey, secret: []byte("0123456789abcdef0123456789abcdef")}
+}
+
+func (k *testKeys) public() StaticKeySet {
+	return StaticKeySet{
+		{ID: "rsa", Key: &k.rsa.PublicKey},
+		{ID: "ec", Key: &k.ecdsa.PublicKey},
+		{ID: "ed", Key: k.ed25519.Public()},
+		{ID: "hmac", Key: k.secret},
+	}
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// sign returns a token with the given claims, signed by the key of alg.
+func (k *testKeys) sign(t *testing.T, alg, kid string, claims map[string]any) string {
+	t.Helper()
+
+	header, err := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	require.NoError(t, err)
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+	input := b64(header) + "." + b64(payload)
+	digest := sha256.Sum256([]byte(input))
+
+	var sig []byte
+	switch alg {
+	case RS256:
+		sig, err = rsa.SignPKCS1v15(rand.Reader, k.rsa, crypto.SHA256, digest[:])
+		require.NoError(t, err)
+	case ES256:
+		r, s, signErr := ecdsa.Sign(rand.Reader, k.ecdsa, digest[:])
+		require.NoError(t, signErr)
+		sig = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	case EdDSA:
+		sig = ed25519.Sign(k.ed25519, []byte(input))
+	case HS256:
+		mac := hmac.New(sha256.New, k.secret)
+		mac.Write([]byte(input))
+		sig = mac.Sum(nil)
+	}
+	return input + "." + b64(sig)
+}
+
+func validClaims() map[string]any {
+	return map[string]any{
+		"iss":   "https://issuer.example.com",
+		"sub":   "alice",
+		"aud":   []string{"api", "other"},
+		"exp":   now.Add(time.Hour).Unix(),
+		"nbf":   now.Add(-time.Hour).Unix(),
+		"iat":   now.Add(-time.Hour).Unix(),
+		"scope": "read write",
+		"roles": []string{"admin"},
+	}
+}
+
+func newTestVerifier(keys KeySet, opts ...Option) *Verifier {
+	v := NewVerifier(keys, append([]Option{WithAnyAudience()}, opts...)...)
+	v.opts.now = func() time.Time { return now }
+	return v
+}
+
+func TestVerifier_Algorithms(t *testing.T) {
+	keys := newTestKeys(t)
+	v := newTestVerifier(keys.public(), WithAlgorithms(RS256, ES256, EdDSA, HS256))
+	for _, alg := range []string{RS256, ES256, EdDSA, HS256} {
+		t.Run(alg, func(t *testing.T) {
+			claims, err := v.Verify(keys.sign(t, alg, "", validClaims()))
+			require.NoError(t, err)
+			assert.Equal(t, "alice", claims.Subject())
+			assert.Equal(t, "https://issuer.example.com", claims.Issuer)
+			assert.Equal(t, []string{"api", "other"}, claims.Audience)
+			assert.Equal(t, now.Add(time.Hour), claims.ExpiresAt.UTC())
+			assert.Equal(t, []string{"read", "write"}, claims.StringListClaim("scope"))
+			assert.Equal(t, []string{"admin"}, claims.StringListClaim("roles"))
+			sub, ok := claims.StringClaim("sub")
+			assert.True(t, ok)
+			assert.Equal(t, "alice", sub)
+		})
+	}
+}
+
+func TestVerifier_Defaults(t *testing.T) {
+	keys := newTestKeys(t)
+
+	_, err := newTestVerifier(keys.public()).Verify(keys.sign(t, HS256, "", validClaims()))
+	require.ErrorContains(t, err, `algorithm "HS256" is not accepted`, "HS256 must be enabled explicitly")
+
+	v := NewVerifier(keys.public())
+	v.opts.now = func() time.Time { return now }
+	_, err = v.Verify(keys.sign(t, RS256, "", validClaims()))
+	require.ErrorIs(t, err, ErrInvalidAudience, "an audience must be configured")
+}
+
+func TestVerifier_Signature(t *testing.T) {
+	keys := newTestKeys(t)
+	other := newTestKeys(t)
+	v := newTestVerifier(keys.public())
+
+	token := keys.sign(t, RS256, "", validClaims())
+	parts := strings.Split(token, ".")
+	tampered := validClaims()
+	tampered["sub"] = "mallory"
+	payload, err := json.Marshal(tampered)
+	require.NoError(t, err)
+
+	for _, tcase := range []struct {
+		name  string
+		token string
+		err   error
+	}{
+		{name: "other key", token: other.sign(t, ES256, "", validClaims()), err: ErrInvalidSignature},
+		{name: "tampered claims", token: parts[0] + "." + b64(payload) + "." + parts[2], err: ErrInvalidSignature},
+		{name: "key ID mismatch", token: keys.sign(t, RS256, "ec", validClaims()), err: ErrInvalidSignature},
+		{name: "not a JWT", token: "abc", err: ErrMalformed},
+		{name: "bad encoding", token: "a.b.!", err: ErrMalformed},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			_, err := v.Verify(tcase.token)
+			require.ErrorIs(t, err, tcase.err)
+		})
+	}
+
+	_, err = v.Verify(b64([]byte(`{"alg":"none"}`)) + "." + parts[1] + ".")
+	require.ErrorContains(t, err, `algorithm "none" is not accepted`)
+
+	// A public key must never be usable as an HMAC secret.
+	pub, err := x509.MarshalPKIXPublicKey(&keys.rsa.PublicKey)
+	require.NoError(t, err)
+	attacker := &testKeys{secret: pub}
+	_, err = newTestVerifier(StaticKeySet{{Key: &keys.rsa.PublicKey}}, WithAlgorithms(RS256, HS256)).Verify(attacker.sign(t, HS256, "", validClaims()))
+	require.ErrorIs(t, err, ErrInvalidSignature)
+
+	_, err = newTestVerifier(keys.public(), WithAlgorithms(RS256)).Verify(keys.sign(t, HS256, "", validClaims()))
+	require.ErrorContains(t, err, `algorithm "HS256" is not accepted`)
+}
+
+func TestVerifier_Claims(t *testing.T) {
+	keys := newTestKeys(t)
+	v := newTestVerifier(keys.public(),
+		WithIssuer("https://issuer.example.com"),
+		WithAudience("api"),
+		WithClockSkew(30*time.Second),
+	)
+	with := func(name string, value any) map[string]any {
+		c := validClaims()
+		if value == nil {
+			delete(c, name)
+		} else {
+			c[name] = value
+		}
+		return c
+	}
+
+	for _, tcase := range []struct {
+		name   string
+		claims map[string]any
+		err    error
+	}{
+		{name: "valid", claims: validClaims()},
+		{name: "expired within skew", claims: with("exp", now.Add(-20*time.Second).Unix())},
+		{name: "expired", claims: with("exp", now.Add(-time.Minute).Unix()), err: ErrExpired},
+		{name: "no expiration", claims: with("exp", nil), err: ErrExpired},
+		{name: "not before within skew", claims: with("nbf", now.Add(20*time.Second).Unix())},
+		{name: "not before", claims: with("nbf", now.Add(time.Minute).Unix()), err: ErrNotValidYet},
+		{name: "issued in the future", claims: with("iat", now.Add(time.Minute).Unix()), err: ErrNotValidYet},
+		{name: "other issuer", claims: with("iss", "https://evil.example.com"), err: ErrInvalidIssuer},
+		{name: "single audience", claims: with("aud", "api")},
+		{name: "other audience", claims: with("aud", "other"), err: ErrInvalidAudience},
+		{name: "no audience", claims: with("aud", nil), err: ErrInvalidAudience},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			_, err := v.Verify(keys.sign(t, ES256, "ec", tcase.claims))
+			if tcase.err == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, tcase.err)
+		})
+	}
+
+	_, err := v.Verify(keys.sign(t, ES256, "ec", with("sub", 42)))
+	require.ErrorContains(t, err, `claim "sub" is not a string`)
+}
+
+func jwks(t *testing.T, keys *testKeys) []byte {
+	t.Helper()
+
+	ecPub := keys.ecdsa.PublicKey
+	ecBytes, err := ecPub.ECDH()
+	require.NoError(t, err)
+	raw := ecBytes.Bytes()
+	doc, err := json.Marshal(map[string]any{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "rsa", "alg": "RS256", "use": "sig", "n": b64(keys.rsa.N.Bytes()), "e": b64([]byte{1, 0, 1})},
+		{"kty": "EC", "kid": "ec", "crv": "P-256", "x": b64(raw[1:33]), "y": b64(raw[33:])},
+		{"kty": "OKP", "kid": "ed", "crv": "Ed25519", "x": b64(keys.ed25519.Public().(ed25519.PublicKey))},
+		{"kty": "oct", "kid": "hmac", "k": b64(keys.secret)},
+		{"kty": "RSA", "kid": "enc", "use": "enc", "n": "AQAB", "e": "AQAB"},
+		{"kty": "EC", "kid": "p384", "crv": "P-384"},
+	}})
+	require.NoError(t, err)
+	return doc
+}
+
+func TestParseJWKS(t *testing.T) {
+	keys := newTestKeys(t)
+	set, err := ParseJWKS(strings.NewReader(string(jwks(t, keys))))
+	require.NoError(t, err)
+	require.Len(t, set, 4, "encryption and unsupported keys must be skipped")
+	assert.Equal(t, RS256, set[0].Algorithm)
+
+	v := newTestVerifier(set, WithAlgorithms(RS256, ES256, EdDSA, HS256))
+	for _, alg := range []string{RS256, ES256, EdDSA, HS256} {
+		_, err = v.Verify(keys.sign(t, alg, "", validClaims()))
+		require.NoError(t, err, alg)
+	}
+
+	_, err = ParseJWKS(strings.NewReader(`{"keys":[{"kty":"EC","crv":"P-256","x":"AQ","y":"AQ"}]}`))
+	require.Error(t, err)
+}
+
+func TestJWKSFile(t *testing.T) {
+	keys := newTestKeys(t)
+	rotated := newTestKeys(t)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, jwks(t, keys), 0o600))
+
+	f, err := NewJWKSFile(path, 10*time.Millisecond, nil)
+	require.NoError(t, err)
+	defer f.Close()
+	v := newTestVerifier(f)
+	_, err = v.Verify(keys.sign(t, EdDSA, "ed", validClaims()))
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(path, jwks(t, rotated), 0o600))
+	require.Eventually(t, func() bool {
+		_, verifyErr := v.Verify(rotated.sign(t, EdDSA, "ed", validClaims()))
+		return verifyErr == nil
+	}, 5*time.Second, 10*time.Millisecond)
+	_, err = v.Verify(keys.sign(t, EdDSA, "ed", validClaims()))
+	require.ErrorIs(t, err, ErrInvalidSignature)
+
+	_, err = NewJWKSFile(filepath.Join(t.TempDir(), "missing.json"), 0, nil)
+	require.Error(t, err)
+}
+
+func TestAuthFunc(t *testing.T) {
+	keys := newTestKeys(t)
+	authFunc := AuthFunc(keys.public(), WithAudience("api"))
+
+	claims := validClaims()
+	claims["exp"] = time.Now().Add(time.Hour).Unix()
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+keys.sign(t, RS256, "rsa", claims)))
+	newCtx, err := authFunc(ctx)
+	require.NoError(t, err)
+	c, ok := ClaimsFromContext(newCtx)
+	require.True(t, ok)
+	assert.Equal(t, "alice", c.Subject())
+	id, ok := auth.IdentityFromContext(newCtx)
+	require.True(t, ok)
+	assert.Equal(t, "alice", id.Subject())
+
+	for _, md := range []metadata.MD{
+		{},
+		metadata.Pairs("authorization", "Bearer "+keys.sign(t, RS256, "rsa", map[string]any{"sub": "alice"})),
+		metadata.Pairs("authorization", fmt.Sprintf("Basic %s", b64([]byte("alice:secret")))),
+	} {
+		_, err = authFunc(metadata.NewIncomingContext(context.Background(), md))
+		require.Error(t, err)
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	}
+}
@@ -0,0 +1,207 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package jwt
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+)
+
+// Key is a key verifying the signature of tokens.
+type Key struct {
+	// ID is matched with the "kid" header of tokens, if both are set.
+	ID string
+	// Algorithm restricts the key to tokens signed with this algorithm, if set.
+	Algorithm string
+	// Key is an *rsa.PublicKey (RS256), an *ecdsa.PublicKey on the P-256 curve (ES256), an ed25519.PublicKey (EdDSA)
+	// or a []byte secret (HS256).
+	Key any
+}
+
+// KeySet provides the keys tokens are verified with.
+type KeySet interface {
+	Keys() []Key
+}
+
+// StaticKeySet is a KeySet with a fixed list of keys.
+type StaticKeySet []Key
+
+func (s StaticKeySet) Keys() []Key {
+	return s
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	K   string `json:"k"`
+}
+
+// ParseJWKS parses a JSON Web Key Set document (RFC 7517). RSA, EC P-256, Ed25519 and symmetric keys are supported,
+// other keys and the keys not meant for signatures are skipped.
+func ParseJWKS(r io.Reader) (StaticKeySet, error) {
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("jwt: parsing JWKS: %w", err)
+	}
+	keys := make(StaticKeySet, 0, len(doc.Keys))
+	for i, k := range doc.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.key()
+		if err != nil {
+			return nil, fmt.Errorf("jwt: parsing JWKS key %d (kid %q): %w", i, k.Kid, err)
+		}
+		if key != nil {
+			keys = append(keys, Key{ID: k.Kid, Algorithm: k.Alg, Key: key})
+		}
+	}
+	return keys, nil
+}
+
+// key returns the key of k, or nil if it is not supported.
+func (k jwk) key() (any, error) {
+	switch {
+	case k.Kty == "RSA":
+		n, err := decodeInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case k.Kty == "EC" && k.Crv == "P-256":
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != 32 || len(y) != 32 {
+			return nil, fmt.Errorf("invalid P-256 coordinates")
+		}
+		// Check that the point is on the curve.
+		if _, err = ecdh.P256().NewPublicKey(append(append([]byte{4}, x...), y...)); err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	case k.Kty == "OKP" && k.Crv == "Ed25519":
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 key size %d", len(x))
+		}
+		return ed25519.PublicKey(x), nil
+	case k.Kty == "oct":
+		return base64.RawURLEncoding.DecodeString(k.K)
+	default:
+		return nil, nil
+	}
+}
+
+func decodeInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// JWKSFile is a KeySet loaded from a JWKS document in a file, optionally reloaded periodically so that keys can be
+// rotated without restarting.
+type JWKSFile struct {
+	path    string
+	onError func(error)
+
+	mu   sync.RWMutex
+	keys StaticKeySet
+
+	stop chan struct{}
+	once sync.Once
+}
+
+// NewJWKSFile loads the JWKS document at path. If reloadInterval is not zero, the document is reloaded at that
+// interval until Close is called. If a reload fails, the previous keys are kept and onError, if not nil, is called.
+func NewJWKSFile(path string, reloadInterval time.Duration, onError func(error)) (*JWKSFile, error) {
+	f := &JWKSFile{path: path, onError: onError, stop: make(chan struct{})}
+	if err := f.Reload(); err != nil {
+		return nil, err
+	}
+	if reloadInterval > 0 {
+		go f.reloadEvery(reloadInterval)
+	}
+	return f, nil
+}
+
+func (f *JWKSFile) reloadEvery(interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-f.stop:
+			return
+		case <-t.C:
+			if err := f.Reload(); err != nil && f.onError != nil {
+				f.onError(err)
+			}
+		}
+	}
+}
+
+// Reload loads the JWKS document again.
+func (f *JWKSFile) Reload() error {
+	file, err := os.Open(f.path)
+	if err != nil {
+		return fmt.Errorf("jwt: loading JWKS: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+	keys, err := ParseJWKS(file)
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	f.keys = keys
+	f.mu.Unlock()
+	return nil
+}
+
+func (f *JWKSFile) Keys() []Key {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.keys
+}
+
+// Close stops reloading the document.
+func (f *JWKSFile) Close() {
+	f.once.Do(func() { close(f.stop) })
+}
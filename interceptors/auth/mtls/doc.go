@@ -0,0 +1,18 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+/*
+Package mtls is an auth.AuthFunc authenticating calls by the client certificate of mutual TLS connections.
+
+The identity of the caller is taken from its certificate, as verified by the TLS credentials of the server: its
+SPIFFE ID URI SAN, DNS SANs and subject common name. Per method rules allow identities to call methods, and the
+identity is put in the context of handlers, see `IdentityFromContext`. SPIFFE ID patterns are only matched against
+the SPIFFE ID and the other patterns against the DNS SANs; the common name is ignored if the certificate has SANs.
+A "*" wildcard matches a single path segment or DNS label.
+
+The server must use TLS credentials requiring and verifying client certificates, i.e. with
+`ClientAuth: tls.RequireAndVerifyClientCert` and the pool of client CAs.
+
+Please see examples for simple examples of use.
+*/
+package mtls
@@ -0,0 +1,45 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package mtls_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"log"
+	"os"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/auth"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/auth/mtls"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// Simple example of server initialization code, requiring client certificates and allowing callers per method.
+func Example_serverConfig() {
+	cert, err := tls.LoadX509KeyPair("/etc/grpc/server.crt", "/etc/grpc/server.key")
+	if err != nil {
+		log.Fatal(err)
+	}
+	caPEM, err := os.ReadFile("/etc/grpc/client-ca.crt")
+	if err != nil {
+		log.Fatal(err)
+	}
+	clientCAs := x509.NewCertPool()
+	clientCAs.AppendCertsFromPEM(caPEM)
+
+	authFunc := mtls.AuthFunc(
+		mtls.Rule{Method: "/my.Billing/Charge", Identities: []string{"spiffe://example.org/ns/prod/sa/checkout"}},
+		mtls.Rule{Method: "/my.Billing/*", Identities: []string{"spiffe://example.org/ns/prod/sa/*"}},
+	)
+	_ = grpc.NewServer(
+		grpc.Creds(credentials.NewTLS(&tls.Config{
+			Certificates: []tls.Certificate{cert},
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+			ClientCAs:    clientCAs,
+			MinVersion:   tls.VersionTLS12,
+		})),
+		grpc.StreamInterceptor(auth.StreamServerInterceptor(authFunc)),
+		grpc.UnaryInterceptor(auth.UnaryServerInterceptor(authFunc)),
+	)
+}
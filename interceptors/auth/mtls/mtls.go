@@ -0,0 +1,203 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package mtls
+
+import (
+	"context"
+	"crypto/x509"
+	"strings"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+var _ auth.Identity = &Identity{}
+
+// Identity is the identity of a caller, from its verified client certificate.
+type Identity struct {
+	// SPIFFEID is the "spiffe://" URI SAN of the certificate, if any.
+	SPIFFEID string
+	// DNSNames are the DNS SANs of the certificate.
+	DNSNames []string
+	// CommonName is the common name of the subject of the certificate.
+	CommonName string
+	// Certificate is the verified leaf certificate of the caller.
+	Certificate *x509.Certificate
+}
+
+// Subject returns the SPIFFE ID of the caller if set, otherwise its first DNS SAN if any, otherwise the common name
+// of its certificate.
+func (i *Identity) Subject() string {
+	switch {
+	case i.SPIFFEID != "":
+		return i.SPIFFEID
+	case len(i.DNSNames) > 0:
+		return i.DNSNames[0]
+	default:
+		return i.CommonName
+	}
+}
+
+// hasSANs reports whether the certificate of the caller has subject alternative names, in which case its common
+// name is ignored.
+func (i *Identity) hasSANs() bool {
+	if i.SPIFFEID != "" || len(i.DNSNames) > 0 {
+		return true
+	}
+	c := i.Certificate
+	return c != nil && len(c.URIs)+len(c.IPAddresses)+len(c.EmailAddresses) > 0
+}
+
+// matches reports whether the caller matches pattern: a SPIFFE ID pattern is matched against its SPIFFE ID only,
+// any other pattern against its DNS SANs, or its common name if its certificate has no SANs.
+func (i *Identity) matches(pattern string) bool {
+	if pattern == "*" {
+		return true
+	}
+	if strings.HasPrefix(pattern, "spiffe://") {
+		return i.SPIFFEID != "" && match(pattern, i.SPIFFEID, "/")
+	}
+	pattern = strings.ToLower(pattern)
+	for _, name := range i.DNSNames {
+		if match(pattern, strings.ToLower(name), ".") {
+			return true
+		}
+	}
+	return !i.hasSANs() && i.CommonName != "" && match(pattern, strings.ToLower(i.CommonName), ".")
+}
+
+// IdentityFromPeer returns the identity of the caller of the call of ctx, from the client certificate verified by
+// the TLS handshake. It fails with the Unauthenticated code if the call has no verified client certificate, i.e.
+// the server does not use TLS credentials requiring and verifying client certificates.
+func IdentityFromPeer(ctx context.Context) (*Identity, error) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "no peer information")
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "connection is not using TLS")
+	}
+	if len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "no verified client certificate")
+	}
+	cert := tlsInfo.State.VerifiedChains[0][0]
+	id := &Identity{DNSNames: cert.DNSNames, CommonName: cert.Subject.CommonName, Certificate: cert}
+	for _, u := range cert.URIs {
+		if u.Scheme == "spiffe" {
+			id.SPIFFEID = u.String()
+			break
+		}
+	}
+	return id, nil
+}
+
+// Rule allows callers to call methods.
+type Rule struct {
+	// Method is the full name of the method the rule applies to ("/my.package.Service/Method"), all methods of a
+	// service ("/my.package.Service/*"), or all methods ("*").
+	Method string
+	// Identities are the patterns of the callers allowed to call the method. Patterns starting with "spiffe://" are
+	// matched against the SPIFFE ID of their certificate only, and the others against its DNS SANs, or its common
+	// name if it has no SANs at all. A "*" in a pattern matches within a single path segment of a SPIFFE ID or a
+	// single label of a DNS name, e.g. "spiffe://example.org/ns/prod/sa/*" or "*.internal.example.com" (but not
+	// "a.b.internal.example.com"). The "*" pattern alone allows any caller with a verified certificate.
+	Identities []string
+}
+
+// AuthFunc returns an auth.AuthFunc authenticating callers by their client certificate (see `IdentityFromPeer`) and
+// authorizing them with rules. The rule of a method is its most specific one: the rule of the method, otherwise
+// the rule of its service, otherwise the "*" rule. Calls to methods without a rule, or by callers not allowed by the
+// rule of the method, fail with the PermissionDenied code.
+//
+// The identity of the caller is put in the context of the call, see `IdentityFromContext`.
+func AuthFunc(rules ...Rule) auth.AuthFunc {
+	byMethod := make(map[string]Rule, len(rules))
+	for _, r := range rules {
+		byMethod[r.Method] = r
+	}
+	return func(ctx context.Context) (context.Context, error) {
+		id, err := IdentityFromPeer(ctx)
+		if err != nil {
+			return nil, err
+		}
+		method, _ := grpc.Method(ctx)
+		if !allowed(byMethod, method, id) {
+			return nil, status.Errorf(codes.PermissionDenied, "%s is not allowed to call %s", id.Subject(), method)
+		}
+		return InjectIdentity(ctx, id), nil
+	}
+}
+
+func allowed(byMethod map[string]Rule, method string, id *Identity) bool {
+	r, ok := byMethod[method]
+	if !ok {
+		service := method[:strings.LastIndex(method, "/")+1]
+		if r, ok = byMethod[service+"*"]; !ok {
+			if r, ok = byMethod["*"]; !ok {
+				return false
+			}
+		}
+	}
+	for _, pattern := range r.Identities {
+		if id.matches(pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// match reports whether name matches pattern, both split by sep into the same number of segments, where a "*" in a
+// segment of pattern matches any sequence of characters within the segment.
+func match(pattern, name, sep string) bool {
+	patterns, names := strings.Split(pattern, sep), strings.Split(name, sep)
+	if len(patterns) != len(names) {
+		return false
+	}
+	for i, p := range patterns {
+		if !matchSegment(p, names[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchSegment reports whether name matches pattern, where "*" matches any sequence of characters.
+func matchSegment(pattern, name string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == name
+	}
+	if !strings.HasPrefix(name, parts[0]) {
+		return false
+	}
+	name = name[len(parts[0]):]
+	for _, p := range parts[1 : len(parts)-1] {
+		i := strings.Index(name, p)
+		if i < 0 {
+			return false
+		}
+		name = name[i+len(p):]
+	}
+	return len(name) >= len(parts[len(parts)-1]) && strings.HasSuffix(name, parts[len(parts)-1])
+}
+
+type identityCtxMarker struct{}
+
+var identityCtxMarkerKey = &identityCtxMarker{}
+
+// InjectIdentity returns a new context carrying id, both as the mTLS identity and as the auth.Identity of the caller.
+func InjectIdentity(ctx context.Context, id *Identity) context.Context {
+	return auth.InjectIdentity(context.WithValue(ctx, identityCtxMarkerKey, id), id)
+}
+
+// IdentityFromContext returns the identity put in the context by the `AuthFunc`, if any.
+func IdentityFromContext(ctx context.Context) (*Identity, bool) {
+	id, ok := ctx.Value(identityCtxMarkerKey).(*Identity)
+	return id, ok
+}
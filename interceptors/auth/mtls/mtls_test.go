@@ -0,0 +1,280 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package mtls
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/auth"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/testing/testpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{cert: cert, key: key}
+}
+
+// issue returns a leaf certificate signed by the CA.
+func (ca *testCA) issue(t *testing.T, cn string, dnsNames []string, uris []string, usage x509.ExtKeyUsage) tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		DNSNames:     dnsNames,
+	}
+	for _, u := range uris {
+		parsed, parseErr := url.Parse(u)
+		require.NoError(t, parseErr)
+		tmpl.URIs = append(tmpl.URIs, parsed)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+func peerContext(cert *x509.Certificate) context.Context {
+	state := tls.ConnectionState{}
+	if cert != nil {
+		state.VerifiedChains = [][]*x509.Certificate{{cert}}
+	}
+	return peer.NewContext(context.Background(), &peer.Peer{AuthInfo: credentials.TLSInfo{State: state}})
+}
+
+type methodStream struct {
+	grpc.ServerTransportStream
+	method string
+}
+
+func (s methodStream) Method() string { return s.method }
+
+func methodContext(ctx context.Context, method string) context.Context {
+	return grpc.NewContextWithServerTransportStream(ctx, methodStream{method: method})
+}
+
+func TestIdentityFromPeer(t *testing.T) {
+	ca := newTestCA(t)
+	for _, tcase := range []struct {
+		name     string
+		cert     tls.Certificate
+		subject  string
+		spiffeID string
+	}{
+		{
+			name:     "SPIFFE ID",
+			cert:     ca.issue(t, "cn", []string{"svc.example.com"}, []string{"https://example.com", "spiffe://example.org/ns/prod/sa/billing"}, x509.ExtKeyUsageClientAuth),
+			subject:  "spiffe://example.org/ns/prod/sa/billing",
+			spiffeID: "spiffe://example.org/ns/prod/sa/billing",
+		},
+		{
+			name:    "DNS SAN",
+			cert:    ca.issue(t, "cn", []string{"svc.example.com", "other.example.com"}, nil, x509.ExtKeyUsageClientAuth),
+			subject: "svc.example.com",
+		},
+		{
+			name:    "common name",
+			cert:    ca.issue(t, "cn", nil, nil, x509.ExtKeyUsageClientAuth),
+			subject: "cn",
+		},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			id, err := IdentityFromPeer(peerContext(tcase.cert.Leaf))
+			require.NoError(t, err)
+			assert.Equal(t, tcase.subject, id.Subject())
+			assert.Equal(t, tcase.spiffeID, id.SPIFFEID)
+			assert.Equal(t, "cn", id.CommonName)
+			assert.Same(t, tcase.cert.Leaf, id.Certificate)
+		})
+	}
+
+	for _, ctx := range []context.Context{
+		context.Background(),
+		peer.NewContext(context.Background(), &peer.Peer{}),
+		peerContext(nil),
+	} {
+		_, err := IdentityFromPeer(ctx)
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	}
+}
+
+func TestAuthFunc_Rules(t *testing.T) {
+	ca := newTestCA(t)
+	billing := ca.issue(t, "billing", []string{"billing.internal.example.com"}, []string{"spiffe://example.org/ns/prod/sa/billing"}, x509.ExtKeyUsageClientAuth).Leaf
+	frontend := ca.issue(t, "frontend", []string{"frontend.internal.example.com"}, nil, x509.ExtKeyUsageClientAuth).Leaf
+	admin := ca.issue(t, "admin", nil, nil, x509.ExtKeyUsageClientAuth).Leaf
+
+	authFunc := AuthFunc(
+		Rule{Method: "/my.Billing/Charge", Identities: []string{"spiffe://example.org/ns/prod/sa/billing"}},
+		Rule{Method: "/my.Billing/*", Identities: []string{"*.internal.example.com"}},
+		Rule{Method: "*", Identities: []string{"admin"}},
+	)
+	for _, tcase := range []struct {
+		method string
+		cert   *x509.Certificate
+		code   codes.Code
+	}{
+		{method: "/my.Billing/Charge", cert: billing, code: codes.OK},
+		{method: "/my.Billing/Charge", cert: frontend, code: codes.PermissionDenied},
+		{method: "/my.Billing/Charge", cert: admin, code: codes.PermissionDenied},
+		{method: "/my.Billing/List", cert: frontend, code: codes.OK},
+		{method: "/my.Billing/List", cert: billing, code: codes.OK},
+		{method: "/my.Billing/List", cert: admin, code: codes.PermissionDenied},
+		{method: "/my.Other/Get", cert: admin, code: codes.OK},
+		{method: "/my.Other/Get", cert: frontend, code: codes.PermissionDenied},
+		{method: "/my.Other/Get", cert: nil, code: codes.Unauthenticated},
+	} {
+		ctx, err := authFunc(methodContext(peerContext(tcase.cert), tcase.method))
+		require.Equal(t, tcase.code, status.Code(err), "%s by %v", tcase.method, tcase.cert)
+		if err != nil {
+			continue
+		}
+		id, ok := IdentityFromContext(ctx)
+		require.True(t, ok)
+		assert.Same(t, tcase.cert, id.Certificate)
+		authID, ok := auth.IdentityFromContext(ctx)
+		require.True(t, ok)
+		assert.Equal(t, id.Subject(), authID.Subject())
+	}
+
+	_, err := AuthFunc()(methodContext(peerContext(admin), "/my.Other/Get"))
+	assert.Equal(t, codes.PermissionDenied, status.Code(err), "methods without rules must be denied")
+}
+
+func TestAuthFunc_NameTypes(t *testing.T) {
+	ca := newTestCA(t)
+	billing := ca.issue(t, "billing", []string{"billing.internal.example.com"}, []string{"spiffe://example.org/ns/prod/sa/billing"}, x509.ExtKeyUsageClientAuth).Leaf
+	admin := ca.issue(t, "admin", nil, nil, x509.ExtKeyUsageClientAuth).Leaf
+
+	for _, tcase := range []struct {
+		name    string
+		pattern string
+		cert    *x509.Certificate
+		code    codes.Code
+	}{
+		{name: "SPIFFE pattern against SPIFFE ID", pattern: "spiffe://example.org/ns/prod/sa/billing", cert: billing, code: codes.OK},
+		{name: "DNS pattern against DNS SAN", pattern: "billing.internal.example.com", cert: billing, code: codes.OK},
+		{name: "DNS pattern against DNS SAN, case-insensitive", pattern: "Billing.Internal.Example.com", cert: billing, code: codes.OK},
+		{name: "DNS pattern against SPIFFE ID", pattern: "*//example.org/ns/prod/sa/billing", cert: billing, code: codes.PermissionDenied},
+		{name: "common name with SANs", pattern: "billing", cert: billing, code: codes.PermissionDenied},
+		{name: "common name without SANs", pattern: "admin", cert: admin, code: codes.OK},
+		{name: "any caller", pattern: "*", cert: admin, code: codes.OK},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			_, err := AuthFunc(Rule{Method: "*", Identities: []string{tcase.pattern}})(methodContext(peerContext(tcase.cert), "/my.Other/Get"))
+			assert.Equal(t, tcase.code, status.Code(err))
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	for _, tcase := range []struct {
+		pattern, name, sep string
+		match              bool
+	}{
+		{pattern: "a.example.com", name: "a.example.com", sep: ".", match: true},
+		{pattern: "a.example.com", name: "b.example.com", sep: "."},
+		{pattern: "*.example.com", name: "a.example.com", sep: ".", match: true},
+		{pattern: "*.example.com", name: "a.b.example.com", sep: "."},
+		{pattern: "*.example.com", name: "example.com", sep: "."},
+		{pattern: "web-*.example.com", name: "web-1.example.com", sep: ".", match: true},
+		{pattern: "web-*.example.com", name: "api-1.example.com", sep: "."},
+		{pattern: "spiffe://example.org/ns/*/sa/billing", name: "spiffe://example.org/ns/prod/sa/billing", sep: "/", match: true},
+		{pattern: "spiffe://example.org/ns/*/sa/billing", name: "spiffe://example.org/ns/prod/sa/web", sep: "/"},
+		{pattern: "spiffe://td/ns/*/sa/web", name: "spiffe://td/ns/a/sa/x/sa/web", sep: "/"},
+		{pattern: "spiffe://td/ns/prod/*", name: "spiffe://td/ns/prod/sa/web", sep: "/"},
+		{pattern: "a*b*c", name: "abc", sep: ".", match: true},
+		{pattern: "a*b*c", name: "a.b.c", sep: "."},
+		{pattern: "a*b*c", name: "acb", sep: "."},
+		{pattern: "a*a", name: "a", sep: "."},
+	} {
+		assert.Equal(t, tcase.match, match(tcase.pattern, tcase.name, tcase.sep), "%s %s", tcase.pattern, tcase.name)
+	}
+}
+
+func TestAuthFunc_MutualTLS(t *testing.T) {
+	ca := newTestCA(t)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	serverCert := ca.issue(t, "localhost", []string{"localhost"}, nil, x509.ExtKeyUsageServerAuth)
+
+	authFunc := AuthFunc(Rule{Method: "*", Identities: []string{"spiffe://example.org/client"}})
+	srv := grpc.NewServer(
+		grpc.Creds(credentials.NewTLS(&tls.Config{
+			Certificates: []tls.Certificate{serverCert},
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+			ClientCAs:    pool,
+			MinVersion:   tls.VersionTLS12,
+		})),
+		grpc.UnaryInterceptor(auth.UnaryServerInterceptor(authFunc)),
+	)
+	testpb.RegisterTestServiceServer(srv, &testpb.TestPingService{})
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	ping := func(clientCert tls.Certificate) error {
+		conn, dialErr := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{
+			Certificates: []tls.Certificate{clientCert},
+			RootCAs:      pool,
+			ServerName:   "localhost",
+			MinVersion:   tls.VersionTLS12,
+		})))
+		require.NoError(t, dialErr)
+		defer func() { _ = conn.Close() }()
+		_, pingErr := testpb.NewTestServiceClient(conn).Ping(context.Background(), testpb.GoodPing)
+		return pingErr
+	}
+
+	require.NoError(t, ping(ca.issue(t, "client", nil, []string{"spiffe://example.org/client"}, x509.ExtKeyUsageClientAuth)))
+	err = ping(ca.issue(t, "other", nil, []string{"spiffe://example.org/other"}, x509.ExtKeyUsageClientAuth))
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
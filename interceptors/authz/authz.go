@@ -0,0 +1,260 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package authz
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/auth"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Reasons of the ErrorInfo details of denials.
+const (
+	ReasonNoRule          = "NO_RULE"
+	ReasonUnauthenticated = "UNAUTHENTICATED"
+	ReasonMissingRole     = "MISSING_ROLE"
+	ReasonMissingScope    = "MISSING_SCOPE"
+	ReasonClaimMismatch   = "CLAIM_MISMATCH"
+	ReasonConditionFailed = "CONDITION_FAILED"
+	ReasonConditionError  = "CONDITION_ERROR"
+)
+
+// Logging fields added to the calls by the interceptors, see logging.AddFields.
+const (
+	// DecisionFieldKey is the field holding the decision, "allow" or "deny".
+	DecisionFieldKey = "authz.decision"
+	// ReasonFieldKey is the field holding the reason of denials, e.g. `ReasonMissingRole`.
+	ReasonFieldKey = "authz.reason"
+)
+
+// RolesIdentity is an auth.Identity providing the roles of the caller.
+type RolesIdentity interface {
+	Roles() []string
+}
+
+// ScopesIdentity is an auth.Identity providing the scopes granted to the caller.
+type ScopesIdentity interface {
+	Scopes() []string
+}
+
+// ClaimsIdentity is an auth.Identity providing claims, such as the claims of a JWT (see auth/jwt).
+type ClaimsIdentity interface {
+	// StringListClaim returns the values of the claim name.
+	StringListClaim(name string) []string
+}
+
+// Authorizer authorizes calls according to a `Policy`.
+type Authorizer struct {
+	domain      string
+	rolesClaim  string
+	scopesClaim string
+	byMethod    map[string]*rule
+}
+
+// rule is a `Rule` with its compiled condition.
+type rule struct {
+	Rule
+	program cel.Program
+}
+
+// NewAuthorizer returns an Authorizer enforcing p, after validating it (see `Policy.Validate`). The Authorizer
+// keeps a copy of p, which can be modified afterwards.
+func NewAuthorizer(p *Policy) (*Authorizer, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	a := &Authorizer{
+		domain:      orDefault(p.Domain, DefaultErrorDomain),
+		rolesClaim:  orDefault(p.RolesClaim, DefaultRolesClaim),
+		scopesClaim: orDefault(p.ScopesClaim, DefaultScopesClaim),
+		byMethod:    map[string]*rule{},
+	}
+	for i, pr := range p.Rules {
+		r := &rule{Rule: Rule{
+			Methods:   slices.Clone(pr.Methods),
+			Public:    pr.Public,
+			Roles:     slices.Clone(pr.Roles),
+			Scopes:    slices.Clone(pr.Scopes),
+			Claims:    maps.Clone(pr.Claims),
+			Condition: pr.Condition,
+		}}
+		if r.Condition != "" {
+			program, err := compileCondition(r.Condition)
+			if err != nil {
+				return nil, fmt.Errorf("authz: rule %d: invalid condition: %w", i, err)
+			}
+			r.program = program
+		}
+		for _, m := range r.Methods {
+			a.byMethod[m] = r
+		}
+	}
+	return a, nil
+}
+
+// rule returns the rule of fullMethod, or nil if it has none.
+func (a *Authorizer) rule(fullMethod string) *rule {
+	if r, ok := a.byMethod[fullMethod]; ok {
+		return r
+	}
+	if r, ok := a.byMethod[fullMethod[:strings.LastIndex(fullMethod, "/")+1]+"*"]; ok {
+		return r
+	}
+	return a.byMethod["*"]
+}
+
+// Authorize returns nil if the caller of the call of ctx, put in the context by the auth interceptor (see
+// auth.InjectIdentity), meets the requirements of the rule of fullMethod. Otherwise, it returns an error with the
+// PermissionDenied code, or the Unauthenticated code if ctx has no identity, and an ErrorInfo detail with the reason.
+// The conditions of rules are evaluated without request.
+func (a *Authorizer) Authorize(ctx context.Context, fullMethod string) error {
+	return a.authorize(ctx, interceptors.NewServerCallMeta(fullMethod, nil, nil), nil)
+}
+
+// authorize authorizes a call, and adds the decision to its logging fields.
+func (a *Authorizer) authorize(ctx context.Context, c interceptors.CallMeta, req any) error {
+	reason, code := a.decide(ctx, c, req)
+	if reason == "" {
+		logging.AddFields(ctx, logging.Fields{DecisionFieldKey, "allow"})
+		return nil
+	}
+	logging.AddFields(ctx, logging.Fields{DecisionFieldKey, "deny", ReasonFieldKey, reason})
+	return a.deny(code, reason, c.FullMethod())
+}
+
+// decide returns the reason and code of the denial of a call, or an empty reason if it is allowed.
+func (a *Authorizer) decide(ctx context.Context, c interceptors.CallMeta, req any) (string, codes.Code) {
+	r := a.rule(c.FullMethod())
+	if r == nil {
+		return ReasonNoRule, codes.PermissionDenied
+	}
+	if r.Public {
+		return "", codes.OK
+	}
+	id, ok := auth.IdentityFromContext(ctx)
+	if !ok {
+		return ReasonUnauthenticated, codes.Unauthenticated
+	}
+	if len(r.Roles) > 0 {
+		roles := a.roles(id)
+		if !slices.ContainsFunc(r.Roles, func(role string) bool { return slices.Contains(roles, role) }) {
+			return ReasonMissingRole, codes.PermissionDenied
+		}
+	}
+	if len(r.Scopes) > 0 {
+		scopes := a.scopes(id)
+		for _, s := range r.Scopes {
+			if !slices.Contains(scopes, s) {
+				return ReasonMissingScope, codes.PermissionDenied
+			}
+		}
+	}
+	claims, _ := id.(ClaimsIdentity)
+	for _, name := range sortedKeys(r.Claims) {
+		var values []string
+		if claims != nil {
+			values = claims.StringListClaim(name)
+		}
+		if !slices.ContainsFunc(r.Claims[name], func(v string) bool { return slices.Contains(values, v) }) {
+			return ReasonClaimMismatch, codes.PermissionDenied
+		}
+	}
+	if r.Condition != "" {
+		allowed, err := a.evalCondition(ctx, r, id, c, req)
+		if err != nil {
+			return ReasonConditionError, codes.PermissionDenied
+		}
+		if !allowed {
+			return ReasonConditionFailed, codes.PermissionDenied
+		}
+	}
+	return "", codes.OK
+}
+
+// roles returns the roles of id, from `RolesIdentity` or from its claims.
+func (a *Authorizer) roles(id auth.Identity) []string {
+	if ri, ok := id.(RolesIdentity); ok {
+		return ri.Roles()
+	}
+	if claims, ok := id.(ClaimsIdentity); ok {
+		return claims.StringListClaim(a.rolesClaim)
+	}
+	return nil
+}
+
+// scopes returns the scopes of id, from `ScopesIdentity` or from its claims.
+func (a *Authorizer) scopes(id auth.Identity) []string {
+	if si, ok := id.(ScopesIdentity); ok {
+		return si.Scopes()
+	}
+	if claims, ok := id.(ClaimsIdentity); ok {
+		return claims.StringListClaim(a.scopesClaim)
+	}
+	return nil
+}
+
+func (a *Authorizer) deny(code codes.Code, reason, fullMethod string) error {
+	md := map[string]string{"method": fullMethod}
+	st := status.New(code, "permission denied")
+	if code == codes.Unauthenticated {
+		st = status.New(code, "unauthenticated")
+	}
+	if withDetails, err := st.WithDetails(&errdetails.ErrorInfo{
+		Reason:   reason,
+		Domain:   a.domain,
+		Metadata: md,
+	}); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
+
+// UnaryServerInterceptor returns a new unary server interceptor that authorizes calls, see `Authorize`. Chain it
+// after the auth interceptor.
+func (a *Authorizer) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := a.authorize(ctx, interceptors.NewServerCallMeta(info.FullMethod, nil, req), req); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor returns a new stream server interceptor that authorizes calls, see `Authorize`. Chain it
+// after the auth interceptor.
+func (a *Authorizer) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := a.authorize(stream.Context(), interceptors.NewServerCallMeta(info.FullMethod, info, nil), nil); err != nil {
+			return err
+		}
+		return handler(srv, stream)
+	}
+}
+
+func orDefault(v, def string) string {
+	if v == "" {
+		return def
+	}
+	return v
+}
+
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
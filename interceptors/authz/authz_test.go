@@ -0,0 +1,278 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package authz
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/auth"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/testing/testpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const testPolicy = `
+domain: billing.example.com
+rules:
+  - methods: ["/grpc.health.v1.Health/*"]
+    public: true
+  - methods: ["/my.Billing/Charge"]
+    roles: [billing-admin, finance]
+    scopes: [billing.read, billing.write]
+  - methods: ["/my.Billing/*"]
+    claims:
+      tenant: [acme, globex]
+  - methods: ["/my.Reports/Get", "/my.Reports/List"]
+`
+
+type claims map[string][]string
+
+func (c claims) Subject() string                      { return "alice" }
+func (c claims) StringListClaim(name string) []string { return c[name] }
+
+type rolesIdentity []string
+
+func (r rolesIdentity) Subject() string  { return "bob" }
+func (r rolesIdentity) Roles() []string  { return r }
+func (r rolesIdentity) Scopes() []string { return []string{"billing.read", "billing.write"} }
+
+func TestAuthorize(t *testing.T) {
+	p, err := LoadPolicy(strings.NewReader(testPolicy))
+	require.NoError(t, err)
+	a, err := NewAuthorizer(p)
+	require.NoError(t, err)
+
+	for _, tcase := range []struct {
+		name     string
+		method   string
+		identity auth.Identity
+		code     codes.Code
+		reason   string
+	}{
+		{name: "public", method: "/grpc.health.v1.Health/Check", code: codes.OK},
+		{name: "no rule", method: "/my.Other/Get", identity: claims{}, code: codes.PermissionDenied, reason: ReasonNoRule},
+		{name: "no identity", method: "/my.Reports/Get", code: codes.Unauthenticated, reason: ReasonUnauthenticated},
+		{name: "any identity", method: "/my.Reports/List", identity: claims{}, code: codes.OK},
+		{
+			name:     "roles and scopes from claims",
+			method:   "/my.Billing/Charge",
+			identity: claims{"roles": {"finance"}, "scope": {"billing.read", "billing.write", "other"}},
+			code:     codes.OK,
+		},
+		{
+			name:     "missing role",
+			method:   "/my.Billing/Charge",
+			identity: claims{"roles": {"viewer"}, "scope": {"billing.read", "billing.write"}},
+			code:     codes.PermissionDenied,
+			reason:   ReasonMissingRole,
+		},
+		{
+			name:     "missing scope",
+			method:   "/my.Billing/Charge",
+			identity: claims{"roles": {"finance"}, "scope": {"billing.read"}},
+			code:     codes.PermissionDenied,
+			reason:   ReasonMissingScope,
+		},
+		{name: "roles and scopes from identity", method: "/my.Billing/Charge", identity: rolesIdentity{"billing-admin"}, code: codes.OK},
+		{name: "claim", method: "/my.Billing/List", identity: claims{"tenant": {"globex"}}, code: codes.OK},
+		{name: "claim mismatch", method: "/my.Billing/List", identity: claims{"tenant": {"initech"}}, code: codes.PermissionDenied, reason: ReasonClaimMismatch},
+		{name: "no claims", method: "/my.Billing/List", identity: rolesIdentity{}, code: codes.PermissionDenied, reason: ReasonClaimMismatch},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tcase.identity != nil {
+				ctx = auth.InjectIdentity(ctx, tcase.identity)
+			}
+			err := a.Authorize(ctx, tcase.method)
+			require.Equal(t, tcase.code, status.Code(err))
+			if tcase.code == codes.OK {
+				return
+			}
+			details := status.Convert(err).Details()
+			require.Len(t, details, 1)
+			info, ok := details[0].(*errdetails.ErrorInfo)
+			require.True(t, ok)
+			assert.Equal(t, tcase.reason, info.GetReason())
+			assert.Equal(t, "billing.example.com", info.GetDomain())
+			assert.Equal(t, tcase.method, info.GetMetadata()["method"])
+		})
+	}
+}
+
+func TestPolicy_CustomClaims(t *testing.T) {
+	p, err := LoadPolicy(strings.NewReader(`{
+		"roles_claim": "groups",
+		"scopes_claim": "scp",
+		"rules": [{"methods": ["*"], "roles": ["admin"], "scopes": ["all"]}]
+	}`))
+	require.NoError(t, err)
+	a, err := NewAuthorizer(p)
+	require.NoError(t, err)
+
+	ctx := auth.InjectIdentity(context.Background(), claims{"groups": {"admin"}, "scp": {"all"}})
+	require.NoError(t, a.Authorize(ctx, "/any.Service/Method"))
+
+	err = a.Authorize(auth.InjectIdentity(context.Background(), claims{"roles": {"admin"}, "scope": {"all"}}), "/any.Service/Method")
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	info := status.Convert(err).Details()[0].(*errdetails.ErrorInfo)
+	assert.Equal(t, DefaultErrorDomain, info.GetDomain())
+}
+
+func TestLoadPolicy_Invalid(t *testing.T) {
+	for _, tcase := range []struct {
+		policy string
+		err    string
+	}{
+		{policy: `rules: [{roles: [a]}]`, err: "rule 0 has no methods"},
+		{policy: `rules: [{methods: ["my.Service/Method"]}]`, err: `invalid method "my.Service/Method"`},
+		{policy: `rules: [{methods: ["/my.Service"]}]`, err: `invalid method "/my.Service"`},
+		{policy: `rules: [{methods: ["/my.Service/*"]}, {methods: ["/my.Service/*"]}]`, err: `method "/my.Service/*" is already listed`},
+		{policy: `rules: [{methods: ["*"], role: [a]}]`, err: "field role not found"},
+		{policy: `rules: {`, err: "authz: parsing policy"},
+		{policy: `rules: [{methods: ["*"], condition: "request.value =="}]`, err: "rule 0: invalid condition"},
+		{policy: `rules: [{methods: ["*"], condition: "call.method"}]`, err: "condition must be a bool"},
+		{policy: `rules: [{methods: ["*"], condition: "unknown == 1"}]`, err: "undeclared reference"},
+	} {
+		_, err := LoadPolicy(strings.NewReader(tcase.policy))
+		require.ErrorContains(t, err, tcase.err, tcase.policy)
+	}
+
+	_, err := LoadPolicyFile("does-not-exist.yaml")
+	require.ErrorContains(t, err, "authz: loading policy")
+}
+
+func TestNewAuthorizer(t *testing.T) {
+	for _, tcase := range []struct {
+		policy *Policy
+		err    string
+	}{
+		{policy: &Policy{Rules: []Rule{{Methods: []string{"my.Service/Method"}}}}, err: `invalid method "my.Service/Method"`},
+		{policy: &Policy{Rules: []Rule{{Methods: []string{"*"}}, {Methods: []string{"*"}}}}, err: `method "*" is already listed`},
+		{policy: &Policy{Rules: []Rule{{Methods: []string{"*"}, Condition: "request.value =="}}}, err: "rule 0: invalid condition"},
+	} {
+		_, err := NewAuthorizer(tcase.policy)
+		require.ErrorContains(t, err, tcase.err)
+	}
+
+	// The Authorizer keeps enforcing the policy it was created with.
+	p := &Policy{Rules: []Rule{{Methods: []string{"/my.Service/Method"}, Roles: []string{"admin"}}}}
+	a, err := NewAuthorizer(p)
+	require.NoError(t, err)
+	p.Rules[0].Roles[0] = "support"
+	p.Rules[0].Methods[0] = "/my.Service/Other"
+	ctx := auth.InjectIdentity(context.Background(), rolesIdentity{"admin"})
+	require.NoError(t, a.Authorize(ctx, "/my.Service/Method"))
+}
+
+func TestInterceptors(t *testing.T) {
+	a, err := NewAuthorizer(&Policy{Rules: []Rule{{Methods: []string{"/my.Service/Allowed"}, Public: true}}})
+	require.NoError(t, err)
+
+	called := false
+	_, err = a.UnaryServerInterceptor()(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/my.Service/Allowed"},
+		func(context.Context, any) (any, error) {
+			called = true
+			return nil, nil
+		})
+	require.NoError(t, err)
+	assert.True(t, called)
+
+	err = a.StreamServerInterceptor()(nil, &fakeStream{}, &grpc.StreamServerInfo{FullMethod: "/my.Service/Denied"},
+		func(any, grpc.ServerStream) error {
+			t.Fatal("the handler of a denied call must not be called")
+			return nil
+		})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+type attributesIdentity map[string]any
+
+func (a attributesIdentity) Subject() string                      { return "carol" }
+func (a attributesIdentity) Roles() []string                      { return []string{"support"} }
+func (a attributesIdentity) Attributes() map[string]any           { return a }
+func (a attributesIdentity) StringListClaim(name string) []string { return nil }
+
+func TestAuthorize_Condition(t *testing.T) {
+	p, err := LoadPolicy(strings.NewReader(`
+rules:
+  - methods: ["/testing.testpb.v1.TestService/Ping"]
+    condition: request.value == identity.claims.tenant && identity.claims.level >= 2
+  - methods: ["/testing.testpb.v1.TestService/PingList"]
+    condition: >
+      "support" in identity.roles && call.type == "server_stream" &&
+      "x-region" in metadata && metadata["x-region"].exists(r, r == "eu")
+  - methods: ["/testing.testpb.v1.TestService/PingEmpty"]
+    condition: identity.claims.missing == 1
+`))
+	require.NoError(t, err)
+	a, err := NewAuthorizer(p)
+	require.NoError(t, err)
+
+	unary := func(ctx context.Context, req *testpb.PingRequest) error {
+		_, err := a.UnaryServerInterceptor()(ctx, req, &grpc.UnaryServerInfo{FullMethod: "/testing.testpb.v1.TestService/Ping"},
+			func(context.Context, any) (any, error) { return nil, nil })
+		return err
+	}
+	// Numbers decoded from JSON claims are json.Number.
+	acme := auth.InjectIdentity(context.Background(), attributesIdentity{"tenant": "acme", "level": json.Number("2")})
+	require.NoError(t, unary(acme, &testpb.PingRequest{Value: "acme"}))
+	assertReason(t, unary(acme, &testpb.PingRequest{Value: "globex"}), ReasonConditionFailed)
+	low := auth.InjectIdentity(context.Background(), attributesIdentity{"tenant": "acme", "level": json.Number("1")})
+	assertReason(t, unary(low, &testpb.PingRequest{Value: "acme"}), ReasonConditionFailed)
+
+	stream := func(ctx context.Context) error {
+		return a.StreamServerInterceptor()(nil, &fakeStream{ctx: ctx},
+			&grpc.StreamServerInfo{FullMethod: "/testing.testpb.v1.TestService/PingList", IsServerStream: true},
+			func(any, grpc.ServerStream) error { return nil })
+	}
+	eu := metadata.NewIncomingContext(acme, metadata.Pairs("x-region", "us", "x-region", "eu"))
+	require.NoError(t, stream(eu))
+	assertReason(t, stream(acme), ReasonConditionFailed)
+	assertReason(t, stream(metadata.NewIncomingContext(auth.InjectIdentity(context.Background(), claims{}), metadata.Pairs("x-region", "eu"))), ReasonConditionFailed)
+
+	assertReason(t, a.Authorize(acme, "/testing.testpb.v1.TestService/PingEmpty"), ReasonConditionError)
+}
+
+func TestAuthorize_LoggingFields(t *testing.T) {
+	a, err := NewAuthorizer(&Policy{Rules: []Rule{{Methods: []string{"/my.Service/Allowed"}, Public: true}}})
+	require.NoError(t, err)
+
+	ctx := logging.InjectFields(context.Background(), logging.Fields{"grpc.method", "Allowed"})
+	require.NoError(t, a.Authorize(ctx, "/my.Service/Allowed"))
+	assert.Equal(t, logging.Fields{DecisionFieldKey, "allow", "grpc.method", "Allowed"}, logging.ExtractFields(ctx))
+
+	ctx = logging.InjectFields(context.Background(), logging.Fields{})
+	require.Error(t, a.Authorize(ctx, "/my.Service/Denied"))
+	assert.Equal(t, logging.Fields{DecisionFieldKey, "deny", ReasonFieldKey, ReasonNoRule}, logging.ExtractFields(ctx))
+}
+
+func assertReason(t *testing.T, err error, reason string) {
+	t.Helper()
+
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	info, ok := status.Convert(err).Details()[0].(*errdetails.ErrorInfo)
+	require.True(t, ok)
+	assert.Equal(t, reason, info.GetReason())
+}
+
+type fakeStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *fakeStream) Context() context.Context {
+	if s.ctx == nil {
+		return context.Background()
+	}
+	return s.ctx
+}
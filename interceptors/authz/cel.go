@@ -0,0 +1,113 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package authz
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/auth"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// AttributesIdentity is an auth.Identity providing attributes to the conditions of rules, as `identity.claims`,
+// such as all the claims of a JWT (see auth/jwt).
+type AttributesIdentity interface {
+	Attributes() map[string]any
+}
+
+// celEnv returns the CEL environment of the conditions of rules, with all the protobuf types linked in the binary.
+var celEnv = sync.OnceValues(func() (*cel.Env, error) {
+	return cel.NewEnv(
+		cel.TypeDescs(protoregistry.GlobalFiles),
+		cel.Variable("identity", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("call", cel.MapType(cel.StringType, cel.StringType)),
+		cel.Variable("metadata", cel.MapType(cel.StringType, cel.ListType(cel.StringType))),
+		cel.Variable("request", cel.DynType),
+	)
+})
+
+// compileCondition compiles the CEL expression of the condition of a rule.
+func compileCondition(expr string) (cel.Program, error) {
+	env, err := celEnv()
+	if err != nil {
+		return nil, err
+	}
+	ast, iss := env.Compile(expr)
+	if iss.Err() != nil {
+		return nil, iss.Err()
+	}
+	if ast.OutputType() != cel.BoolType && ast.OutputType() != cel.DynType {
+		return nil, fmt.Errorf("condition must be a bool, got %v", ast.OutputType())
+	}
+	return env.Program(ast)
+}
+
+// evalCondition evaluates the compiled condition of r for a call.
+func (a *Authorizer) evalCondition(ctx context.Context, r *rule, id auth.Identity, c interceptors.CallMeta, req any) (bool, error) {
+	identity := map[string]any{}
+	if id != nil {
+		identity["subject"] = id.Subject()
+		identity["roles"] = a.roles(id)
+		identity["scopes"] = a.scopes(id)
+		if ai, ok := id.(AttributesIdentity); ok {
+			identity["claims"] = celValue(ai.Attributes())
+		}
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	mdVals := make(map[string][]string, len(md))
+	for k, v := range md {
+		mdVals[k] = v
+	}
+	out, _, err := r.program.Eval(map[string]any{
+		"identity": identity,
+		"call": map[string]string{
+			"service":     c.Service,
+			"method":      c.Method,
+			"full_method": c.FullMethod(),
+			"type":        string(c.Typ),
+		},
+		"metadata": mdVals,
+		"request":  req,
+	})
+	if err != nil {
+		return false, err
+	}
+	allowed, ok := out.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("condition returned %v, not a bool", out.Type())
+	}
+	return allowed, nil
+}
+
+// celValue converts the numbers decoded from JSON as json.Number, which CEL does not support, to int64 or float64.
+func celValue(v any) any {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for k, e := range v {
+			m[k] = celValue(e)
+		}
+		return m
+	case []any:
+		l := make([]any, len(v))
+		for i, e := range v {
+			l[i] = celValue(e)
+		}
+		return l
+	default:
+		return v
+	}
+}
@@ -0,0 +1,29 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+/*
+Package authz is a middleware that authorizes gRPC calls according to a declarative policy.
+
+# Server Side Authorization Middleware
+
+A `Policy`, loaded from YAML or JSON with `LoadPolicy`, maps services and methods to the roles, scopes and claims
+their callers must have. The caller is the auth.Identity put in the context by the AuthFunc of the auth interceptor,
+such as the claims of auth/jwt. Its roles and scopes come from the `RolesIdentity` and `ScopesIdentity` interfaces,
+or from its claims if it implements `ClaimsIdentity`. `NewAuthorizer` validates the policy, also when built in code,
+and compiles its conditions before any call is authorized.
+
+Calls are denied by default: calls to methods without a rule fail with the PermissionDenied code. Every denial has
+an ErrorInfo detail, whose reason tells which requirement was not met.
+
+Rules can also have a condition, a CEL expression (https://cel.dev) over the caller's identity, the method, the
+incoming metadata and the request message, e.g. `request.tenant == identity.claims.tenant`. Conditions are compiled
+once; calls whose condition is false, or fails to evaluate, are denied.
+
+Every decision is added to the logging fields of the call (see logging.AddFields), as the `DecisionFieldKey` and
+`ReasonFieldKey` fields, so that the logging interceptor reports it when chained before the `Authorizer`.
+
+Chain the interceptors of an `Authorizer` after the auth interceptor.
+
+Please see examples for simple examples of use.
+*/
+package authz
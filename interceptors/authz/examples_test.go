@@ -0,0 +1,41 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package authz_test
+
+import (
+	"log"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/auth"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/auth/jwt"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/authz"
+	"google.golang.org/grpc"
+)
+
+// Simple example of server initialization code, authenticating callers with JWTs and authorizing them with a policy.
+func Example_serverConfig() {
+	keys, err := jwt.NewJWKSFile("/etc/grpc/jwks.json", 0, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	policy, err := authz.LoadPolicyFile("/etc/grpc/authz.yaml")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	authFunc := jwt.AuthFunc(keys, jwt.WithAudience("billing"))
+	authorizer, err := authz.NewAuthorizer(policy)
+	if err != nil {
+		log.Fatal(err)
+	}
+	_ = grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			auth.UnaryServerInterceptor(authFunc),
+			authorizer.UnaryServerInterceptor(),
+		),
+		grpc.ChainStreamInterceptor(
+			auth.StreamServerInterceptor(authFunc),
+			authorizer.StreamServerInterceptor(),
+		),
+	)
+}
@@ -0,0 +1,125 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package authz
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Defaults of the `Policy` fields.
+const (
+	DefaultErrorDomain = "authz.go-grpc-middleware"
+	DefaultRolesClaim  = "roles"
+	DefaultScopesClaim = "scope"
+)
+
+// Policy maps methods to the requirements their callers must meet. Calls to methods without a rule are denied.
+//
+// The rule of a method is its most specific one: the rule listing the full method name ("/my.package.Service/Method"),
+// otherwise the rule listing its service ("/my.package.Service/*"), otherwise the rule listing "*".
+type Policy struct {
+	// Domain is the domain of the ErrorInfo details of denials, `DefaultErrorDomain` if empty.
+	Domain string `yaml:"domain" json:"domain"`
+	// RolesClaim is the claim holding the roles of callers whose identity does not implement `RolesIdentity`,
+	// `DefaultRolesClaim` if empty.
+	RolesClaim string `yaml:"roles_claim" json:"roles_claim"`
+	// ScopesClaim is the claim holding the scopes of callers whose identity does not implement `ScopesIdentity`,
+	// `DefaultScopesClaim` if empty.
+	ScopesClaim string `yaml:"scopes_claim" json:"scopes_claim"`
+	Rules       []Rule `yaml:"rules" json:"rules"`
+}
+
+// Rule holds the requirements of the callers of methods.
+type Rule struct {
+	// Methods are the full method names, services ("/my.package.Service/*") or "*", the rule applies to.
+	Methods []string `yaml:"methods" json:"methods"`
+	// Public allows calls without an identity in the context, e.g. for health checks. The other requirements are
+	// ignored.
+	Public bool `yaml:"public" json:"public"`
+	// Roles are the roles of which the caller must have at least one, if not empty.
+	Roles []string `yaml:"roles" json:"roles"`
+	// Scopes are the scopes the caller must all have.
+	Scopes []string `yaml:"scopes" json:"scopes"`
+	// Claims are the claims the caller must have, with one of the listed values each.
+	Claims map[string][]string `yaml:"claims" json:"claims"`
+	// Condition is a CEL expression the call must satisfy, e.g. `request.tenant == identity.claims.tenant`. It is
+	// compiled once, and evaluated after the other requirements with the variables:
+	//   - identity: a map with the "subject", "roles" and "scopes" of the caller, and its "claims" if it implements
+	//     `AttributesIdentity`;
+	//   - call: a map with the "service", "method", "full_method" and "type" (e.g. "unary") of the call;
+	//   - metadata: the incoming metadata of the call, a map of lists of strings;
+	//   - request: the request message of unary calls, null for streams.
+	//
+	// Calls whose condition does not evaluate to true, or fails, are denied.
+	Condition string `yaml:"condition" json:"condition"`
+}
+
+// LoadPolicy reads a policy in YAML or JSON from r, and validates it.
+//
+// For example:
+//
+//	rules:
+//	  - methods: ["/grpc.health.v1.Health/*"]
+//	    public: true
+//	  - methods: ["/my.Billing/Charge"]
+//	    roles: [billing-admin]
+//	    scopes: [billing.write]
+//	  - methods: ["/my.Billing/*"]
+//	    claims:
+//	      tenant: [acme]
+//	  - methods: ["/my.Accounts/Get"]
+//	    condition: request.tenant == identity.claims.tenant
+func LoadPolicy(r io.Reader) (*Policy, error) {
+	p := &Policy{}
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+	if err := dec.Decode(p); err != nil {
+		return nil, fmt.Errorf("authz: parsing policy: %w", err)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// LoadPolicyFile reads a policy in YAML or JSON from the file at path, and validates it.
+func LoadPolicyFile(path string) (*Policy, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("authz: loading policy: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	return LoadPolicy(f)
+}
+
+// Validate checks that the method patterns of the rules are well-formed, that no method pattern is listed by
+// several rules, and that the conditions of the rules compile.
+func (p *Policy) Validate() error {
+	seen := map[string]bool{}
+	for i, r := range p.Rules {
+		if len(r.Methods) == 0 {
+			return fmt.Errorf("authz: rule %d has no methods", i)
+		}
+		for _, m := range r.Methods {
+			if m != "*" && (!strings.HasPrefix(m, "/") || strings.Count(m, "/") != 2 || strings.HasSuffix(m, "/")) {
+				return fmt.Errorf("authz: rule %d: invalid method %q, expected \"/package.Service/Method\", \"/package.Service/*\" or \"*\"", i, m)
+			}
+			if seen[m] {
+				return fmt.Errorf("authz: rule %d: method %q is already listed by another rule", i, m)
+			}
+			seen[m] = true
+		}
+		if r.Condition != "" {
+			if _, err := compileCondition(r.Condition); err != nil {
+				return fmt.Errorf("authz: rule %d: invalid condition: %w", i, err)
+			}
+		}
+	}
+	return nil
+}
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 )
 
 // UnaryClientInterceptor is a gRPC client-side interceptor that provides reporting for Unary RPCs.
@@ -20,9 +21,19 @@ func UnaryClientInterceptor(reportable ClientReportable) grpc.UnaryClientInterce
 		r := newReport(NewClientCallMeta(method, nil, req))
 		reporter, newCtx := reportable.ClientReporter(ctx, r.callMeta)
 
+		var header, trailer metadata.MD
+		htReporter, reportHeaderTrailer := reporter.(HeaderTrailerReporter)
+		if reportHeaderTrailer {
+			// Make sure we never modify the caller's slice.
+			opts = append(opts[:len(opts):len(opts)], grpc.Header(&header), grpc.Trailer(&trailer))
+		}
+
 		reporter.PostMsgSend(req, nil, time.Since(r.startTime))
 		err := invoker(newCtx, method, req, reply, cc, opts...)
 		reporter.PostMsgReceive(reply, err, time.Since(r.startTime))
+		if reportHeaderTrailer {
+			htReporter.PostHeaderTrailer(header, trailer)
+		}
 		reporter.PostCall(err, time.Since(r.startTime))
 		return err
 	}
@@ -64,10 +75,15 @@ func (s *monitoredClientStream) RecvMsg(m any) error {
 	err := s.ClientStream.RecvMsg(m)
 	s.reporter.PostMsgReceive(m, err, time.Since(start))
 
+	if s.hasServerStream && err == nil {
+		return nil
+	}
+	if htReporter, ok := s.reporter.(HeaderTrailerReporter); ok {
+		// The call is over, so neither blocks.
+		header, _ := s.ClientStream.Header()
+		htReporter.PostHeaderTrailer(header, s.ClientStream.Trailer())
+	}
 	if s.hasServerStream {
-		if err == nil {
-			return nil
-		}
 		var postErr error
 		if !errors.Is(err, io.EOF) {
 			postErr = err
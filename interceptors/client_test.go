@@ -18,6 +18,7 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
@@ -353,3 +354,79 @@ func (s *ClientInterceptorTestSuite) TestClientStream() {
 		postMsgSends:    make([]error, 100),
 	}})
 }
+
+type headerTrailerReport struct {
+	NoopReporter
+
+	headers, trailers []metadata.MD
+	postCalls         int
+}
+
+func (r *headerTrailerReport) PostHeaderTrailer(header, trailer metadata.MD) {
+	r.headers = append(r.headers, header)
+	r.trailers = append(r.trailers, trailer)
+}
+
+func (r *headerTrailerReport) PostCall(error, time.Duration) {
+	r.postCalls++
+}
+
+func TestUnaryClientInterceptor_HeaderTrailerReporter(t *testing.T) {
+	report := &headerTrailerReport{}
+	interceptor := UnaryClientInterceptor(CommonReportableFunc(func(ctx context.Context, _ CallMeta) (Reporter, context.Context) {
+		return report, ctx
+	}))
+	invoker := func(_ context.Context, _ string, _, _ any, _ *grpc.ClientConn, opts ...grpc.CallOption) error {
+		for _, o := range opts {
+			switch o := o.(type) {
+			case grpc.HeaderCallOption:
+				*o.HeaderAddr = metadata.Pairs("h", "1")
+			case grpc.TrailerCallOption:
+				*o.TrailerAddr = metadata.Pairs("t", "2")
+			}
+		}
+		return nil
+	}
+	err := interceptor(context.Background(), "/test.Service/Ping", nil, nil, nil, invoker)
+	require.NoError(t, err)
+	require.Equal(t, []metadata.MD{metadata.Pairs("h", "1")}, report.headers)
+	require.Equal(t, []metadata.MD{metadata.Pairs("t", "2")}, report.trailers)
+	require.Equal(t, 1, report.postCalls)
+}
+
+type headerTrailerClientStream struct {
+	grpc.ClientStream
+	recvs int
+}
+
+func (s *headerTrailerClientStream) Header() (metadata.MD, error) {
+	return metadata.Pairs("h", "1"), nil
+}
+
+func (s *headerTrailerClientStream) Trailer() metadata.MD { return metadata.Pairs("t", "2") }
+
+func (s *headerTrailerClientStream) RecvMsg(any) error {
+	if s.recvs == 0 {
+		return io.EOF
+	}
+	s.recvs--
+	return nil
+}
+
+func TestStreamClientInterceptor_HeaderTrailerReporter(t *testing.T) {
+	report := &headerTrailerReport{}
+	interceptor := StreamClientInterceptor(CommonReportableFunc(func(ctx context.Context, _ CallMeta) (Reporter, context.Context) {
+		return report, ctx
+	}))
+	streamer := func(context.Context, *grpc.StreamDesc, *grpc.ClientConn, string, ...grpc.CallOption) (grpc.ClientStream, error) {
+		return &headerTrailerClientStream{recvs: 2}, nil
+	}
+	cs, err := interceptor(context.Background(), &grpc.StreamDesc{ServerStreams: true}, nil, "/test.Service/PingList", streamer)
+	require.NoError(t, err)
+	for cs.RecvMsg(nil) == nil {
+		require.Empty(t, report.headers, "the header and trailer should only be reported when the call finishes")
+	}
+	require.Equal(t, []metadata.MD{metadata.Pairs("h", "1")}, report.headers)
+	require.Equal(t, []metadata.MD{metadata.Pairs("t", "2")}, report.trailers)
+	require.Equal(t, 1, report.postCalls)
+}
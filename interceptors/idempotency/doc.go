@@ -0,0 +1,25 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+/*
+Package idempotency is a middleware that executes retried unary calls only once.
+
+# Server Side Idempotency Middleware
+
+Clients set an idempotency key in the `x-idempotency-key` header of a call, which stays the same across
+its retries, e.g. with the `WithIdempotencyKey` option of the retry interceptor. The first call with a given
+key runs the handler and its response (or error status) is saved in a `Store` for a while. Calls with the same
+key return the saved response or status instead of running the handler again, and calls arriving while the
+first one is still running wait for it.
+
+Errors with codes that are worth retrying (e.g. `Unavailable`) are not saved, so a retry runs the handler again.
+Keys are scoped to the method of the call and to the authenticated caller (see `WithScopeFunc`), so the auth
+interceptor must be chained before this one. A call reusing a key with a different request fails with
+`InvalidArgument`. Calls without a key are not affected.
+
+`NewInMemoryStore` keeps the responses in the memory of the process, other stores (e.g. shared by all replicas
+of a service) can be implemented with the `Store` interface.
+
+Please see examples for simple examples of use.
+*/
+package idempotency
@@ -0,0 +1,37 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package idempotency_test
+
+import (
+	"time"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/auth"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/idempotency"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/retry"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// Simple example of a unary server initialization code. The interceptor is chained after the auth interceptor, so
+// that idempotency keys are scoped to the authenticated caller.
+func ExampleUnaryServerInterceptor() {
+	var authFn auth.AuthFunc // e.g. jwt.AuthFunc or mtls.AuthFunc.
+	_ = grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			auth.UnaryServerInterceptor(authFn),
+			idempotency.UnaryServerInterceptor(idempotency.NewInMemoryStore(), idempotency.WithTTL(time.Hour)),
+		),
+	)
+}
+
+// Clients send a key that stays the same across the retries of a call with the retry interceptor.
+func ExampleUnaryServerInterceptor_client() {
+	_, _ = grpc.NewClient("localhost:8080",
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(retry.UnaryClientInterceptor(
+			retry.WithMax(3),
+			retry.WithIdempotencyKey(),
+		)),
+	)
+}
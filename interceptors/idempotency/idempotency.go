@@ -0,0 +1,240 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const (
+	// MetadataKey is the header carrying the idempotency key of a call, the same as the
+	// `IdempotencyKeyMetadataKey` of the retry interceptor.
+	MetadataKey = "x-idempotency-key"
+
+	// DefaultTTL is how long results are kept by default.
+	DefaultTTL = 24 * time.Hour
+)
+
+// DefaultTransientCodes are the codes of the errors that are not saved by default, as retrying the call may
+// succeed.
+var DefaultTransientCodes = []codes.Code{
+	codes.Canceled,
+	codes.DeadlineExceeded,
+	codes.ResourceExhausted,
+	codes.Aborted,
+	codes.Unavailable,
+}
+
+type options struct {
+	ttl            time.Duration
+	transientCodes []codes.Code
+	scopeFunc      func(ctx context.Context) string
+}
+
+// Option configures the interceptor.
+type Option func(*options)
+
+// WithTTL sets how long the result of a call is kept, `DefaultTTL` by default.
+func WithTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.ttl = ttl
+	}
+}
+
+// WithTransientCodes sets the codes of the errors that are not saved, so that the handler runs again when the
+// call is retried. Defaults to `DefaultTransientCodes`.
+func WithTransientCodes(c ...codes.Code) Option {
+	return func(o *options) {
+		o.transientCodes = c
+	}
+}
+
+// WithScopeFunc sets the function returning the scope of the idempotency keys of a call from its context, so that
+// callers in different scopes cannot read each other's results by guessing or reusing their keys. By default, it is
+// the subject of the `auth.Identity` put in the context by the auth interceptor (see `auth.InjectIdentity`), which
+// must then be chained before this interceptor. Calls without an identity share the empty scope.
+func WithScopeFunc(f func(ctx context.Context) string) Option {
+	return func(o *options) {
+		o.scopeFunc = f
+	}
+}
+
+// identityScope is the default scope function, returning the subject of the authenticated caller.
+func identityScope(ctx context.Context) string {
+	if id, ok := auth.IdentityFromContext(ctx); ok {
+		return id.Subject()
+	}
+	return ""
+}
+
+// UnaryServerInterceptor returns a new unary server interceptor that runs the handler only once per idempotency
+// key, and returns the result saved in store for the following calls with the same key.
+//
+// Keys are scoped to the method and the scope of the call (see `WithScopeFunc`). A call reusing the key of another
+// call with a different request fails with InvalidArgument, instead of getting the result of the other request.
+//
+// Errors of the store are not returned to the client: the handler runs as if there were no saved result.
+// Responses that are not a proto.Message are never saved.
+func UnaryServerInterceptor(store Store, opts ...Option) grpc.UnaryServerInterceptor {
+	o := &options{ttl: DefaultTTL, transientCodes: DefaultTransientCodes, scopeFunc: identityScope}
+	for _, opt := range opts {
+		opt(o)
+	}
+	g := &group{calls: map[string]*call{}}
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		vals := metadata.ValueFromIncomingContext(ctx, MetadataKey)
+		if len(vals) == 0 || vals[0] == "" {
+			return handler(ctx, req)
+		}
+		// The scope is escaped so that it cannot contain the separator.
+		key := info.FullMethod + "/" + url.PathEscape(o.scopeFunc(ctx)) + "/" + vals[0]
+		fp := fingerprint(req)
+		for {
+			c, leader := g.join(key, fp)
+			if !leader {
+				if c.fingerprint != fp {
+					return nil, errKeyReused
+				}
+				select {
+				case <-c.done:
+				case <-ctx.Done():
+					return nil, status.FromContextError(ctx.Err()).Err()
+				}
+				if c.saved {
+					return c.resp, c.err
+				}
+				// The call failed with a transient error, run it again.
+				continue
+			}
+			func() {
+				// Release the waiting calls even if the handler panics.
+				defer g.leave(key, c)
+				c.resp, c.saved, c.err = o.run(ctx, store, key, fp, req, handler)
+			}()
+			return c.resp, c.err
+		}
+	}
+}
+
+var errKeyReused = status.Error(codes.InvalidArgument, "grpc_idempotency: idempotency key reused with a different request")
+
+// fingerprint returns the hash of req, or an empty string if it is not a proto.Message.
+func fingerprint(req any) string {
+	msg, ok := req.(proto.Message)
+	if !ok {
+		return ""
+	}
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// run returns the saved result for key or runs the handler, and reports whether the result is saved. The saved
+// result of another request (with another fingerprint fp) is returned as an error.
+func (o *options) run(ctx context.Context, store Store, key, fp string, req any, handler grpc.UnaryHandler) (any, bool, error) {
+	if res, found, getErr := store.Get(ctx, key); getErr == nil && found {
+		if res.Fingerprint != fp {
+			return nil, false, errKeyReused
+		}
+		if resp, ok, err := decodeResult(res); ok {
+			return resp, true, err
+		}
+	}
+	resp, err := handler(ctx, req)
+	res, ok := o.encodeResult(resp, err)
+	if !ok {
+		return resp, false, err
+	}
+	res.Fingerprint = fp
+	if putErr := store.Put(ctx, key, res, o.ttl); putErr != nil {
+		return resp, false, err
+	}
+	return resp, true, err
+}
+
+func (o *options) encodeResult(resp any, err error) (*Result, bool) {
+	if err != nil {
+		st := status.Convert(err)
+		for _, c := range o.transientCodes {
+			if st.Code() == c {
+				return nil, false
+			}
+		}
+		return &Result{Status: st.Proto()}, true
+	}
+	msg, ok := resp.(proto.Message)
+	if !ok {
+		return nil, false
+	}
+	a, anyErr := anypb.New(msg)
+	if anyErr != nil {
+		return nil, false
+	}
+	return &Result{Response: a}, true
+}
+
+// decodeResult returns the response or error of a saved result, and false if it cannot be decoded.
+func decodeResult(res *Result) (any, bool, error) {
+	if res.Status != nil {
+		return nil, true, status.ErrorProto(res.Status)
+	}
+	if res.Response == nil {
+		return nil, false, nil
+	}
+	msg, err := res.Response.UnmarshalNew()
+	if err != nil {
+		return nil, false, nil
+	}
+	return msg, true, nil
+}
+
+// group tracks the calls in flight, so that concurrent calls with the same key wait for the first one.
+type group struct {
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+type call struct {
+	done        chan struct{}
+	fingerprint string
+	resp        any
+	err         error
+	saved       bool
+}
+
+// join returns the call in flight for key, or registers a new one for the request with fingerprint fp and returns
+// true if there is none.
+func (g *group) join(key, fp string) (*call, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if c, ok := g.calls[key]; ok {
+		return c, false
+	}
+	c := &call{done: make(chan struct{}), fingerprint: fp}
+	g.calls[key] = c
+	return c, true
+}
+
+func (g *group) leave(key string, c *call) {
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+	close(c.done)
+}
@@ -0,0 +1,320 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/auth"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/retry"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/testing/testpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+var pingInfo = &grpc.UnaryServerInfo{FullMethod: testpb.TestServiceFullName + "/Ping"}
+
+func withKey(key string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs(MetadataKey, key))
+}
+
+// countingHandler returns a handler that returns the results in order, then the last one.
+func countingHandler(calls *atomic.Int32, results ...error) grpc.UnaryHandler {
+	return func(_ context.Context, req any) (any, error) {
+		n := int(calls.Add(1)) - 1
+		if err := results[min(n, len(results)-1)]; err != nil {
+			return nil, err
+		}
+		return &testpb.PingResponse{Value: req.(*testpb.PingRequest).Value, Counter: int32(n)}, nil
+	}
+}
+
+func TestUnaryServerInterceptor_ReturnsSavedResponse(t *testing.T) {
+	var calls atomic.Int32
+	interceptor := UnaryServerInterceptor(NewInMemoryStore())
+	handler := countingHandler(&calls, nil)
+
+	for range 3 {
+		resp, err := interceptor(withKey("k1"), &testpb.PingRequest{Value: "a"}, pingInfo, handler)
+		require.NoError(t, err)
+		require.True(t, proto.Equal(&testpb.PingResponse{Value: "a"}, resp.(proto.Message)), "got %v", resp)
+	}
+	assert.EqualValues(t, 1, calls.Load())
+
+	// Another key, or the same key for another method, runs the handler again.
+	_, err := interceptor(withKey("k2"), &testpb.PingRequest{Value: "b"}, pingInfo, handler)
+	require.NoError(t, err)
+	_, err = interceptor(withKey("k1"), &testpb.PingRequest{Value: "c"}, &grpc.UnaryServerInfo{FullMethod: "/other/Method"}, handler)
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, calls.Load())
+}
+
+func TestUnaryServerInterceptor_WithoutKey(t *testing.T) {
+	var calls atomic.Int32
+	interceptor := UnaryServerInterceptor(NewInMemoryStore())
+	handler := countingHandler(&calls, nil)
+
+	for range 2 {
+		_, err := interceptor(context.Background(), &testpb.PingRequest{}, pingInfo, handler)
+		require.NoError(t, err)
+	}
+	assert.EqualValues(t, 2, calls.Load())
+}
+
+func TestUnaryServerInterceptor_Errors(t *testing.T) {
+	var calls atomic.Int32
+	interceptor := UnaryServerInterceptor(NewInMemoryStore())
+	handler := countingHandler(&calls,
+		status.Error(codes.Unavailable, "try again"),
+		status.Error(codes.InvalidArgument, "bad request"),
+		nil,
+	)
+
+	_, err := interceptor(withKey("k"), &testpb.PingRequest{}, pingInfo, handler)
+	require.Equal(t, codes.Unavailable, status.Code(err))
+
+	// Transient errors are not saved, the retry runs the handler.
+	for range 2 {
+		_, err = interceptor(withKey("k"), &testpb.PingRequest{}, pingInfo, handler)
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+		require.Equal(t, "bad request", status.Convert(err).Message())
+	}
+	assert.EqualValues(t, 2, calls.Load())
+}
+
+func TestUnaryServerInterceptor_WithTransientCodes(t *testing.T) {
+	var calls atomic.Int32
+	interceptor := UnaryServerInterceptor(NewInMemoryStore(), WithTransientCodes(codes.Internal))
+	handler := countingHandler(&calls, status.Error(codes.Internal, "oops"), status.Error(codes.Unavailable, "down"))
+
+	for _, want := range []codes.Code{codes.Internal, codes.Unavailable, codes.Unavailable} {
+		_, err := interceptor(withKey("k"), &testpb.PingRequest{}, pingInfo, handler)
+		require.Equal(t, want, status.Code(err))
+	}
+	assert.EqualValues(t, 2, calls.Load())
+}
+
+func TestUnaryServerInterceptor_KeyReusedWithAnotherRequest(t *testing.T) {
+	var calls atomic.Int32
+	interceptor := UnaryServerInterceptor(NewInMemoryStore())
+	handler := countingHandler(&calls, nil)
+
+	_, err := interceptor(withKey("k"), &testpb.PingRequest{Value: "a"}, pingInfo, handler)
+	require.NoError(t, err)
+	_, err = interceptor(withKey("k"), &testpb.PingRequest{Value: "b"}, pingInfo, handler)
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.EqualValues(t, 1, calls.Load())
+
+	// The same request still gets the saved response.
+	resp, err := interceptor(withKey("k"), &testpb.PingRequest{Value: "a"}, pingInfo, handler)
+	require.NoError(t, err)
+	assert.Equal(t, "a", resp.(*testpb.PingResponse).Value)
+}
+
+func TestUnaryServerInterceptor_KeyReusedWhileRunning(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	interceptor := UnaryServerInterceptor(NewInMemoryStore())
+	started := make(chan struct{})
+	handler := func(_ context.Context, _ any) (any, error) {
+		close(started)
+		<-release
+		return &testpb.PingResponse{}, nil
+	}
+	go func() {
+		_, _ = interceptor(withKey("k"), &testpb.PingRequest{Value: "a"}, pingInfo, handler)
+	}()
+	<-started
+
+	_, err := interceptor(withKey("k"), &testpb.PingRequest{Value: "b"}, pingInfo, handler)
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+type subject string
+
+func (s subject) Subject() string {
+	return string(s)
+}
+
+func TestUnaryServerInterceptor_KeysScopedByIdentity(t *testing.T) {
+	var calls atomic.Int32
+	interceptor := UnaryServerInterceptor(NewInMemoryStore())
+	handler := countingHandler(&calls, nil)
+
+	for _, id := range []auth.Identity{subject("alice"), subject("bob"), subject("alice")} {
+		ctx := auth.InjectIdentity(withKey("k"), id)
+		_, err := interceptor(ctx, &testpb.PingRequest{Value: "a"}, pingInfo, handler)
+		require.NoError(t, err)
+	}
+	assert.EqualValues(t, 2, calls.Load(), "callers should not share their results")
+
+	// A custom scope.
+	calls.Store(0)
+	interceptor = UnaryServerInterceptor(NewInMemoryStore(), WithScopeFunc(func(ctx context.Context) string {
+		return metadata.ValueFromIncomingContext(ctx, "tenant")[0]
+	}))
+	for _, tenant := range []string{"t1", "t2", "t2"} {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(MetadataKey, "k", "tenant", tenant))
+		_, err := interceptor(ctx, &testpb.PingRequest{Value: "a"}, pingInfo, handler)
+		require.NoError(t, err)
+	}
+	assert.EqualValues(t, 2, calls.Load())
+}
+
+func TestUnaryServerInterceptor_CoalescesConcurrentCalls(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	interceptor := UnaryServerInterceptor(NewInMemoryStore())
+	handler := func(_ context.Context, _ any) (any, error) {
+		calls.Add(1)
+		<-release
+		return &testpb.PingResponse{Value: "done"}, nil
+	}
+
+	const n = 5
+	var wg sync.WaitGroup
+	resps := make([]any, n)
+	errs := make([]error, n)
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resps[i], errs[i] = interceptor(withKey("k"), &testpb.PingRequest{}, pingInfo, handler)
+		}()
+	}
+	require.Eventually(t, func() bool { return calls.Load() == 1 }, time.Second, time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.EqualValues(t, 1, calls.Load())
+	for i := range n {
+		require.NoError(t, errs[i])
+		assert.Equal(t, "done", resps[i].(*testpb.PingResponse).Value)
+	}
+}
+
+func TestUnaryServerInterceptor_WaitingCallCancelled(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	interceptor := UnaryServerInterceptor(NewInMemoryStore())
+	started := make(chan struct{})
+	handler := func(_ context.Context, _ any) (any, error) {
+		close(started)
+		<-release
+		return &testpb.PingResponse{}, nil
+	}
+	go func() {
+		_, _ = interceptor(withKey("k"), &testpb.PingRequest{}, pingInfo, handler)
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(withKey("k"), 10*time.Millisecond)
+	defer cancel()
+	_, err := interceptor(ctx, &testpb.PingRequest{}, pingInfo, handler)
+	require.Equal(t, codes.DeadlineExceeded, status.Code(err))
+}
+
+func TestInMemoryStore_Expiry(t *testing.T) {
+	now := time.Unix(0, 0)
+	store := NewInMemoryStore()
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	require.NoError(t, store.Put(ctx, "a", &Result{}, time.Minute))
+	_, ok, err := store.Get(ctx, "a")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	now = now.Add(time.Minute)
+	_, ok, err = store.Get(ctx, "a")
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.Equal(t, 0, store.Len())
+
+	// Expired entries are swept as the store grows.
+	for i := range minSweepSize - 1 {
+		require.NoError(t, store.Put(ctx, string(rune('a'+i)), &Result{}, time.Second))
+	}
+	now = now.Add(time.Second)
+	require.NoError(t, store.Put(ctx, "last", &Result{}, time.Second))
+	require.Equal(t, 1, store.Len())
+}
+
+type IdempotencySuite struct {
+	*testpb.InterceptorTestSuite
+	handlerCalls *atomic.Int32
+}
+
+func TestIdempotencySuite(t *testing.T) {
+	var handlerCalls, dropped atomic.Int32
+	// dropFirstResponse loses the response of the first attempt of every call after it has been handled, as
+	// a broken connection would.
+	dropFirstResponse := func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		resp, err := handler(ctx, req)
+		if _, ok := retry.AttemptFromIncomingContext(ctx); !ok {
+			dropped.Add(1)
+			return nil, status.Error(codes.Unavailable, "connection lost")
+		}
+		return resp, err
+	}
+	s := &IdempotencySuite{
+		InterceptorTestSuite: &testpb.InterceptorTestSuite{
+			TestService: &testpb.TestPingService{PingFunc: func(context.Context) { handlerCalls.Add(1) }},
+			ServerOpts: []grpc.ServerOption{
+				grpc.ChainUnaryInterceptor(dropFirstResponse, UnaryServerInterceptor(NewInMemoryStore())),
+			},
+			ClientOpts: []grpc.DialOption{
+				grpc.WithUnaryInterceptor(retry.UnaryClientInterceptor(
+					retry.WithMax(3),
+					retry.WithBackoff(retry.BackoffLinear(time.Millisecond)),
+				)),
+			},
+		},
+		handlerCalls: &handlerCalls,
+	}
+	suite.Run(t, s)
+}
+
+func (s *IdempotencySuite) SetupTest() {
+	s.handlerCalls.Store(0)
+}
+
+func (s *IdempotencySuite) TestRetryWithIdempotencyKey() {
+	resp, err := s.Client.Ping(s.SimpleCtx(), &testpb.PingRequest{Value: "x"}, retry.WithIdempotencyKey())
+	s.Require().NoError(err)
+	s.Equal("x", resp.Value)
+	s.EqualValues(1, s.handlerCalls.Load(), "the retry must return the saved response")
+}
+
+func (s *IdempotencySuite) TestRetryWithoutIdempotencyKey() {
+	_, err := s.Client.Ping(s.SimpleCtx(), &testpb.PingRequest{Value: "x"})
+	s.Require().NoError(err)
+	s.EqualValues(2, s.handlerCalls.Load(), "without a key the retry runs the handler again")
+}
+
+func (s *IdempotencySuite) TestRetryKeepsKeyOfCaller() {
+	ctx := metadata.AppendToOutgoingContext(s.SimpleCtx(), retry.IdempotencyKeyMetadataKey, "my-key")
+	_, err := s.Client.Ping(ctx, &testpb.PingRequest{Value: "x"}, retry.WithIdempotencyKey())
+	s.Require().NoError(err)
+	s.EqualValues(1, s.handlerCalls.Load())
+
+	// The same key is a repeated call even without a retry.
+	_, err = s.Client.Ping(ctx, &testpb.PingRequest{Value: "x"}, retry.WithIdempotencyKey())
+	s.Require().NoError(err)
+	s.EqualValues(1, s.handlerCalls.Load())
+}
+
+func TestMetadataKeyMatchesRetry(t *testing.T) {
+	assert.Equal(t, retry.IdempotencyKeyMetadataKey, MetadataKey)
+}
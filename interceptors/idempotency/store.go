@@ -0,0 +1,99 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// Result is the outcome of a call saved in a `Store`.
+type Result struct {
+	// Response is the response of the call, nil if it failed.
+	Response *anypb.Any
+	// Status is the error status of the call, nil if it succeeded.
+	Status *spb.Status
+	// Fingerprint is the hash of the request of the call, to reject the calls reusing its idempotency key for
+	// another request. It is empty if the request is not a proto.Message.
+	Fingerprint string
+}
+
+// Store saves the results of calls by idempotency key.
+type Store interface {
+	// Get returns the result saved for key, and false if there is none or it expired.
+	Get(ctx context.Context, key string) (*Result, bool, error)
+	// Put saves the result for key, for ttl.
+	Put(ctx context.Context, key string, result *Result, ttl time.Duration) error
+}
+
+// InMemoryStore is a `Store` keeping the results in memory. Expired results are removed as new ones are saved.
+type InMemoryStore struct {
+	mu        sync.Mutex
+	entries   map[string]inMemoryEntry
+	nextSweep int
+	now       func() time.Time
+}
+
+type inMemoryEntry struct {
+	result   *Result
+	deadline time.Time
+}
+
+// NewInMemoryStore returns an empty `InMemoryStore`.
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{
+		entries:   map[string]inMemoryEntry{},
+		nextSweep: minSweepSize,
+		now:       time.Now,
+	}
+}
+
+// minSweepSize is the number of entries below which expired entries are not swept.
+const minSweepSize = 64
+
+// Get implements `Store`.
+func (s *InMemoryStore) Get(_ context.Context, key string) (*Result, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	if !s.now().Before(e.deadline) {
+		delete(s.entries, key)
+		return nil, false, nil
+	}
+	return e.result, true, nil
+}
+
+// Put implements `Store`.
+func (s *InMemoryStore) Put(_ context.Context, key string, result *Result, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.entries[key] = inMemoryEntry{result: result, deadline: now.Add(ttl)}
+	if len(s.entries) >= s.nextSweep {
+		for k, e := range s.entries {
+			if !now.Before(e.deadline) {
+				delete(s.entries, k)
+			}
+		}
+		// Sweep again once the store doubled in size, so that saving stays amortized O(1).
+		s.nextSweep = max(2*len(s.entries), minSweepSize)
+	}
+	return nil
+}
+
+// Len returns the number of results in the store, including the expired ones not removed yet.
+func (s *InMemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
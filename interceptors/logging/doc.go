@@ -13,6 +13,42 @@ All logging middleware will emit a final log statement. It is based on the error
 the gRPC status code, an error (if any) and it emits at a level controlled via `WithLevels`. You can control this behavior
 using `WithDecider`.
 
+The payloads logged on the `PayloadReceived` and `PayloadSent` events can contain passwords, tokens or personal
+data. `WithPayloadRedaction` masks their sensitive fields before they are passed to the logger, whatever its
+implementation: fields with the `debug_redact` option, a custom field option, paths or names matching a pattern
+(see `NewRedactor`).
+
+Large payloads can be truncated before they are logged with `WithMaxPayloadFieldSize` (long string and bytes
+fields), `WithMaxPayloadListLength` (repeated and map fields) and `WithMaxPayloadSize` (the whole payload). The
+original size of the payload, as sent on the wire before redaction and truncation, is then logged in the
+`grpc.request.size` or `grpc.response.size` field.
+
+On busy services, `WithSampling` only logs a share of the calls of each method, optionally limited to a number of
+calls per second (see `NewSampler`). The decision is made when a call starts, so that all of its log lines are either
+logged or not, but failed and slow calls always log their final statement. The number of suppressed log lines is
+available from the sampler.
+
+Calls can also be logged depending on their latency: `WithSlowCalls` raises the final statement of calls slower than
+the threshold of their method (see `SlowCallThresholds`) to the warn level and marks it with `grpc.slow`, and
+`WithOnlySlowCalls` drops everything else (with `DefaultSlowCallThreshold` if no threshold is set).
+
+To debug streams, `WithStreamSummary` numbers the logged messages of each direction and adds a summary of the
+stream to its final statement: the messages and bytes sent and received, the time to the first message received and
+the longest time without any message.
+
+gRPC metadata is not logged by default. `WithMetadataFields` logs the request metadata with the given keys (or all
+of them with `AllMetadataKeys`) and, on the client side, the header and trailer received from the server, which helps tracing calls through proxies. The
+values of credentials such as authorization and cookie are always redacted.
+
+Errors are logged as text in the `grpc.error` field. Use `WithErrorFields(logging.ErrorDetailsToFields)` to also
+log their google.rpc status details (e.g. ErrorInfo, BadRequest or protovalidate violations) as structured fields.
+
+Handlers can log their own lines with the Logger of the interceptor and the fields of the call, using
+`logging.FromContext(ctx)`.
+
+Ready-to-use adapters for zap, zerolog, logrus and go-kit are available in the providers/zap, providers/zerolog,
+providers/logrus and providers/kit modules, which keep their dependencies out of this module.
+
 # This parent package
 
 This particular package is intended for use by other middleware, logging or otherwise. It contains interfaces that other
@@ -0,0 +1,183 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package logging
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// protovalidateViolations is the full name of the violations detail of protovalidate, which is read by reflection to
+// avoid depending on its generated package.
+const protovalidateViolations protoreflect.FullName = "buf.validate.Violations"
+
+// ErrorDetailsToFields is an `ErrorToFields` that logs the google.rpc status details of errors as structured fields,
+// to be used with `WithErrorFields`:
+//   - ErrorInfo: "grpc.error.reason", "grpc.error.domain" and "grpc.error.metadata.<key>";
+//   - BadRequest and the buf.validate.Violations attached by interceptors/protovalidate (when its type is linked in
+//     the binary, e.g. by that interceptor): "grpc.error.violations", with one "<field>: <description>" entry per
+//     violation, separated by "; ";
+//   - PreconditionFailure: "grpc.error.precondition_violations", QuotaFailure: "grpc.error.quota_violations";
+//   - RetryInfo: "grpc.error.retry_delay";
+//   - DebugInfo: "grpc.error.debug_detail" and "grpc.error.stack";
+//   - RequestInfo: "grpc.error.request_id", ResourceInfo: "grpc.error.resource", LocalizedMessage:
+//     "grpc.error.localized_message", Help: "grpc.error.help".
+//
+// Other details are logged by their type URL in "grpc.error.details", separated by commas.
+func ErrorDetailsToFields(err error) Fields {
+	st, ok := status.FromError(err)
+	if !ok {
+		return nil
+	}
+	var (
+		fields                                        Fields
+		violations, preconditions, quotas, help, urls []string
+	)
+	for _, a := range st.Proto().GetDetails() {
+		detail, unmarshalErr := a.UnmarshalNew()
+		if unmarshalErr != nil {
+			urls = append(urls, a.GetTypeUrl())
+			continue
+		}
+		switch d := detail.(type) {
+		case *errdetails.ErrorInfo:
+			fields = append(fields, "grpc.error.reason", d.GetReason(), "grpc.error.domain", d.GetDomain())
+			keys := make([]string, 0, len(d.GetMetadata()))
+			for k := range d.GetMetadata() {
+				keys = append(keys, k)
+			}
+			slices.Sort(keys)
+			for _, k := range keys {
+				fields = append(fields, "grpc.error.metadata."+k, d.GetMetadata()[k])
+			}
+		case *errdetails.BadRequest:
+			for _, v := range d.GetFieldViolations() {
+				violations = append(violations, v.GetField()+": "+v.GetDescription())
+			}
+		case *errdetails.PreconditionFailure:
+			for _, v := range d.GetViolations() {
+				preconditions = append(preconditions, v.GetType()+" "+v.GetSubject()+": "+v.GetDescription())
+			}
+		case *errdetails.QuotaFailure:
+			for _, v := range d.GetViolations() {
+				quotas = append(quotas, v.GetSubject()+": "+v.GetDescription())
+			}
+		case *errdetails.RetryInfo:
+			fields = append(fields, "grpc.error.retry_delay", d.GetRetryDelay().AsDuration().String())
+		case *errdetails.DebugInfo:
+			fields = append(fields, "grpc.error.debug_detail", d.GetDetail())
+			if len(d.GetStackEntries()) > 0 {
+				fields = append(fields, "grpc.error.stack", strings.Join(d.GetStackEntries(), "\n"))
+			}
+		case *errdetails.RequestInfo:
+			fields = append(fields, "grpc.error.request_id", d.GetRequestId())
+		case *errdetails.ResourceInfo:
+			fields = append(fields, "grpc.error.resource", d.GetResourceType()+"/"+d.GetResourceName())
+		case *errdetails.LocalizedMessage:
+			fields = append(fields, "grpc.error.localized_message", d.GetMessage())
+		case *errdetails.Help:
+			for _, l := range d.GetLinks() {
+				help = append(help, l.GetUrl())
+			}
+		default:
+			if m := detail.ProtoReflect(); m.Descriptor().FullName() == protovalidateViolations {
+				violations = append(violations, protovalidateViolationStrings(m)...)
+				continue
+			}
+			urls = append(urls, a.GetTypeUrl())
+		}
+	}
+	for _, f := range []struct {
+		key    string
+		values []string
+		sep    string
+	}{
+		{key: "grpc.error.violations", values: violations, sep: "; "},
+		{key: "grpc.error.precondition_violations", values: preconditions, sep: "; "},
+		{key: "grpc.error.quota_violations", values: quotas, sep: "; "},
+		{key: "grpc.error.help", values: help, sep: ","},
+		{key: "grpc.error.details", values: urls, sep: ","},
+	} {
+		if len(f.values) > 0 {
+			fields = append(fields, f.key, strings.Join(f.values, f.sep))
+		}
+	}
+	return fields
+}
+
+// protovalidateViolationStrings returns the "<field>: <message>" entries of a buf.validate.Violations message.
+func protovalidateViolationStrings(m protoreflect.Message) []string {
+	list := getField(m, "violations")
+	if !list.IsValid() {
+		return nil
+	}
+	var entries []string
+	for i := 0; i < list.List().Len(); i++ {
+		v := list.List().Get(i).Message()
+		var path string
+		if f := getField(v, "field"); f.IsValid() {
+			path = fieldPathString(f.Message())
+		}
+		var msg string
+		if s := getField(v, "message"); s.IsValid() {
+			msg = s.String()
+		}
+		entries = append(entries, path+": "+msg)
+	}
+	return entries
+}
+
+// fieldPathString returns the path of a protovalidate violation from its buf.validate.FieldPath, e.g.
+// "items[2].name".
+func fieldPathString(path protoreflect.Message) string {
+	elements := getField(path, "elements")
+	if !elements.IsValid() {
+		return ""
+	}
+	var sb strings.Builder
+	for i := 0; i < elements.List().Len(); i++ {
+		e := elements.List().Get(i).Message()
+		if i > 0 {
+			sb.WriteByte('.')
+		}
+		if name := getField(e, "field_name"); name.IsValid() {
+			sb.WriteString(name.String())
+		}
+		oneof := e.Descriptor().Oneofs().ByName("subscript")
+		if oneof == nil {
+			continue
+		}
+		fd := e.WhichOneof(oneof)
+		if fd == nil {
+			continue
+		}
+		v := e.Get(fd)
+		switch fd.Kind() {
+		case protoreflect.StringKind:
+			sb.WriteString(fmt.Sprintf("[%q]", v.String()))
+		case protoreflect.BoolKind:
+			sb.WriteString("[" + strconv.FormatBool(v.Bool()) + "]")
+		case protoreflect.Uint32Kind, protoreflect.Uint64Kind, protoreflect.Fixed32Kind, protoreflect.Fixed64Kind:
+			sb.WriteString("[" + strconv.FormatUint(v.Uint(), 10) + "]")
+		default:
+			sb.WriteString("[" + strconv.FormatInt(v.Int(), 10) + "]")
+		}
+	}
+	return sb.String()
+}
+
+// getField returns the value of the field of m with the given name, or an invalid value if m has no such field.
+func getField(m protoreflect.Message, name protoreflect.Name) protoreflect.Value {
+	fd := m.Descriptor().Fields().ByName(name)
+	if fd == nil {
+		return protoreflect.Value{}
+	}
+	return m.Get(fd)
+}
@@ -0,0 +1,66 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package logging
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"buf.build/gen/go/bufbuild/protovalidate/protocolbuffers/go/buf/validate"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/testing/testpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func TestErrorDetailsToFields(t *testing.T) {
+	st, err := status.New(codes.InvalidArgument, "bad").WithDetails(
+		&errdetails.ErrorInfo{Reason: "QUOTA", Domain: "example.com", Metadata: map[string]string{"b": "2", "a": "1"}},
+		&errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{
+			{Field: "name", Description: "required"},
+		}},
+		&validate.Violations{Violations: []*validate.Violation{{
+			Field: &validate.FieldPath{Elements: []*validate.FieldPathElement{
+				{FieldName: proto.String("items"), Subscript: &validate.FieldPathElement_Index{Index: 2}},
+				{FieldName: proto.String("labels"), Subscript: &validate.FieldPathElement_StringKey{StringKey: "env"}},
+			}},
+			Message: proto.String("too long"),
+		}}},
+		&errdetails.QuotaFailure{Violations: []*errdetails.QuotaFailure_Violation{{Subject: "project:1", Description: "daily limit"}}},
+		&errdetails.RetryInfo{RetryDelay: durationpb.New(3 * time.Second)},
+		&errdetails.DebugInfo{Detail: "oops", StackEntries: []string{"a.go:1", "b.go:2"}},
+		&testpb.PingRequest{},
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, Fields{
+		"grpc.error.reason", "QUOTA",
+		"grpc.error.domain", "example.com",
+		"grpc.error.metadata.a", "1",
+		"grpc.error.metadata.b", "2",
+		"grpc.error.retry_delay", "3s",
+		"grpc.error.debug_detail", "oops",
+		"grpc.error.stack", "a.go:1\nb.go:2",
+		"grpc.error.violations", `name: required; items[2].labels["env"]: too long`,
+		"grpc.error.quota_violations", "project:1: daily limit",
+		"grpc.error.details", "type.googleapis.com/" + string((&testpb.PingRequest{}).ProtoReflect().Descriptor().FullName()),
+	}, ErrorDetailsToFields(st.Err()))
+}
+
+func TestErrorDetailsToFields_UnknownType(t *testing.T) {
+	st := status.New(codes.Internal, "bad").Proto()
+	st.Details = append(st.Details, &anypb.Any{TypeUrl: "type.googleapis.com/unknown.Detail"})
+	assert.Equal(t, Fields{"grpc.error.details", "type.googleapis.com/unknown.Detail"}, ErrorDetailsToFields(status.ErrorProto(st)))
+}
+
+func TestErrorDetailsToFields_NoDetails(t *testing.T) {
+	assert.Empty(t, ErrorDetailsToFields(status.Error(codes.Internal, "bad")))
+	assert.Empty(t, ErrorDetailsToFields(errors.New("not a status")))
+}
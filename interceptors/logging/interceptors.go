@@ -8,10 +8,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strconv"
 	"time"
 
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/peer"
 	"google.golang.org/protobuf/proto"
 )
@@ -22,6 +25,12 @@ type reporter struct {
 	ctx             context.Context
 	kind            string
 	startCallLogged bool
+	// sampled is false if the log lines of the call are suppressed by the sampler.
+	sampled bool
+	// stream is only set for streaming calls with WithStreamSummary.
+	stream *streamStats
+	// responseMetadata are the fields of the header and trailer received by client calls with WithMetadataFields.
+	responseMetadata Fields
 
 	opts   *options
 	fields Fields
@@ -37,6 +46,14 @@ func (c *reporter) PostCall(err error, duration time.Duration) {
 	}
 
 	code := c.opts.codeFunc(err)
+	slow := c.opts.slowCallFunc != nil && c.opts.slowCallFunc(c.CallMeta, duration)
+	if c.opts.onlySlowCalls && !slow {
+		return
+	}
+	if !c.sampled && !c.opts.sampler.alwaysLog(code != codes.OK || slow, duration) {
+		c.opts.sampler.suppress(c.CallMeta, 1)
+		return
+	}
 	fields := c.fields.WithUnique(ExtractFields(c.ctx))
 	fields = fields.AppendUnique(Fields{"grpc.code", code.String()})
 	if err != nil {
@@ -49,13 +66,29 @@ func (c *reporter) PostCall(err error, duration time.Duration) {
 		// fieldsFromCtxFn dups override the existing fields.
 		fields = c.opts.fieldsFromCtxCallMetaFn(c.ctx, c.CallMeta).AppendUnique(fields)
 	}
-	c.logger.Log(c.ctx, c.opts.levelFunc(code), "finished call", fields.AppendUnique(c.opts.durationFieldFunc(duration))...)
+	if c.stream != nil {
+		fields = fields.AppendUnique(c.stream.fields())
+	}
+	fields = fields.AppendUnique(c.responseMetadata)
+	level := c.opts.levelFunc(code)
+	if slow {
+		fields = fields.AppendUnique(Fields{SlowCallFieldKey, "true"})
+		level = max(level, LevelWarn)
+	}
+	c.logger.Log(c.ctx, level, "finished call", fields.AppendUnique(c.opts.durationFieldFunc(duration))...)
 }
 
 func (c *reporter) PostMsgSend(payload any, err error, duration time.Duration) {
+	seq := 0
+	if c.stream != nil && err == nil {
+		seq = c.stream.record(true, payload)
+	}
 	logStartCall := !c.startCallLogged && has(c.opts.loggableEvents, StartCall)
 	logPayloadSend := err == nil && has(c.opts.loggableEvents, PayloadSent)
-	if !logStartCall && !logPayloadSend {
+	if c.opts.onlySlowCalls || (!logStartCall && !logPayloadSend) {
+		return
+	}
+	if c.suppressMsg(logStartCall, logPayloadSend) {
 		return
 	}
 
@@ -94,15 +127,29 @@ func (c *reporter) PostMsgSend(payload any, err error, duration time.Duration) {
 		return
 	}
 
-	fields = fields.AppendUnique(Fields{"grpc.send.duration", duration.String(), fmt.Sprintf("grpc.%s.content", callType), p})
+	logged := p
+	if c.opts.redactor != nil {
+		logged = c.opts.redactor.Redact(p)
+	}
+	fields = fields.AppendUnique(Fields{"grpc.send.duration", duration.String()}.AppendUnique(c.opts.payloadLimits.fields(callType, p, logged)))
+	if seq > 0 {
+		fields = fields.AppendUnique(Fields{"grpc.send.seq", strconv.Itoa(seq)})
+	}
 	fields = fields.AppendUnique(c.opts.durationFieldFunc(duration))
 	c.logger.Log(c.ctx, logLvl, fmt.Sprintf("%s sent", callType), fields...)
 }
 
 func (c *reporter) PostMsgReceive(payload any, err error, duration time.Duration) {
+	seq := 0
+	if c.stream != nil && err == nil {
+		seq = c.stream.record(false, payload)
+	}
 	logStartCall := !c.startCallLogged && has(c.opts.loggableEvents, StartCall)
 	logPayloadReceived := err == nil && has(c.opts.loggableEvents, PayloadReceived)
-	if !logStartCall && !logPayloadReceived {
+	if c.opts.onlySlowCalls || (!logStartCall && !logPayloadReceived) {
+		return
+	}
+	if c.suppressMsg(logStartCall, logPayloadReceived) {
 		return
 	}
 
@@ -141,11 +188,45 @@ func (c *reporter) PostMsgReceive(payload any, err error, duration time.Duration
 		return
 	}
 
-	fields = fields.AppendUnique(Fields{"grpc.recv.duration", duration.String(), fmt.Sprintf("grpc.%s.content", callType), p})
+	logged := p
+	if c.opts.redactor != nil {
+		logged = c.opts.redactor.Redact(p)
+	}
+	fields = fields.AppendUnique(Fields{"grpc.recv.duration", duration.String()}.AppendUnique(c.opts.payloadLimits.fields(callType, p, logged)))
+	if seq > 0 {
+		fields = fields.AppendUnique(Fields{"grpc.recv.seq", strconv.Itoa(seq)})
+	}
 	fields = fields.AppendUnique(c.opts.durationFieldFunc(duration))
 	c.logger.Log(c.ctx, logLvl, fmt.Sprintf("%s received", callType), fields...)
 }
 
+// suppressMsg reports whether the log lines of a message event are suppressed by the sampler, and records them.
+func (c *reporter) suppressMsg(logStartCall, logPayload bool) bool {
+	if c.sampled {
+		return false
+	}
+	n := 0
+	if logStartCall {
+		c.startCallLogged = true
+		n++
+	}
+	if logPayload {
+		n++
+	}
+	c.opts.sampler.suppress(c.CallMeta, n)
+	return true
+}
+
+// metadataReporter is the reporter of client calls logging the header and trailer received.
+type metadataReporter struct {
+	*reporter
+}
+
+func (c metadataReporter) PostHeaderTrailer(header, trailer metadata.MD) {
+	c.responseMetadata = c.opts.metadataFilter.fields("grpc.response.header.", header).
+		AppendUnique(c.opts.metadataFilter.fields("grpc.response.trailer.", trailer))
+}
+
 func reportable(logger Logger, opts *options) interceptors.CommonReportableFunc {
 	return func(ctx context.Context, c interceptors.CallMeta) (interceptors.Reporter, context.Context) {
 		kind := KindServerFieldValue
@@ -174,16 +255,34 @@ func reportable(logger Logger, opts *options) interceptors.CommonReportableFunc
 		if d, ok := ctx.Deadline(); ok {
 			singleUseFields = singleUseFields.AppendUnique(Fields{"grpc.request.deadline", d.Format(opts.timestampFormat)})
 		}
+		if opts.metadataFilter != nil {
+			md, _ := metadata.FromIncomingContext(ctx)
+			if c.IsClient {
+				md, _ = metadata.FromOutgoingContext(ctx)
+			}
+			singleUseFields = singleUseFields.AppendUnique(opts.metadataFilter.fields("grpc.request.metadata.", md))
+		}
 		ctx = InjectFields(ctx, fields)
-		return &reporter{
+		ctx = context.WithValue(ctx, loggerCtxMarkerKey, logger)
+		var stream *streamStats
+		if opts.streamSummary && c.Typ != interceptors.Unary {
+			stream = newStreamStats()
+		}
+		r := &reporter{
 			CallMeta:        c,
 			ctx:             ctx,
 			startCallLogged: false,
+			sampled:         opts.sampler == nil || opts.sampler.sample(c),
+			stream:          stream,
 			opts:            opts,
 			fields:          fields.WithUnique(singleUseFields),
 			logger:          logger,
 			kind:            kind,
-		}, ctx
+		}
+		if c.IsClient && opts.metadataFilter != nil {
+			return metadataReporter{r}, ctx
+		}
+		return r, ctx
 	}
 }
 
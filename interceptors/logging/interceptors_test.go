@@ -24,8 +24,11 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/proto"
 )
@@ -827,3 +830,325 @@ func waitUntil(interval time.Duration, stopc <-chan struct{}, f func() error) er
 		}
 	}
 }
+
+func TestUnaryServerInterceptor_PayloadRedaction(t *testing.T) {
+	logger := newMockLogger()
+	interceptor := logging.UnaryServerInterceptor(logger,
+		logging.WithLogOnEvents(logging.PayloadReceived, logging.PayloadSent),
+		logging.WithPayloadRedaction(logging.NewRedactor(logging.RedactPaths("value"))),
+	)
+	req := &testpb.PingRequest{Value: "secret", SleepTimeMs: 1}
+	info := &grpc.UnaryServerInfo{FullMethod: testpb.TestServiceFullName + "/Ping"}
+	_, err := interceptor(context.Background(), req, info, func(_ context.Context, _ any) (any, error) {
+		return &testpb.PingResponse{Value: "secret", Counter: 2}, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "secret", req.Value, "the payload itself must not be modified")
+
+	lines := logger.o.Lines()
+	require.Len(t, lines, 2)
+	assert.Equal(t, `{"value":"[REDACTED]","sleepTimeMs":1}`, lines[0].fields["grpc.request.content"])
+	assert.Equal(t, `{"value":"[REDACTED]","counter":2}`, lines[1].fields["grpc.response.content"])
+}
+
+func TestUnaryServerInterceptor_PayloadLimits(t *testing.T) {
+	logger := newMockLogger()
+	interceptor := logging.UnaryServerInterceptor(logger,
+		logging.WithLogOnEvents(logging.PayloadReceived, logging.PayloadSent),
+		logging.WithMaxPayloadFieldSize(4),
+	)
+	req := &testpb.PingRequest{Value: "something"}
+	info := &grpc.UnaryServerInfo{FullMethod: testpb.TestServiceFullName + "/Ping"}
+	_, err := interceptor(context.Background(), req, info, func(_ context.Context, _ any) (any, error) {
+		return &testpb.PingResponse{Value: "ok"}, nil
+	})
+	require.NoError(t, err)
+
+	lines := logger.o.Lines()
+	require.Len(t, lines, 2)
+	assert.Equal(t, `{"value":"some...[truncated]"}`, lines[0].fields["grpc.request.content"])
+	assert.Equal(t, strconv.Itoa(proto.Size(req)), lines[0].fields["grpc.request.size"])
+	assert.Equal(t, "true", lines[0].fields["grpc.request.truncated"])
+	assert.Equal(t, `{"value":"ok"}`, lines[1].fields["grpc.response.content"])
+	assert.Equal(t, "4", lines[1].fields["grpc.response.size"])
+	assert.Empty(t, lines[1].fields["grpc.response.truncated"])
+}
+
+func TestUnaryServerInterceptor_Sampling(t *testing.T) {
+	logger := newMockLogger()
+	sampler := logging.NewSampler(logging.SampleRate(0))
+	interceptor := logging.UnaryServerInterceptor(logger,
+		logging.WithLogOnEvents(logging.StartCall, logging.PayloadReceived, logging.FinishCall),
+		logging.WithSampling(sampler),
+	)
+	info := &grpc.UnaryServerInfo{FullMethod: testpb.TestServiceFullName + "/Ping"}
+
+	_, err := interceptor(context.Background(), testpb.GoodPing, info, func(_ context.Context, _ any) (any, error) {
+		return &testpb.PingResponse{}, nil
+	})
+	require.NoError(t, err)
+	assert.Empty(t, logger.o.Lines())
+	assert.EqualValues(t, 3, sampler.Suppressed())
+
+	_, err = interceptor(context.Background(), testpb.GoodPing, info, func(_ context.Context, _ any) (any, error) {
+		return nil, status.Error(codes.Internal, "boom")
+	})
+	require.Error(t, err)
+	lines := logger.o.Lines()
+	require.Len(t, lines, 1, "errors should always be logged")
+	assert.Equal(t, "finished call", lines[0].msg)
+	assert.Equal(t, "Internal", lines[0].fields["grpc.code"])
+	assert.EqualValues(t, 5, sampler.Suppressed())
+}
+
+func TestUnaryServerInterceptor_SlowCalls(t *testing.T) {
+	info := &grpc.UnaryServerInfo{FullMethod: testpb.TestServiceFullName + "/Ping"}
+	slowHandler := func(_ context.Context, _ any) (any, error) {
+		time.Sleep(20 * time.Millisecond)
+		return &testpb.PingResponse{}, nil
+	}
+	fastHandler := func(_ context.Context, _ any) (any, error) {
+		return &testpb.PingResponse{}, nil
+	}
+	slowCalls := logging.WithSlowCalls(logging.SlowCallThresholds(10*time.Millisecond, nil))
+
+	t.Run("raises level", func(t *testing.T) {
+		logger := newMockLogger()
+		interceptor := logging.UnaryServerInterceptor(logger, slowCalls)
+		_, err := interceptor(context.Background(), testpb.GoodPing, info, slowHandler)
+		require.NoError(t, err)
+		_, err = interceptor(context.Background(), testpb.GoodPing, info, fastHandler)
+		require.NoError(t, err)
+
+		lines := logger.o.Lines()
+		require.Len(t, lines, 4)
+		assert.Equal(t, "finished call", lines[1].msg)
+		assert.Equal(t, logging.LevelWarn, lines[1].lvl)
+		assert.Equal(t, "true", lines[1].fields[logging.SlowCallFieldKey])
+		assert.Equal(t, "finished call", lines[3].msg)
+		assert.Equal(t, logging.LevelInfo, lines[3].lvl)
+		assert.NotContains(t, lines[3].fields, logging.SlowCallFieldKey)
+	})
+
+	t.Run("only slow calls", func(t *testing.T) {
+		logger := newMockLogger()
+		interceptor := logging.UnaryServerInterceptor(logger, slowCalls, logging.WithOnlySlowCalls())
+		_, err := interceptor(context.Background(), testpb.GoodPing, info, fastHandler)
+		require.NoError(t, err)
+		_, err = interceptor(context.Background(), testpb.GoodPing, info, slowHandler)
+		require.NoError(t, err)
+
+		lines := logger.o.Lines()
+		require.Len(t, lines, 1)
+		assert.Equal(t, "finished call", lines[0].msg)
+		assert.Equal(t, "true", lines[0].fields[logging.SlowCallFieldKey])
+	})
+}
+
+// fakeServerStream receives the given requests, then io.EOF.
+type fakeServerStream struct {
+	grpc.ServerStream
+	requests []*testpb.PingStreamRequest
+}
+
+func (f *fakeServerStream) Context() context.Context { return context.Background() }
+
+func (f *fakeServerStream) SendMsg(any) error { return nil }
+
+func (f *fakeServerStream) RecvMsg(m any) error {
+	if len(f.requests) == 0 {
+		return io.EOF
+	}
+	proto.Merge(m.(proto.Message), f.requests[0])
+	f.requests = f.requests[1:]
+	return nil
+}
+
+func TestStreamServerInterceptor_StreamSummary(t *testing.T) {
+	logger := newMockLogger()
+	interceptor := logging.StreamServerInterceptor(logger,
+		logging.WithLogOnEvents(logging.PayloadReceived, logging.PayloadSent, logging.FinishCall),
+		logging.WithStreamSummary(),
+	)
+	req := &testpb.PingStreamRequest{Value: "something"}
+	stream := &fakeServerStream{requests: []*testpb.PingStreamRequest{req, req, req}}
+	info := &grpc.StreamServerInfo{FullMethod: testpb.TestServiceFullName + "/PingStream", IsClientStream: true, IsServerStream: true}
+	err := interceptor(nil, stream, info, func(_ any, ss grpc.ServerStream) error {
+		for {
+			var r testpb.PingStreamRequest
+			if err := ss.RecvMsg(&r); err != nil {
+				return nil
+			}
+			if err := ss.SendMsg(&testpb.PingStreamResponse{Value: r.Value}); err != nil {
+				return err
+			}
+		}
+	})
+	require.NoError(t, err)
+
+	lines := logger.o.Lines()
+	require.Len(t, lines, 7)
+	for i := range 3 {
+		assert.Equal(t, "request received", lines[2*i].msg)
+		assert.Equal(t, strconv.Itoa(i+1), lines[2*i].fields["grpc.recv.seq"])
+		assert.Equal(t, "response sent", lines[2*i+1].msg)
+		assert.Equal(t, strconv.Itoa(i+1), lines[2*i+1].fields["grpc.send.seq"])
+	}
+	finish := lines[6].fields
+	assert.Equal(t, "3", finish["grpc.stream.sent"])
+	assert.Equal(t, "3", finish["grpc.stream.received"])
+	assert.Equal(t, strconv.Itoa(3*proto.Size(&testpb.PingStreamResponse{Value: "something"})), finish["grpc.stream.sent_bytes"])
+	assert.Equal(t, strconv.Itoa(3*proto.Size(req)), finish["grpc.stream.received_bytes"])
+	assert.NotEmpty(t, finish["grpc.stream.first_received_ms"])
+	assert.NotEmpty(t, finish["grpc.stream.max_gap_ms"])
+}
+
+func TestUnaryServerInterceptor_StreamSummaryIgnored(t *testing.T) {
+	logger := newMockLogger()
+	interceptor := logging.UnaryServerInterceptor(logger,
+		logging.WithLogOnEvents(logging.PayloadReceived, logging.FinishCall),
+		logging.WithStreamSummary(),
+	)
+	info := &grpc.UnaryServerInfo{FullMethod: testpb.TestServiceFullName + "/Ping"}
+	_, err := interceptor(context.Background(), testpb.GoodPing, info, func(_ context.Context, _ any) (any, error) {
+		return &testpb.PingResponse{}, nil
+	})
+	require.NoError(t, err)
+
+	lines := logger.o.Lines()
+	require.Len(t, lines, 2)
+	assert.NotContains(t, lines[0].fields, "grpc.recv.seq")
+	assert.NotContains(t, lines[1].fields, "grpc.stream.received")
+}
+
+func TestUnaryServerInterceptor_MetadataFields(t *testing.T) {
+	logger := newMockLogger()
+	interceptor := logging.UnaryServerInterceptor(logger,
+		logging.WithMetadataFields([]string{"X-Request-Id", "authorization", "trace-bin"}, nil),
+	)
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+		"x-request-id", "a", "x-request-id", "b",
+		"authorization", "Bearer secret",
+		"trace-bin", "\x01\x02",
+		"user-agent", "test",
+	))
+	info := &grpc.UnaryServerInfo{FullMethod: testpb.TestServiceFullName + "/Ping"}
+	_, err := interceptor(ctx, testpb.GoodPing, info, func(_ context.Context, _ any) (any, error) {
+		return &testpb.PingResponse{}, nil
+	})
+	require.NoError(t, err)
+
+	lines := logger.o.Lines()
+	require.Len(t, lines, 2)
+	for _, l := range lines {
+		assert.Equal(t, "a,b", l.fields["grpc.request.metadata.x-request-id"])
+		assert.Equal(t, logging.DefaultRedactionMask, l.fields["grpc.request.metadata.authorization"])
+		assert.Equal(t, "AQI=", l.fields["grpc.request.metadata.trace-bin"])
+		assert.NotContains(t, l.fields, "grpc.request.metadata.user-agent")
+	}
+}
+
+func TestUnaryClientInterceptor_MetadataFields(t *testing.T) {
+	logger := newMockLogger()
+	interceptor := logging.UnaryClientInterceptor(logger,
+		logging.WithMetadataFields([]string{logging.AllMetadataKeys}, []string{"x-internal"}),
+	)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-request-id", "a", "cookie", "c", "x-internal", "i")
+	invoker := func(_ context.Context, _ string, _, _ any, _ *grpc.ClientConn, opts ...grpc.CallOption) error {
+		for _, o := range opts {
+			switch o := o.(type) {
+			case grpc.HeaderCallOption:
+				*o.HeaderAddr = metadata.Pairs("x-served-by", "proxy-1", "x-internal", "i")
+			case grpc.TrailerCallOption:
+				*o.TrailerAddr = metadata.Pairs("x-upstream-time", "12")
+			}
+		}
+		return nil
+	}
+	err := interceptor(ctx, testpb.TestServiceFullName+"/Ping", testpb.GoodPing, &testpb.PingResponse{}, nil, invoker)
+	require.NoError(t, err)
+
+	lines := logger.o.Lines()
+	require.Len(t, lines, 2)
+	assert.Equal(t, "a", lines[0].fields["grpc.request.metadata.x-request-id"])
+	assert.Equal(t, logging.DefaultRedactionMask, lines[0].fields["grpc.request.metadata.cookie"])
+	assert.NotContains(t, lines[0].fields, "grpc.request.metadata.x-internal")
+	assert.NotContains(t, lines[0].fields, "grpc.response.header.x-served-by", "the response header is only known at the end of the call")
+
+	finish := lines[1].fields
+	assert.Equal(t, "finished call", lines[1].msg)
+	assert.Equal(t, "a", finish["grpc.request.metadata.x-request-id"])
+	assert.Equal(t, "proxy-1", finish["grpc.response.header.x-served-by"])
+	assert.Equal(t, "12", finish["grpc.response.trailer.x-upstream-time"])
+	assert.NotContains(t, finish, "grpc.response.header.x-internal")
+}
+
+func TestUnaryServerInterceptor_MetadataFieldsEmptyAllowList(t *testing.T) {
+	logger := newMockLogger()
+	interceptor := logging.UnaryServerInterceptor(logger, logging.WithMetadataFields(nil, nil))
+	info := &grpc.UnaryServerInfo{FullMethod: testpb.TestServiceFullName + "/Ping"}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-request-id", "a", "user-agent", "test"))
+	_, err := interceptor(ctx, testpb.GoodPing, info, func(_ context.Context, _ any) (any, error) {
+		return &testpb.PingResponse{}, nil
+	})
+	require.NoError(t, err)
+
+	lines := logger.o.Lines()
+	require.Len(t, lines, 2)
+	for _, l := range lines {
+		for k := range l.fields {
+			assert.NotContains(t, k, "grpc.request.metadata.", "an empty allow list should not log any key")
+		}
+	}
+}
+
+func TestUnaryServerInterceptor_ErrorDetailsToFields(t *testing.T) {
+	logger := newMockLogger()
+	interceptor := logging.UnaryServerInterceptor(logger, logging.WithErrorFields(logging.ErrorDetailsToFields))
+	info := &grpc.UnaryServerInfo{FullMethod: testpb.TestServiceFullName + "/Ping"}
+	_, err := interceptor(context.Background(), testpb.GoodPing, info, func(_ context.Context, _ any) (any, error) {
+		st, _ := status.New(codes.FailedPrecondition, "no").WithDetails(&errdetails.ErrorInfo{Reason: "DISABLED", Domain: "example.com"})
+		return nil, st.Err()
+	})
+	require.Error(t, err)
+
+	lines := logger.o.Lines()
+	require.Len(t, lines, 2)
+	assert.Equal(t, "DISABLED", lines[1].fields["grpc.error.reason"])
+	assert.Equal(t, "example.com", lines[1].fields["grpc.error.domain"])
+}
+
+func TestUnaryServerInterceptor_FromContext(t *testing.T) {
+	logger := newMockLogger()
+	interceptor := logging.UnaryServerInterceptor(logger)
+	ctx := logging.InjectFields(context.Background(), logging.Fields{"trace.id", "abc"})
+	info := &grpc.UnaryServerInfo{FullMethod: testpb.TestServiceFullName + "/Ping"}
+	_, err := interceptor(ctx, testpb.GoodPing, info, func(ctx context.Context, _ any) (any, error) {
+		logging.AddFields(ctx, logging.Fields{"user.id", "42"})
+		logging.FromContext(ctx).Log(ctx, logging.LevelInfo, "handling ping", "ping.value", "something", "trace.id", "override")
+		return &testpb.PingResponse{}, nil
+	})
+	require.NoError(t, err)
+
+	lines := logger.o.Lines()
+	require.Len(t, lines, 3)
+	assert.Equal(t, "handling ping", lines[1].msg)
+	assert.Equal(t, logging.LevelInfo, lines[1].lvl)
+	assert.Equal(t, testDisposableFields{
+		"protocol":         "grpc",
+		"grpc.component":   "server",
+		"grpc.service":     testpb.TestServiceFullName,
+		"grpc.method":      "Ping",
+		"grpc.method_type": "unary",
+		"trace.id":         "override",
+		"user.id":          "42",
+		"ping.value":       "something",
+	}, lines[1].fields)
+	assert.Equal(t, "abc", lines[2].fields["trace.id"])
+}
+
+func TestFromContext_NoInterceptor(t *testing.T) {
+	assert.NotPanics(t, func() {
+		logging.FromContext(context.Background()).Log(context.Background(), logging.LevelInfo, "discarded")
+	})
+}
@@ -27,10 +27,15 @@ type (
 	fieldsCtxValue  struct {
 		fields Fields
 	}
+	loggerCtxMarker struct{}
 )
 
-// fieldsCtxMarkerKey is the Context value marker that is used by logging middleware to read and write logging fields into context.
-var fieldsCtxMarkerKey = &fieldsCtxMarker{}
+var (
+	// fieldsCtxMarkerKey is the Context value marker that is used by logging middleware to read and write logging fields into context.
+	fieldsCtxMarkerKey = &fieldsCtxMarker{}
+	// loggerCtxMarkerKey is the Context value marker that is used by logging middleware to pass its Logger to FromContext.
+	loggerCtxMarkerKey = &loggerCtxMarker{}
+)
 
 func newCommonFields(kind string, c interceptors.CallMeta) Fields {
 	return Fields{
@@ -204,6 +209,24 @@ func AddFields(ctx context.Context, f Fields) {
 	t.fields = f.AppendUnique(t.fields)
 }
 
+// FromContext returns a Logger for the handlers of a call, that logs with the Logger of the logging interceptor and
+// the fields in the context (see `ExtractFields`), so that the lines of the application share the correlation fields
+// (e.g. service, method, peer and trace ID) of the lines of the interceptor. The fields are read from ctx every time
+// a line is logged, so that those added later with `AddFields` are included. In case of duplicates, the fields passed
+// to Log win.
+//
+// If ctx does not come from a call intercepted by the logging interceptor, FromContext returns a Logger that discards
+// all lines.
+func FromContext(ctx context.Context) Logger {
+	l, ok := ctx.Value(loggerCtxMarkerKey).(Logger)
+	if !ok {
+		return LoggerFunc(func(context.Context, Level, string, ...any) {})
+	}
+	return LoggerFunc(func(logCtx context.Context, level Level, msg string, fields ...any) {
+		l.Log(logCtx, level, msg, Fields(fields).AppendUnique(ExtractFields(ctx))...)
+	})
+}
+
 // Logger requires Log method, similar to experimental slog, allowing logging interceptor to be interoperable. Official
 // adapters for popular loggers are in `provider/` directory (separate modules). It's totally ok to copy simple function
 // implementation over.
@@ -0,0 +1,76 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package logging
+
+import (
+	"encoding/base64"
+	"slices"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// alwaysRedactedMetadata are the metadata keys whose values are never logged, see `WithMetadataFields`.
+var alwaysRedactedMetadata = []string{"authorization", "proxy-authorization", "cookie", "set-cookie"}
+
+// AllMetadataKeys is the wildcard allowing all the metadata keys to be logged by `WithMetadataFields`.
+const AllMetadataKeys = "*"
+
+// WithMetadataFields logs the gRPC metadata of calls with the given keys, except those in deny. No key is logged if
+// allow is empty, use `AllMetadataKeys` to log all of them. The values of the authorization, proxy-authorization, cookie and set-cookie keys are always replaced with
+// `DefaultRedactionMask`, the values of binary keys (ending with "-bin") are base64 encoded.
+//
+// The metadata of the request is logged on every log line of the call with the "grpc.request.metadata.<key>" fields.
+// On the client side, the header and trailer received from the server are also logged on the final log statement
+// with the "grpc.response.header.<key>" and "grpc.response.trailer.<key>" fields. Multiple values of a key are
+// separated with a comma.
+func WithMetadataFields(allow, deny []string) Option {
+	lower := func(keys []string) []string {
+		l := make([]string, 0, len(keys))
+		for _, k := range keys {
+			l = append(l, strings.ToLower(k))
+		}
+		return l
+	}
+	return func(o *options) {
+		o.metadataFilter = &metadataFilter{allow: lower(allow), allowAll: slices.Contains(allow, AllMetadataKeys), deny: lower(deny)}
+	}
+}
+
+type metadataFilter struct {
+	allow    []string
+	allowAll bool
+	deny     []string
+}
+
+// fields returns the fields of the allowed keys of md, each prefixed with prefix.
+func (f *metadataFilter) fields(prefix string, md metadata.MD) Fields {
+	keys := make([]string, 0, len(md))
+	for k := range md {
+		if (f.allowAll || slices.Contains(f.allow, k)) && !slices.Contains(f.deny, k) {
+			keys = append(keys, k)
+		}
+	}
+	// Sort the keys, so that the fields are always logged in the same order.
+	slices.Sort(keys)
+
+	fields := make(Fields, 0, 2*len(keys))
+	for _, k := range keys {
+		var v string
+		switch {
+		case slices.Contains(alwaysRedactedMetadata, k):
+			v = DefaultRedactionMask
+		case strings.HasSuffix(k, "-bin"):
+			vals := make([]string, 0, len(md[k]))
+			for _, val := range md[k] {
+				vals = append(vals, base64.StdEncoding.EncodeToString([]byte(val)))
+			}
+			v = strings.Join(vals, ",")
+		default:
+			v = strings.Join(md[k], ",")
+		}
+		fields = append(fields, prefix+k, v)
+	}
+	return fields
+}
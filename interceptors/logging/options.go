@@ -24,11 +24,13 @@ const (
 	// PayloadReceived is a loggable event representing received request (server) or response (client).
 	// Log line for this event also includes (potentially big) proto.Message of that payload in
 	// "grpc.request.content" (server) or "grpc.response.content" (client) field.
+	// Sensitive fields can be masked with WithPayloadRedaction.
 	// NOTE: This can get quite verbose, especially for streaming calls, use with caution (e.g. debug only purposes).
 	PayloadReceived
 	// PayloadSent is a loggable event representing sent response (server) or request (client).
 	// Log line for this event also includes (potentially big) proto.Message of that payload in
 	// "grpc.response.content" (server) or "grpc.request.content" (client) field.
+	// Sensitive fields can be masked with WithPayloadRedaction.
 	// NOTE: This can get quite verbose, especially for streaming calls, use with caution (e.g. debug only purposes).
 	PayloadSent
 )
@@ -61,6 +63,13 @@ type options struct {
 	timestampFormat         string
 	fieldsFromCtxCallMetaFn fieldsFromCtxCallMetaFn
 	disableGrpcLogFields    []string
+	redactor                *Redactor
+	payloadLimits           payloadLimits
+	sampler                 *Sampler
+	slowCallFunc            SlowCallFunc
+	onlySlowCalls           bool
+	streamSummary           bool
+	metadataFilter          *metadataFilter
 }
 
 type Option func(*options)
@@ -72,6 +81,7 @@ func evaluateServerOpt(opts []Option) *options {
 	for _, o := range opts {
 		o(optCopy)
 	}
+	optCopy.defaultSlowCalls()
 	return optCopy
 }
 
@@ -82,6 +92,7 @@ func evaluateClientOpt(opts []Option) *options {
 	for _, o := range opts {
 		o(optCopy)
 	}
+	optCopy.defaultSlowCalls()
 	return optCopy
 }
 
@@ -0,0 +1,204 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package logging
+
+import (
+	"regexp"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// DefaultRedactionMask is the value string fields are replaced with by a `Redactor`.
+const DefaultRedactionMask = "[REDACTED]"
+
+// Redactor masks the sensitive fields of the payloads logged on the PayloadReceived and PayloadSent events.
+//
+// A field is sensitive if it has the `debug_redact` field option, or is selected by one of the `RedactOption`. The
+// value of a sensitive string field (or of every element of a repeated or map string field) is replaced by the mask,
+// any other sensitive field is cleared, including whole messages. The fields of messages packed in a
+// google.protobuf.Any are not inspected.
+type Redactor struct {
+	mask        string
+	paths       map[string]struct{}
+	namePattern *regexp.Regexp
+	extensions  []protoreflect.ExtensionType
+}
+
+// RedactOption configures a `Redactor`.
+type RedactOption func(*Redactor)
+
+// NewRedactor returns a `Redactor` masking the fields with the `debug_redact` option and those selected by opts.
+func NewRedactor(opts ...RedactOption) *Redactor {
+	r := &Redactor{mask: DefaultRedactionMask, paths: map[string]struct{}{}}
+	for _, o := range opts {
+		o(r)
+	}
+	return r
+}
+
+// RedactPaths masks the fields at the given paths. A path is either the dot separated names of the fields from the
+// payload to the field (e.g. "user.credentials.password"), regardless of repeated fields and maps on the way, or
+// the full name of a field (e.g. "my.package.User.password") to mask it in any message.
+func RedactPaths(paths ...string) RedactOption {
+	return func(r *Redactor) {
+		for _, p := range paths {
+			r.paths[p] = struct{}{}
+		}
+	}
+}
+
+// RedactNamePattern masks the fields whose name matches pattern, e.g. `(?i)password|token|secret`.
+func RedactNamePattern(pattern *regexp.Regexp) RedactOption {
+	return func(r *Redactor) {
+		r.namePattern = pattern
+	}
+}
+
+// RedactFieldOption masks the fields annotated with a custom bool field option, e.g. `[(my.sensitive) = true]`.
+// The extension type is the E_ variable generated for the option, such as `mypb.E_Sensitive`.
+func RedactFieldOption(ext protoreflect.ExtensionType) RedactOption {
+	return func(r *Redactor) {
+		r.extensions = append(r.extensions, ext)
+	}
+}
+
+// RedactMask sets the value string fields are replaced with, `DefaultRedactionMask` by default.
+func RedactMask(mask string) RedactOption {
+	return func(r *Redactor) {
+		r.mask = mask
+	}
+}
+
+// WithPayloadRedaction masks the sensitive fields of the payloads before they are logged on the PayloadReceived and
+// PayloadSent events, see `Redactor`. The payloads sent and received by the call are never modified.
+func WithPayloadRedaction(r *Redactor) Option {
+	return func(o *options) {
+		o.redactor = r
+	}
+}
+
+// Redact returns a copy of msg with its sensitive fields masked, or msg itself if it has none.
+func (r *Redactor) Redact(msg proto.Message) proto.Message {
+	if msg == nil {
+		return nil
+	}
+	if !r.needsRedaction(msg.ProtoReflect(), "") {
+		return msg
+	}
+	c := proto.Clone(msg)
+	r.redact(c.ProtoReflect(), "")
+	return c
+}
+
+func (r *Redactor) sensitive(fd protoreflect.FieldDescriptor, path string) bool {
+	if _, ok := r.paths[path]; ok {
+		return true
+	}
+	if _, ok := r.paths[string(fd.FullName())]; ok {
+		return true
+	}
+	if r.namePattern != nil && r.namePattern.MatchString(string(fd.Name())) {
+		return true
+	}
+	fo, ok := fd.Options().(*descriptorpb.FieldOptions)
+	if !ok || fo == nil {
+		return false
+	}
+	if fo.GetDebugRedact() {
+		return true
+	}
+	for _, ext := range r.extensions {
+		if v, ok := proto.GetExtension(fo, ext).(bool); ok && v {
+			return true
+		}
+	}
+	return false
+}
+
+// needsRedaction reports whether any populated field of m is sensitive, to avoid copying messages that have none.
+func (r *Redactor) needsRedaction(m protoreflect.Message, path string) bool {
+	found := false
+	r.rangeMessages(m, path, func(protoreflect.Message, protoreflect.FieldDescriptor) {
+		found = true
+	})
+	return found
+}
+
+func (r *Redactor) redact(m protoreflect.Message, path string) {
+	r.rangeMessages(m, path, func(owner protoreflect.Message, fd protoreflect.FieldDescriptor) {
+		r.maskField(owner, fd)
+	})
+}
+
+// rangeMessages calls f with the sensitive populated fields of m and of its nested messages, along with the message
+// they belong to. The fields of a message are reported once it has been iterated, so that f can modify it.
+func (r *Redactor) rangeMessages(m protoreflect.Message, path string, f func(owner protoreflect.Message, fd protoreflect.FieldDescriptor)) {
+	var found []protoreflect.FieldDescriptor
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		p := string(fd.Name())
+		if path != "" {
+			p = path + "." + p
+		}
+		if r.sensitive(fd, p) {
+			found = append(found, fd)
+			return true
+		}
+		switch {
+		case fd.IsMap():
+			if fd.MapValue().Message() != nil {
+				v.Map().Range(func(_ protoreflect.MapKey, mv protoreflect.Value) bool {
+					r.rangeMessages(mv.Message(), p, f)
+					return true
+				})
+			}
+		case fd.IsList():
+			if fd.Message() != nil {
+				for i := 0; i < v.List().Len(); i++ {
+					r.rangeMessages(v.List().Get(i).Message(), p, f)
+				}
+			}
+		case fd.Message() != nil:
+			r.rangeMessages(v.Message(), p, f)
+		}
+		return true
+	})
+	for _, fd := range found {
+		f(m, fd)
+	}
+}
+
+func (r *Redactor) maskField(m protoreflect.Message, fd protoreflect.FieldDescriptor) {
+	mask := protoreflect.ValueOfString(r.mask)
+	switch {
+	case fd.IsMap():
+		if fd.MapValue().Kind() != protoreflect.StringKind {
+			break
+		}
+		mp := m.Mutable(fd).Map()
+		var keys []protoreflect.MapKey
+		mp.Range(func(k protoreflect.MapKey, _ protoreflect.Value) bool {
+			keys = append(keys, k)
+			return true
+		})
+		for _, k := range keys {
+			mp.Set(k, mask)
+		}
+		return
+	case fd.IsList():
+		if fd.Kind() != protoreflect.StringKind {
+			break
+		}
+		l := m.Mutable(fd).List()
+		for i := 0; i < l.Len(); i++ {
+			l.Set(i, mask)
+		}
+		return
+	case fd.Kind() == protoreflect.StringKind:
+		m.Set(fd, mask)
+		return
+	}
+	m.Clear(fd)
+}
@@ -0,0 +1,175 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package logging
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/testing/testpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// loginDescriptor describes:
+//
+//	message Credentials {
+//	  string password = 1 [debug_redact = true];
+//	  string user = 2;
+//	}
+//	message Login {
+//	  Credentials creds = 1;
+//	  repeated string tokens = 2;
+//	  map<string, string> headers = 3;
+//	  repeated Credentials history = 4;
+//	  int64 pin = 5;
+//	  string note = 6;
+//	}
+func loginDescriptor(t *testing.T) protoreflect.MessageDescriptor {
+	t.Helper()
+
+	field := func(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, label descriptorpb.FieldDescriptorProto_Label, typeName string) *descriptorpb.FieldDescriptorProto {
+		f := &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(number),
+			Type:     typ.Enum(),
+			Label:    label.Enum(),
+		}
+		if typeName != "" {
+			f.TypeName = proto.String(typeName)
+		}
+		return f
+	}
+	const (
+		optional = descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+		repeated = descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+		str      = descriptorpb.FieldDescriptorProto_TYPE_STRING
+		msg      = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE
+	)
+	password := field("password", 1, str, optional, "")
+	password.Options = &descriptorpb.FieldOptions{DebugRedact: proto.Bool(true)}
+
+	fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("redact_test.proto"),
+		Package: proto.String("redact.test"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name:  proto.String("Credentials"),
+				Field: []*descriptorpb.FieldDescriptorProto{password, field("user", 2, str, optional, "")},
+			},
+			{
+				Name: proto.String("Login"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("creds", 1, msg, optional, ".redact.test.Credentials"),
+					field("tokens", 2, str, repeated, ""),
+					field("headers", 3, msg, repeated, ".redact.test.Login.HeadersEntry"),
+					field("history", 4, msg, repeated, ".redact.test.Credentials"),
+					field("pin", 5, descriptorpb.FieldDescriptorProto_TYPE_INT64, optional, ""),
+					field("note", 6, str, optional, ""),
+				},
+				NestedType: []*descriptorpb.DescriptorProto{{
+					Name:    proto.String("HeadersEntry"),
+					Field:   []*descriptorpb.FieldDescriptorProto{field("key", 1, str, optional, ""), field("value", 2, str, optional, "")},
+					Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+				}},
+			},
+		},
+	}, nil)
+	require.NoError(t, err)
+	return fd.Messages().ByName("Login")
+}
+
+func newLogin(t *testing.T) *dynamicpb.Message {
+	t.Helper()
+
+	login := dynamicpb.NewMessage(loginDescriptor(t))
+	fields := login.Descriptor().Fields()
+	newCreds := func(user, password string) protoreflect.Value {
+		creds := login.NewField(fields.ByName("creds")).Message()
+		creds.Set(creds.Descriptor().Fields().ByName("user"), protoreflect.ValueOfString(user))
+		creds.Set(creds.Descriptor().Fields().ByName("password"), protoreflect.ValueOfString(password))
+		return protoreflect.ValueOfMessage(creds)
+	}
+	login.Set(fields.ByName("creds"), newCreds("alice", "secret"))
+	history := login.Mutable(fields.ByName("history")).List()
+	history.Append(newCreds("bob", "old-secret"))
+	tokens := login.Mutable(fields.ByName("tokens")).List()
+	tokens.Append(protoreflect.ValueOfString("t1"))
+	tokens.Append(protoreflect.ValueOfString("t2"))
+	headers := login.Mutable(fields.ByName("headers")).Map()
+	headers.Set(protoreflect.ValueOfString("authorization").MapKey(), protoreflect.ValueOfString("Bearer x"))
+	login.Set(fields.ByName("pin"), protoreflect.ValueOfInt64(1234))
+	login.Set(fields.ByName("note"), protoreflect.ValueOfString("hello"))
+	return login
+}
+
+// get returns the value at the path of field names, following the first element of lists.
+func get(m protoreflect.Message, names ...string) protoreflect.Value {
+	for i, name := range names {
+		fd := m.Descriptor().Fields().ByName(protoreflect.Name(name))
+		v := m.Get(fd)
+		if fd.IsList() && fd.Message() != nil {
+			v = v.List().Get(0)
+		}
+		if i == len(names)-1 {
+			return v
+		}
+		m = v.Message()
+	}
+	return protoreflect.Value{}
+}
+
+func TestRedactor_DebugRedact(t *testing.T) {
+	login := newLogin(t)
+	redacted := NewRedactor().Redact(login).ProtoReflect()
+
+	assert.Equal(t, DefaultRedactionMask, get(redacted, "creds", "password").String())
+	assert.Equal(t, DefaultRedactionMask, get(redacted, "history", "password").String())
+	assert.Equal(t, "alice", get(redacted, "creds", "user").String())
+	assert.Equal(t, "hello", get(redacted, "note").String())
+
+	// The original message is left untouched.
+	assert.Equal(t, "secret", get(login, "creds", "password").String())
+	assert.Equal(t, "old-secret", get(login, "history", "password").String())
+}
+
+func TestRedactor_Paths(t *testing.T) {
+	login := newLogin(t)
+	redacted := NewRedactor(
+		RedactPaths("creds.user", "tokens", "headers", "redact.test.Login.pin"),
+		RedactMask("***"),
+	).Redact(login).ProtoReflect()
+
+	assert.Equal(t, "***", get(redacted, "creds", "user").String())
+	assert.Equal(t, "bob", get(redacted, "history", "user").String(), "paths should only match from the root")
+	tokens := get(redacted, "tokens").List()
+	require.Equal(t, 2, tokens.Len())
+	assert.Equal(t, "***", tokens.Get(0).String())
+	assert.Equal(t, "***", tokens.Get(1).String())
+	assert.Equal(t, "***", get(redacted, "headers").Map().Get(protoreflect.ValueOfString("authorization").MapKey()).String())
+	assert.False(t, redacted.Has(redacted.Descriptor().Fields().ByName("pin")), "non string fields should be cleared")
+	assert.Equal(t, "hello", get(redacted, "note").String())
+}
+
+func TestRedactor_NamePattern(t *testing.T) {
+	redacted := NewRedactor(RedactNamePattern(regexp.MustCompile(`^(creds|note)$`))).Redact(newLogin(t)).ProtoReflect()
+
+	fields := redacted.Descriptor().Fields()
+	assert.False(t, redacted.Has(fields.ByName("creds")), "messages should be cleared")
+	assert.Equal(t, DefaultRedactionMask, get(redacted, "note").String())
+	assert.Equal(t, "bob", get(redacted, "history", "user").String())
+}
+
+func TestRedactor_NothingToRedact(t *testing.T) {
+	msg := &testpb.PingRequest{Value: "something"}
+	assert.Same(t, msg, NewRedactor().Redact(msg))
+	assert.Nil(t, NewRedactor().Redact(nil))
+}
@@ -0,0 +1,166 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package logging
+
+import (
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors"
+)
+
+// Sampler decides which calls are logged, to reduce the cost of logging high-volume methods.
+//
+// The decision is made once when a call starts (head sampling), so that all the log lines of a call are either
+// logged or suppressed together. A call is sampled with the rate of its method and, if a rate limit is set, only if
+// the token bucket of its method has a token left. Failed calls, calls slower than the threshold set with
+// `SampleSlowerThan` and slow calls (see `WithSlowCalls`) always log their "finished call" line, even if they were
+// not sampled.
+//
+// A Sampler can be shared by several interceptors, in which case they share the rate limits.
+type Sampler struct {
+	rate        float64
+	rates       map[string]float64
+	limit       float64
+	burst       int
+	slowerThan  time.Duration
+	onSuppress  func(c interceptors.CallMeta)
+	randFloat64 func() float64
+	now         func() time.Time
+
+	mu         sync.Mutex
+	buckets    map[string]*tokenBucket
+	suppressed atomic.Uint64
+}
+
+// SamplerOption configures a `Sampler`.
+type SamplerOption func(*Sampler)
+
+// NewSampler returns a `Sampler` that logs every call unless configured otherwise by opts.
+func NewSampler(opts ...SamplerOption) *Sampler {
+	s := &Sampler{
+		rate:        1,
+		rates:       map[string]float64{},
+		randFloat64: rand.Float64,
+		now:         time.Now,
+		buckets:     map[string]*tokenBucket{},
+	}
+	for _, o := range opts {
+		o(s)
+	}
+	return s
+}
+
+// SampleRate sets the share of calls that are logged, between 0 (none) and 1 (all, the default), for the methods
+// without a rate set by `SampleMethodRate`.
+func SampleRate(rate float64) SamplerOption {
+	return func(s *Sampler) {
+		s.rate = rate
+	}
+}
+
+// SampleMethodRate sets the share of calls of a method that are logged, between 0 (none) and 1 (all). The method is
+// either a full method name, e.g. "/my.package.Service/Method", or a service name, e.g. "my.package.Service", for
+// all of its methods. The rate of a full method name takes precedence over the rate of its service.
+func SampleMethodRate(method string, rate float64) SamplerOption {
+	return func(s *Sampler) {
+		s.rates[method] = rate
+	}
+}
+
+// SampleRateLimit limits the sampled calls of every method to perSecond on average, with bursts of up to burst calls.
+// Each method has its own token bucket. A perSecond value of 0, the default, disables the limit.
+func SampleRateLimit(perSecond float64, burst int) SamplerOption {
+	return func(s *Sampler) {
+		s.limit = perSecond
+		s.burst = max(burst, 1)
+	}
+}
+
+// SampleSlowerThan makes the calls taking longer than d log their "finished call" line even if they were not
+// sampled. A value of 0, the default, disables it.
+func SampleSlowerThan(d time.Duration) SamplerOption {
+	return func(s *Sampler) {
+		s.slowerThan = d
+	}
+}
+
+// SampleOnSuppress sets a function called with every log line suppressed by the sampler, e.g. to count them in a
+// metric.
+func SampleOnSuppress(f func(c interceptors.CallMeta)) SamplerOption {
+	return func(s *Sampler) {
+		s.onSuppress = f
+	}
+}
+
+// WithSampling only logs the calls sampled by s, see `Sampler`.
+func WithSampling(s *Sampler) Option {
+	return func(o *options) {
+		o.sampler = s
+	}
+}
+
+// Suppressed returns the number of log lines suppressed by the sampler so far.
+func (s *Sampler) Suppressed() uint64 {
+	return s.suppressed.Load()
+}
+
+// sample makes the sampling decision for a call starting.
+func (s *Sampler) sample(c interceptors.CallMeta) bool {
+	fullMethod := c.FullMethod()
+	rate, ok := s.rates[fullMethod]
+	if !ok {
+		if rate, ok = s.rates[c.Service]; !ok {
+			rate = s.rate
+		}
+	}
+	if rate < 1 && (rate <= 0 || s.randFloat64() >= rate) {
+		return false
+	}
+	if s.limit <= 0 {
+		return true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.buckets[fullMethod]
+	if !ok {
+		b = &tokenBucket{tokens: float64(s.burst), last: s.now()}
+		s.buckets[fullMethod] = b
+	}
+	return b.take(s.now(), s.limit, float64(s.burst))
+}
+
+// alwaysLog reports whether the "finished call" line of a call must be logged even if it was not sampled.
+func (s *Sampler) alwaysLog(failed bool, duration time.Duration) bool {
+	return failed || (s.slowerThan > 0 && duration > s.slowerThan)
+}
+
+// suppress records n suppressed log lines of a call.
+func (s *Sampler) suppress(c interceptors.CallMeta, n int) {
+	s.suppressed.Add(uint64(n))
+	if s.onSuppress != nil {
+		for range n {
+			s.onSuppress(c)
+		}
+	}
+}
+
+// tokenBucket holds up to burst tokens, refilled at a constant rate.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func (b *tokenBucket) take(now time.Time, perSecond, burst float64) bool {
+	b.tokens = min(burst, b.tokens+now.Sub(b.last).Seconds()*perSecond)
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
@@ -0,0 +1,66 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package logging
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSampler_Rates(t *testing.T) {
+	s := NewSampler(
+		SampleRate(0.5),
+		SampleMethodRate("my.Service", 0),
+		SampleMethodRate("/my.Service/Important", 1),
+	)
+	ping := interceptors.NewServerCallMeta("/other.Service/Ping", nil, nil)
+
+	s.randFloat64 = func() float64 { return 0.4 }
+	assert.True(t, s.sample(ping))
+	s.randFloat64 = func() float64 { return 0.5 }
+	assert.False(t, s.sample(ping))
+
+	assert.False(t, s.sample(interceptors.NewServerCallMeta("/my.Service/Other", nil, nil)), "the rate of the service should apply")
+	assert.True(t, s.sample(interceptors.NewServerCallMeta("/my.Service/Important", nil, nil)), "the rate of the method should take precedence")
+}
+
+func TestSampler_RateLimit(t *testing.T) {
+	now := time.Unix(0, 0)
+	s := NewSampler(SampleRateLimit(2, 3))
+	s.now = func() time.Time { return now }
+	ping := interceptors.NewServerCallMeta("/my.Service/Ping", nil, nil)
+	other := interceptors.NewServerCallMeta("/my.Service/Other", nil, nil)
+
+	for range 3 {
+		assert.True(t, s.sample(ping), "bursts should be allowed")
+	}
+	assert.False(t, s.sample(ping))
+	assert.True(t, s.sample(other), "every method should have its own bucket")
+
+	now = now.Add(time.Second)
+	assert.True(t, s.sample(ping))
+	assert.True(t, s.sample(ping))
+	assert.False(t, s.sample(ping), "tokens should be refilled at the rate limit")
+}
+
+func TestSampler_AlwaysLog(t *testing.T) {
+	s := NewSampler(SampleRate(0), SampleSlowerThan(time.Second))
+	assert.True(t, s.alwaysLog(true, 0))
+	assert.True(t, s.alwaysLog(false, 2*time.Second))
+	assert.False(t, s.alwaysLog(false, time.Millisecond))
+	assert.False(t, NewSampler().alwaysLog(false, time.Hour), "slow calls are not logged without a threshold")
+}
+
+func TestSampler_Suppressed(t *testing.T) {
+	var calls []string
+	s := NewSampler(SampleOnSuppress(func(c interceptors.CallMeta) {
+		calls = append(calls, c.FullMethod())
+	}))
+	s.suppress(interceptors.NewServerCallMeta("/my.Service/Ping", nil, nil), 2)
+	assert.EqualValues(t, 2, s.Suppressed())
+	assert.Equal(t, []string{"/my.Service/Ping", "/my.Service/Ping"}, calls)
+}
@@ -0,0 +1,61 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package logging
+
+import (
+	"time"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors"
+)
+
+const (
+	// SlowCallFieldKey is the field set to "true" on the final log statement of slow calls, see `WithSlowCalls`.
+	SlowCallFieldKey = "grpc.slow"
+
+	// DefaultSlowCallThreshold is the threshold of the calls logged by `WithOnlySlowCalls` without `WithSlowCalls`.
+	DefaultSlowCallThreshold = time.Second
+)
+
+// SlowCallFunc reports whether a call that took duration is slow.
+type SlowCallFunc func(c interceptors.CallMeta, duration time.Duration) bool
+
+// SlowCallThresholds returns a `SlowCallFunc` reporting the calls that took longer than the threshold of their method
+// as slow. The keys of perMethod are either full method names, e.g. "/my.package.Service/Method", or service names,
+// e.g. "my.package.Service", for all of its methods. The threshold of a full method name takes precedence over the
+// one of its service, and defaultThreshold applies to the other methods. A threshold of 0 never reports calls as slow.
+func SlowCallThresholds(defaultThreshold time.Duration, perMethod map[string]time.Duration) SlowCallFunc {
+	return func(c interceptors.CallMeta, duration time.Duration) bool {
+		threshold, ok := perMethod[c.FullMethod()]
+		if !ok {
+			if threshold, ok = perMethod[c.Service]; !ok {
+				threshold = defaultThreshold
+			}
+		}
+		return threshold > 0 && duration > threshold
+	}
+}
+
+// WithSlowCalls marks the final log statement of the calls reported as slow by f with the "grpc.slow" field, and
+// raises its level to at least warn, whatever the level of its code.
+func WithSlowCalls(f SlowCallFunc) Option {
+	return func(o *options) {
+		o.slowCallFunc = f
+	}
+}
+
+// WithOnlySlowCalls only logs the final statement of the calls reported as slow by the `WithSlowCalls` function,
+// e.g. to log the slow calls to a different logger. All the other log lines are dropped. Without `WithSlowCalls`, the
+// calls slower than `DefaultSlowCallThreshold` are reported as slow.
+func WithOnlySlowCalls() Option {
+	return func(o *options) {
+		o.onlySlowCalls = true
+	}
+}
+
+// defaultSlowCalls sets the default slow call function if only slow calls are logged and none was set.
+func (o *options) defaultSlowCalls() {
+	if o.onlySlowCalls && o.slowCallFunc == nil {
+		o.slowCallFunc = SlowCallThresholds(DefaultSlowCallThreshold, nil)
+	}
+}
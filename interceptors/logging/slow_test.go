@@ -0,0 +1,45 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package logging
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlowCallThresholds(t *testing.T) {
+	slow := SlowCallThresholds(time.Second, map[string]time.Duration{
+		"my.Service":           10 * time.Second,
+		"/my.Service/Download": time.Minute,
+		"/my.Service/Watch":    0,
+	})
+	call := func(fullMethod string) interceptors.CallMeta {
+		return interceptors.NewServerCallMeta(fullMethod, nil, nil)
+	}
+
+	assert.True(t, slow(call("/other.Service/Ping"), 2*time.Second))
+	assert.False(t, slow(call("/other.Service/Ping"), time.Second))
+	assert.False(t, slow(call("/my.Service/Ping"), 2*time.Second), "the threshold of the service should apply")
+	assert.True(t, slow(call("/my.Service/Ping"), 11*time.Second))
+	assert.False(t, slow(call("/my.Service/Download"), 11*time.Second), "the threshold of the method should take precedence")
+	assert.False(t, slow(call("/my.Service/Watch"), time.Hour), "a threshold of 0 should disable it")
+}
+
+func TestWithOnlySlowCalls_DefaultThreshold(t *testing.T) {
+	call := interceptors.NewServerCallMeta("/my.Service/Ping", nil, nil)
+
+	o := evaluateServerOpt([]Option{WithOnlySlowCalls()})
+	assert.NotNil(t, o.slowCallFunc, "only slow calls should not drop all the calls")
+	assert.True(t, o.slowCallFunc(call, DefaultSlowCallThreshold+time.Millisecond))
+	assert.False(t, o.slowCallFunc(call, DefaultSlowCallThreshold))
+
+	o = evaluateClientOpt([]Option{WithOnlySlowCalls(), WithSlowCalls(SlowCallThresholds(time.Minute, nil))})
+	assert.False(t, o.slowCallFunc(call, 2*DefaultSlowCallThreshold), "the threshold set should be kept")
+
+	o = evaluateServerOpt(nil)
+	assert.Nil(t, o.slowCallFunc)
+}
@@ -0,0 +1,90 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package logging
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// WithStreamSummary adds ordering and timing information to the logs of streaming calls:
+//   - every payload logged on the PayloadSent and PayloadReceived events carries its sequence number in the
+//     "grpc.send.seq" or "grpc.recv.seq" field, starting at 1 in each direction;
+//   - the final log statement carries a summary of the stream: the number of messages sent and received
+//     ("grpc.stream.sent", "grpc.stream.received"), their total size ("grpc.stream.sent_bytes",
+//     "grpc.stream.received_bytes"), the time from the start of the call to the first message received
+//     ("grpc.stream.first_received_ms") and the longest time without any message in either direction
+//     ("grpc.stream.max_gap_ms").
+//
+// Unary calls are not affected.
+func WithStreamSummary() Option {
+	return func(o *options) {
+		o.streamSummary = true
+	}
+}
+
+// streamStats records the messages of a stream, which can be sent and received concurrently.
+type streamStats struct {
+	mu            sync.Mutex
+	start         time.Time
+	last          time.Time
+	sent          int
+	received      int
+	sentBytes     int
+	receivedBytes int
+	firstReceived time.Duration
+	maxGap        time.Duration
+}
+
+func newStreamStats() *streamStats {
+	now := time.Now()
+	return &streamStats{start: now, last: now}
+}
+
+// record records a message sent or received, and returns its sequence number.
+func (s *streamStats) record(sent bool, payload any) int {
+	size := 0
+	if p, ok := payload.(proto.Message); ok {
+		size = proto.Size(p)
+	}
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maxGap = max(s.maxGap, now.Sub(s.last))
+	s.last = now
+	if sent {
+		s.sent++
+		s.sentBytes += size
+		return s.sent
+	}
+	if s.received == 0 {
+		s.firstReceived = now.Sub(s.start)
+	}
+	s.received++
+	s.receivedBytes += size
+	return s.received
+}
+
+// fields returns the summary of the stream when it finishes.
+func (s *streamStats) fields() Fields {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// The time since the last message counts as a gap too, e.g. if the stream was stuck until it was cancelled.
+	maxGap := max(s.maxGap, time.Since(s.last))
+	fields := Fields{
+		"grpc.stream.sent", strconv.Itoa(s.sent),
+		"grpc.stream.received", strconv.Itoa(s.received),
+		"grpc.stream.sent_bytes", strconv.Itoa(s.sentBytes),
+		"grpc.stream.received_bytes", strconv.Itoa(s.receivedBytes),
+	}
+	if s.received > 0 {
+		fields = append(fields, "grpc.stream.first_received_ms", fmt.Sprintf("%v", durationToMilliseconds(s.firstReceived)))
+	}
+	return append(fields, "grpc.stream.max_gap_ms", fmt.Sprintf("%v", durationToMilliseconds(maxGap)))
+}
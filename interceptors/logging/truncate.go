@@ -0,0 +1,196 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package logging
+
+import (
+	"fmt"
+	"strconv"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// TruncationMarker is appended to the string and bytes fields of logged payloads that were truncated.
+const TruncationMarker = "...[truncated]"
+
+// WithMaxPayloadSize limits the serialized size of the payloads logged on the PayloadReceived and PayloadSent
+// events. A payload still larger than maxBytes once its fields are truncated (see `WithMaxPayloadFieldSize` and
+// `WithMaxPayloadListLength`) is replaced by a string stating its size. A value of 0, the default, sets no limit.
+//
+// When any payload limit is set, the original serialized size of the payload, before redaction and truncation, is
+// logged in the "grpc.request.size" or "grpc.response.size" field, and "grpc.request.truncated" or "grpc.response.truncated" is set to "true" if it
+// was truncated.
+func WithMaxPayloadSize(maxBytes int) Option {
+	return func(o *options) {
+		o.payloadLimits.maxSize = maxBytes
+	}
+}
+
+// WithMaxPayloadFieldSize truncates the string and bytes fields of logged payloads longer than maxBytes, and appends
+// `TruncationMarker` to them. A value of 0, the default, sets no limit.
+func WithMaxPayloadFieldSize(maxBytes int) Option {
+	return func(o *options) {
+		o.payloadLimits.maxFieldSize = maxBytes
+	}
+}
+
+// WithMaxPayloadListLength only logs the first maxElements elements of the repeated fields of logged payloads, and
+// maxElements entries of their map fields. A value of 0, the default, sets no limit.
+func WithMaxPayloadListLength(maxElements int) Option {
+	return func(o *options) {
+		o.payloadLimits.maxListLength = maxElements
+	}
+}
+
+type payloadLimits struct {
+	maxSize       int
+	maxFieldSize  int
+	maxListLength int
+}
+
+func (l payloadLimits) enabled() bool {
+	return l.maxSize > 0 || l.maxFieldSize > 0 || l.maxListLength > 0
+}
+
+// fields returns the fields logging p, the possibly redacted version of original, as callType ("request" or
+// "response") within the limits. The size logged is the one of original, as sent on the wire.
+func (l payloadLimits) fields(callType string, original, p proto.Message) Fields {
+	contentKey := fmt.Sprintf("grpc.%s.content", callType)
+	if !l.enabled() {
+		return Fields{contentKey, p}
+	}
+	originalSize := proto.Size(original)
+	fields := Fields{fmt.Sprintf("grpc.%s.size", callType), strconv.Itoa(originalSize)}
+	size := originalSize
+	if p != original {
+		size = proto.Size(p)
+	}
+
+	var content any = p
+	truncated := false
+	if l.truncateMessage(p.ProtoReflect(), false) {
+		c := proto.Clone(p)
+		l.truncateMessage(c.ProtoReflect(), true)
+		content, truncated = c, true
+		size = proto.Size(c)
+	}
+	if l.maxSize > 0 && size > l.maxSize {
+		content = fmt.Sprintf("[%d bytes payload omitted]", originalSize)
+		truncated = true
+	}
+	fields = append(fields, contentKey, content)
+	if truncated {
+		fields = append(fields, fmt.Sprintf("grpc.%s.truncated", callType), "true")
+	}
+	return fields
+}
+
+// truncateMessage reports whether any field of m exceeds the limits and, if apply is true, truncates them.
+func (l payloadLimits) truncateMessage(m protoreflect.Message, apply bool) bool {
+	type populated struct {
+		fd protoreflect.FieldDescriptor
+		v  protoreflect.Value
+	}
+	var fields []populated
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		fields = append(fields, populated{fd: fd, v: v})
+		return true
+	})
+
+	truncated := false
+	for _, f := range fields {
+		switch {
+		case f.fd.IsMap():
+			mp := f.v.Map()
+			if l.maxListLength > 0 && mp.Len() > l.maxListLength {
+				truncated = true
+				if !apply {
+					return true
+				}
+				var extra []protoreflect.MapKey
+				n := 0
+				mp.Range(func(k protoreflect.MapKey, _ protoreflect.Value) bool {
+					if n++; n > l.maxListLength {
+						extra = append(extra, k)
+					}
+					return true
+				})
+				for _, k := range extra {
+					mp.Clear(k)
+				}
+			}
+			var keys []protoreflect.MapKey
+			mp.Range(func(k protoreflect.MapKey, _ protoreflect.Value) bool {
+				keys = append(keys, k)
+				return true
+			})
+			for _, k := range keys {
+				if v, ok := l.truncateValue(f.fd.MapValue(), mp.Get(k), apply); ok {
+					truncated = true
+					if !apply {
+						return true
+					}
+					mp.Set(k, v)
+				}
+			}
+		case f.fd.IsList():
+			list := f.v.List()
+			if l.maxListLength > 0 && list.Len() > l.maxListLength {
+				truncated = true
+				if !apply {
+					return true
+				}
+				list.Truncate(l.maxListLength)
+			}
+			for i := 0; i < list.Len(); i++ {
+				if v, ok := l.truncateValue(f.fd, list.Get(i), apply); ok {
+					truncated = true
+					if !apply {
+						return true
+					}
+					list.Set(i, v)
+				}
+			}
+		default:
+			if v, ok := l.truncateValue(f.fd, f.v, apply); ok {
+				truncated = true
+				if !apply {
+					return true
+				}
+				m.Set(f.fd, v)
+			}
+		}
+	}
+	return truncated
+}
+
+// truncateValue returns the truncated value of a single (not repeated) value of fd, and whether it was truncated.
+func (l payloadLimits) truncateValue(fd protoreflect.FieldDescriptor, v protoreflect.Value, apply bool) (protoreflect.Value, bool) {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		s := v.String()
+		if l.maxFieldSize <= 0 || len(s) <= l.maxFieldSize {
+			return v, false
+		}
+		// Cut on a rune boundary, as strings must remain valid UTF-8.
+		n := l.maxFieldSize
+		for n > 0 && !utf8.RuneStart(s[n]) {
+			n--
+		}
+		return protoreflect.ValueOfString(s[:n] + TruncationMarker), true
+	case protoreflect.BytesKind:
+		b := v.Bytes()
+		if l.maxFieldSize <= 0 || len(b) <= l.maxFieldSize {
+			return v, false
+		}
+		t := make([]byte, 0, l.maxFieldSize+len(TruncationMarker))
+		t = append(append(t, b[:l.maxFieldSize]...), TruncationMarker...)
+		return protoreflect.ValueOfBytes(t), true
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return v, l.truncateMessage(v.Message(), apply)
+	default:
+		return v, false
+	}
+}
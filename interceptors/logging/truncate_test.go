@@ -0,0 +1,91 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package logging
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/testing/testpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+func fieldsMap(t *testing.T, f Fields) map[string]any {
+	t.Helper()
+
+	m := map[string]any{}
+	i := f.Iterator()
+	for i.Next() {
+		k, v := i.At()
+		m[k] = v
+	}
+	return m
+}
+
+func TestPayloadLimits_Disabled(t *testing.T) {
+	p := &testpb.PingRequest{Value: strings.Repeat("a", 100)}
+	got := fieldsMap(t, payloadLimits{}.fields("request", p, p))
+	assert.Equal(t, map[string]any{"grpc.request.content": p}, got)
+}
+
+func TestPayloadLimits_WithinLimits(t *testing.T) {
+	p := &testpb.PingRequest{Value: "small"}
+	got := fieldsMap(t, payloadLimits{maxFieldSize: 10, maxSize: 100}.fields("request", p, p))
+	assert.Same(t, p, got["grpc.request.content"])
+	assert.Equal(t, strconv.Itoa(proto.Size(p)), got["grpc.request.size"])
+	assert.NotContains(t, got, "grpc.request.truncated")
+}
+
+func TestPayloadLimits_FieldSize(t *testing.T) {
+	p := &testpb.PingRequest{Value: "héllo world"}
+	got := fieldsMap(t, payloadLimits{maxFieldSize: 2}.fields("response", p, p))
+
+	assert.Equal(t, "h"+TruncationMarker, got["grpc.response.content"].(*testpb.PingRequest).Value, "should cut on a rune boundary")
+	assert.Equal(t, strconv.Itoa(proto.Size(p)), got["grpc.response.size"])
+	assert.Equal(t, "true", got["grpc.response.truncated"])
+	assert.Equal(t, "héllo world", p.Value, "the payload itself must not be modified")
+}
+
+func TestPayloadLimits_Lists(t *testing.T) {
+	login := newLogin(t)
+	fields := login.Descriptor().Fields()
+	tokens := login.Mutable(fields.ByName("tokens")).List()
+	tokens.Append(protoreflect.ValueOfString(strings.Repeat("x", 20)))
+	headers := login.Mutable(fields.ByName("headers")).Map()
+	headers.Set(protoreflect.ValueOfString("other").MapKey(), protoreflect.ValueOfString("value"))
+
+	got := fieldsMap(t, payloadLimits{maxListLength: 1, maxFieldSize: 5}.fields("request", login, login))
+	truncated := got["grpc.request.content"].(proto.Message).ProtoReflect()
+	require.Equal(t, 1, truncated.Get(fields.ByName("tokens")).List().Len())
+	require.Equal(t, 1, truncated.Get(fields.ByName("headers")).Map().Len())
+	assert.Equal(t, "secre"+TruncationMarker, get(truncated, "creds", "password").String(), "nested messages should be truncated")
+	assert.Equal(t, "true", got["grpc.request.truncated"])
+
+	assert.Equal(t, 3, login.Get(fields.ByName("tokens")).List().Len(), "the payload itself must not be modified")
+}
+
+func TestPayloadLimits_MaxSize(t *testing.T) {
+	p := &testpb.PingRequest{Value: strings.Repeat("a", 100)}
+	size := proto.Size(p)
+	got := fieldsMap(t, payloadLimits{maxSize: 50}.fields("request", p, p))
+	assert.Equal(t, "["+strconv.Itoa(size)+" bytes payload omitted]", got["grpc.request.content"])
+	assert.Equal(t, "true", got["grpc.request.truncated"])
+
+	// Truncating the fields can be enough to fit the limit.
+	got = fieldsMap(t, payloadLimits{maxSize: 50, maxFieldSize: 10}.fields("request", p, p))
+	assert.Equal(t, strings.Repeat("a", 10)+TruncationMarker, got["grpc.request.content"].(*testpb.PingRequest).Value)
+}
+
+func TestPayloadLimits_SizeBeforeRedaction(t *testing.T) {
+	p := &testpb.PingRequest{Value: strings.Repeat("a", 100)}
+	redacted := &testpb.PingRequest{Value: "[REDACTED]"}
+	got := fieldsMap(t, payloadLimits{maxSize: 50}.fields("request", p, redacted))
+	assert.Same(t, redacted, got["grpc.request.content"], "the redacted payload fits the limit")
+	assert.Equal(t, strconv.Itoa(proto.Size(p)), got["grpc.request.size"], "the size should be the one sent on the wire")
+	assert.NotContains(t, got, "grpc.request.truncated")
+}
@@ -10,6 +10,9 @@ Package ratelimit is a middleware that limits the rate of requests.
 
 It allows to do grpc rate limit by your own rate limiter (e.g. token bucket, leaky bucket, etc.)
 
+Instead of rejecting the requests the limiter does not allow right away, the interceptors can wait and ask the
+limiter again with `WithWaitBackoff`, using the strategies of the util/backoff package.
+
 Please see examples for simple examples of use.
 */
 package ratelimit
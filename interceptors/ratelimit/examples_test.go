@@ -5,8 +5,10 @@ package ratelimit_test
 
 import (
 	"context"
+	"time"
 
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/ratelimit"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/util/backoff"
 	"google.golang.org/grpc"
 )
 
@@ -67,6 +69,19 @@ func ExampleUnaryClientInterceptor() {
 	)
 }
 
+// Example of a unary client waiting for the limiter with a decorrelated jitter backoff, up to 5 times, instead of
+// failing the requests right away.
+func ExampleWithWaitBackoff() {
+	limiter := &alwaysPassLimiter{}
+	_, _ = grpc.NewClient(
+		":8080",
+		grpc.WithUnaryInterceptor(
+			ratelimit.UnaryClientInterceptor(limiter,
+				ratelimit.WithWaitBackoff(backoff.DecorrelatedJitter(10*time.Millisecond, time.Second), 5)),
+		),
+	)
+}
+
 // Simple example of a streaming client initialization code.
 func ExampleStreamClientInterceptor() {
 	// Create stream rateLimiter, based on token bucket here.
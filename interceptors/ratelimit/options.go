@@ -0,0 +1,62 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/util/backoff"
+)
+
+// Option configures the rate limiting interceptors.
+type Option func(*options)
+
+type options struct {
+	waitBackoff backoff.Func
+	maxWaits    uint
+}
+
+func evaluateOptions(opts []Option) *options {
+	o := &options{}
+	for _, f := range opts {
+		f(o)
+	}
+	return o
+}
+
+// WithWaitBackoff makes the interceptor wait and ask the limiter again, instead of rejecting the request as soon
+// as the limiter does. It waits following strategy (see the util/backoff package), up to maxWaits times or until
+// the context of the request is done, and then rejects the request with the last error of the limiter.
+//
+// This is mostly useful on the client side, to smooth bursts of requests instead of failing them. On the server
+// side, waiting requests keep holding their resources.
+func WithWaitBackoff(strategy backoff.Func, maxWaits uint) Option {
+	return func(o *options) {
+		o.waitBackoff = strategy
+		o.maxWaits = maxWaits
+	}
+}
+
+// limit asks the limiter whether the request is allowed, waiting and asking again if configured to.
+func (o *options) limit(ctx context.Context, limiter Limiter) error {
+	err := limiter.Limit(ctx)
+	if err == nil || o.waitBackoff == nil {
+		return err
+	}
+	seq := backoff.NewSequence(o.waitBackoff)
+	for range o.maxWaits {
+		timer := time.NewTimer(seq.Next())
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		if err = limiter.Limit(ctx); err == nil {
+			return nil
+		}
+	}
+	return err
+}
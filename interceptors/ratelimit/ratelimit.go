@@ -19,9 +19,10 @@ type Limiter interface {
 }
 
 // UnaryServerInterceptor returns a new unary server interceptors that performs request rate limiting.
-func UnaryServerInterceptor(limiter Limiter) grpc.UnaryServerInterceptor {
+func UnaryServerInterceptor(limiter Limiter, opts ...Option) grpc.UnaryServerInterceptor {
+	o := evaluateOptions(opts)
 	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
-		if err := limiter.Limit(ctx); err != nil {
+		if err := o.limit(ctx, limiter); err != nil {
 			return nil, status.Errorf(codes.ResourceExhausted, "%s is rejected by grpc_ratelimit middleware, please retry later. %s", info.FullMethod, err)
 		}
 		return handler(ctx, req)
@@ -29,9 +30,10 @@ func UnaryServerInterceptor(limiter Limiter) grpc.UnaryServerInterceptor {
 }
 
 // StreamServerInterceptor returns a new stream server interceptor that performs rate limiting on the request.
-func StreamServerInterceptor(limiter Limiter) grpc.StreamServerInterceptor {
+func StreamServerInterceptor(limiter Limiter, opts ...Option) grpc.StreamServerInterceptor {
+	o := evaluateOptions(opts)
 	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
-		if err := limiter.Limit(stream.Context()); err != nil {
+		if err := o.limit(stream.Context(), limiter); err != nil {
 			return status.Errorf(codes.ResourceExhausted, "%s is rejected by grpc_ratelimit middleware, please retry later. %s", info.FullMethod, err)
 		}
 		return handler(srv, stream)
@@ -42,11 +44,12 @@ func StreamServerInterceptor(limiter Limiter) grpc.StreamServerInterceptor {
 // client side.
 // This can be helpful for clients that want to limit the number of requests they send in a given time, potentially
 // saving cost.
-func UnaryClientInterceptor(limiter Limiter) grpc.UnaryClientInterceptor {
+func UnaryClientInterceptor(limiter Limiter, opts ...Option) grpc.UnaryClientInterceptor {
+	o := evaluateOptions(opts)
 	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
 		invoker grpc.UnaryInvoker, opts ...grpc.CallOption,
 	) error {
-		if err := limiter.Limit(ctx); err != nil {
+		if err := o.limit(ctx, limiter); err != nil {
 			return status.Errorf(codes.ResourceExhausted, "%s is rejected by grpc_ratelimit middleware, please retry later. %s", method, err)
 		}
 		return invoker(ctx, method, req, reply, cc, opts...)
@@ -57,9 +60,10 @@ func UnaryClientInterceptor(limiter Limiter) grpc.UnaryClientInterceptor {
 // client side.
 // This can be helpful for clients that want to limit the number of requests they send in a given time, potentially
 // saving cost.
-func StreamClientInterceptor(limiter Limiter) grpc.StreamClientInterceptor {
+func StreamClientInterceptor(limiter Limiter, opts ...Option) grpc.StreamClientInterceptor {
+	o := evaluateOptions(opts)
 	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
-		if err := limiter.Limit(ctx); err != nil {
+		if err := o.limit(ctx, limiter); err != nil {
 			return nil, status.Errorf(codes.ResourceExhausted, "%s is rejected by grpc_ratelimit middleware, please retry later. %s", method, err)
 		}
 		return streamer(ctx, desc, cc, method, opts...)
@@ -7,7 +7,9 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/util/backoff"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
@@ -189,3 +191,47 @@ func TestStreamClientInterceptor_RateLimitFail(t *testing.T) {
 	require.EqualError(t, err, expErr.Error())
 	assert.False(t, called)
 }
+
+type countingLimiter struct {
+	failures int
+	calls    int
+}
+
+func (l *countingLimiter) Limit(_ context.Context) error {
+	l.calls++
+	if l.calls <= l.failures {
+		return errors.New("rate limit exceeded")
+	}
+	return nil
+}
+
+func TestUnaryClientInterceptor_WaitBackoff(t *testing.T) {
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		return nil
+	}
+
+	limiter := &countingLimiter{failures: 2}
+	interceptor := UnaryClientInterceptor(limiter, WithWaitBackoff(backoff.Constant(time.Millisecond), 2))
+	require.NoError(t, interceptor(context.Background(), "FakeMethod", nil, nil, nil, invoker))
+	assert.Equal(t, 3, limiter.calls)
+
+	limiter = &countingLimiter{failures: 3}
+	interceptor = UnaryClientInterceptor(limiter, WithWaitBackoff(backoff.Constant(time.Millisecond), 2))
+	err := interceptor(context.Background(), "FakeMethod", nil, nil, nil, invoker)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, 3, limiter.calls)
+}
+
+func TestUnaryClientInterceptor_WaitBackoffContextDone(t *testing.T) {
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	limiter := &countingLimiter{failures: 2}
+	interceptor := UnaryClientInterceptor(limiter, WithWaitBackoff(backoff.Constant(time.Hour), 2))
+	err := interceptor(ctx, "FakeMethod", nil, nil, nil, invoker)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, 1, limiter.calls)
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 )
 
 type GRPCType string
@@ -51,6 +52,12 @@ type Reporter interface {
 	PostMsgReceive(replyProto any, err error, recvDuration time.Duration)
 }
 
+// HeaderTrailerReporter is an optional interface of the Reporter of client calls. If implemented, PostHeaderTrailer is
+// called with the header and trailer received from the server when the call finishes, right before PostCall.
+type HeaderTrailerReporter interface {
+	PostHeaderTrailer(header, trailer metadata.MD)
+}
+
 var _ Reporter = NoopReporter{}
 
 type NoopReporter struct{}
@@ -5,9 +5,9 @@ package retry
 
 import (
 	"context"
-	"math"
-	"math/rand"
 	"time"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/util/backoff"
 )
 
 // BackoffLinear is very simple: it waits for a fixed period of time between calls.
@@ -21,20 +21,7 @@ func BackoffLinear(waitBetween time.Duration) BackoffFunc {
 // This adds or subtracts time from the duration within a given jitter fraction.
 // For example for 10s and jitter 0.1, it will return a time within [9s, 11s])
 func jitterUp(duration time.Duration, jitter float64) time.Duration {
-	multiplier := jitter * (rand.Float64()*2 - 1)
-	return time.Duration(float64(duration) * (1 + multiplier))
-}
-
-// exponentBase2 computes 2^(a-1) where a >= 1. If a is 0, the result is 1.
-// if a is greater than 62, the result is 2^62 to avoid overflowing int64
-func exponentBase2(a uint) uint64 {
-	if a == 0 {
-		return 1
-	}
-	if a > 62 {
-		return 1 << 62
-	}
-	return (1 << a) >> 1
+	return backoff.WithJitter(backoff.Constant(duration), jitter)(1, 0)
 }
 
 // BackoffLinearWithJitter waits a set period of time, allowing for jitter (fractional adjustment).
@@ -49,40 +36,24 @@ func BackoffLinearWithJitter(waitBetween time.Duration, jitterFraction float64)
 // The scalar is multiplied times 2 raised to the current attempt. So the first
 // retry with a scalar of 100ms is 100ms, while the 5th attempt would be 1.6s.
 func BackoffExponential(scalar time.Duration) BackoffFunc {
+	exponential := backoff.Exponential(scalar)
 	return func(ctx context.Context, attempt uint) time.Duration {
-		return scalar * time.Duration(exponentBase2(attempt))
+		return exponential(attempt, 0)
 	}
 }
 
 // BackoffExponentialWithJitter creates an exponential backoff like
 // BackoffExponential does, but adds jitter.
 func BackoffExponentialWithJitter(scalar time.Duration, jitterFraction float64) BackoffFunc {
+	jittered := backoff.WithJitter(backoff.Exponential(scalar), jitterFraction)
 	return func(ctx context.Context, attempt uint) time.Duration {
-		exp := exponentBase2(attempt)
-		dur := scalar * time.Duration(exp)
-		// Check for overflow in duration multiplication
-		if exp != 0 && dur/scalar != time.Duration(exp) {
-			return time.Duration(math.MaxInt64)
-		}
-		return jitterUp(scalar*time.Duration(exponentBase2(attempt)), jitterFraction)
+		return jittered(attempt, 0)
 	}
 }
 
 func BackoffExponentialWithJitterBounded(scalar time.Duration, jitterFrac float64, maxBound time.Duration) BackoffFunc {
+	jittered := backoff.WithJitter(backoff.Exponential(scalar), jitterFrac)
 	return func(ctx context.Context, attempt uint) time.Duration {
-		exp := exponentBase2(attempt)
-		dur := scalar * time.Duration(exp)
-		// Check for overflow in duration multiplication
-		if exp != 0 && dur/scalar != time.Duration(exp) {
-			return maxBound
-		}
-		// Apply random jitter between -jitterFrac and +jitterFrac
-		jitter := 1 + jitterFrac*(rand.Float64()*2-1)
-		jitteredDuration := time.Duration(float64(dur) * jitter)
-		// Check for overflow in jitter multiplication
-		if float64(dur)*jitter > float64(math.MaxInt64) {
-			return maxBound
-		}
-		return min(jitteredDuration, maxBound)
+		return min(jittered(attempt, 0), maxBound)
 	}
 }
@@ -6,6 +6,12 @@ import (
 	"context"
 	"testing"
 	"time"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/testing/testpb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func TestBackoffExponentialWithJitter(t *testing.T) {
@@ -37,3 +43,18 @@ func TestBackoffExponentialWithJitterBounded(t *testing.T) {
 		}
 	}
 }
+
+func TestUnaryClientInterceptor_BackoffStrategy(t *testing.T) {
+	var prevs []time.Duration
+	strategy := func(attempt uint, prev time.Duration) time.Duration {
+		prevs = append(prevs, prev)
+		return time.Duration(attempt) * time.Millisecond
+	}
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		return status.Error(codes.Unavailable, "unavailable")
+	}
+	interceptor := UnaryClientInterceptor(WithMax(4), WithBackoffStrategy(strategy))
+	err := interceptor(context.Background(), "/test/Ping", testpb.GoodPing, &testpb.PingResponse{}, nil, invoker)
+	require.Equal(t, codes.Unavailable, status.Code(err))
+	require.Equal(t, []time.Duration{0, time.Millisecond, 2 * time.Millisecond}, prevs, "strategy should get the previous wait")
+}
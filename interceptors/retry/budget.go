@@ -0,0 +1,151 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package retry
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	budgetBuckets = 10
+	// minBudgetWindow is the shortest window of a RetryBudget, so that its buckets last at least 100ms.
+	minBudgetWindow = time.Second
+)
+
+// RetryBudget limits the number of retries relative to the number of calls made, to avoid retry storms when
+// a backend is struggling. It is safe for concurrent use and is meant to be shared, e.g. by all the calls of a
+// single grpc.ClientConn, through `WithRetryBudget`.
+//
+// Within the sliding window, retries are allowed as long as their number stays under
+// `ratio * calls + minRetriesPerSecond * window`, so that a low traffic client can still retry now and then.
+type RetryBudget struct {
+	ratio               float64
+	minRetriesPerSecond float64
+	window              time.Duration
+	now                 func() time.Time
+
+	mu      sync.Mutex
+	buckets [budgetBuckets]budgetBucket
+}
+
+type budgetBucket struct {
+	id      int64
+	calls   uint64
+	retries uint64
+}
+
+// RetryBudgetState is a snapshot of the retry budget usage within its window.
+type RetryBudgetState struct {
+	// Calls is the number of calls (not counting retries) made within the window.
+	Calls uint64
+	// Retries is the number of retries made within the window.
+	Retries uint64
+	// MaxRetries is the number of retries allowed within the window given the number of calls.
+	MaxRetries uint64
+}
+
+// NewRetryBudget returns a RetryBudget that allows retries to be at most `ratio` (e.g. 0.1 for 10%) of the calls
+// made within the sliding `window`, plus `minRetriesPerSecond`. A window of 0 defaults to 10 seconds, and shorter
+// windows than a second are raised to a second.
+func NewRetryBudget(ratio float64, minRetriesPerSecond float64, window time.Duration) *RetryBudget {
+	if window <= 0 {
+		window = 10 * time.Second
+	}
+	window = max(window, minBudgetWindow)
+	return &RetryBudget{
+		ratio:               ratio,
+		minRetriesPerSecond: minRetriesPerSecond,
+		window:              window,
+		now:                 time.Now,
+	}
+}
+
+// State returns the current usage of the budget.
+func (b *RetryBudget) State() RetryBudgetState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state(b.bucketID())
+}
+
+func (b *RetryBudget) bucketID() int64 {
+	return b.now().UnixNano() / int64(b.window/budgetBuckets)
+}
+
+// bucket returns the bucket for the given id, resetting it if it was last used in a past window.
+func (b *RetryBudget) bucket(id int64) *budgetBucket {
+	bucket := &b.buckets[id%budgetBuckets]
+	if bucket.id != id {
+		*bucket = budgetBucket{id: id}
+	}
+	return bucket
+}
+
+func (b *RetryBudget) state(id int64) RetryBudgetState {
+	var s RetryBudgetState
+	for i := range b.buckets {
+		// Skip buckets that are older than the window.
+		if id-b.buckets[i].id >= budgetBuckets {
+			continue
+		}
+		s.Calls += b.buckets[i].calls
+		s.Retries += b.buckets[i].retries
+	}
+	s.MaxRetries = uint64(b.ratio*float64(s.Calls) + b.minRetriesPerSecond*b.window.Seconds())
+	return s
+}
+
+// recordCall deposits a new call in the budget.
+func (b *RetryBudget) recordCall() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.bucket(b.bucketID()).calls++
+}
+
+// tryRetry withdraws a retry from the budget, returning false if the budget is exhausted.
+func (b *RetryBudget) tryRetry() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	id := b.bucketID()
+	if s := b.state(id); s.Retries >= s.MaxRetries {
+		return false
+	}
+	b.bucket(id).retries++
+	return true
+}
+
+// RetryBudgetExhaustedError is passed to the OnRetryCallback when a retry was not made, because the retry
+// budget was exhausted. It wraps the error of the last attempt, which is returned to the caller as is.
+type RetryBudgetExhaustedError struct {
+	Err   error
+	State RetryBudgetState
+}
+
+func (e *RetryBudgetExhaustedError) Error() string {
+	return fmt.Sprintf("grpc_retry: retry budget exhausted (%d/%d retries for %d calls): %v", e.State.Retries, e.State.MaxRetries, e.State.Calls, e.Err)
+}
+
+func (e *RetryBudgetExhaustedError) Unwrap() error {
+	return e.Err
+}
+
+// allowRetry withdraws the given retry attempt from the retry budget, if any. When the budget is exhausted,
+// it reports it through the OnRetryCallback and returns false.
+func allowRetry(ctx context.Context, attempt uint, lastErr error, callOpts *options) bool {
+	if callOpts.retryBudget.tryRetry() {
+		return true
+	}
+	exhaustedErr := &RetryBudgetExhaustedError{Err: lastErr, State: callOpts.retryBudget.State()}
+	logTrace(ctx, "grpc_retry attempt: %d, %v", attempt, exhaustedErr)
+	callOpts.onRetryCallback(ctx, attempt, exhaustedErr)
+	return false
+}
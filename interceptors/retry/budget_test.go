@@ -0,0 +1,125 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/testing/testpb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	return c.t
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.t = c.t.Add(d)
+}
+
+func TestRetryBudget(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1000, 0)}
+	b := NewRetryBudget(0.2, 0, 10*time.Second)
+	b.now = clock.now
+
+	require.False(t, b.tryRetry(), "no calls, no retries")
+
+	for i := 0; i < 10; i++ {
+		b.recordCall()
+	}
+	require.True(t, b.tryRetry())
+	require.True(t, b.tryRetry())
+	require.False(t, b.tryRetry(), "20% of 10 calls is 2 retries")
+	require.Equal(t, RetryBudgetState{Calls: 10, Retries: 2, MaxRetries: 2}, b.State())
+
+	clock.advance(5 * time.Second)
+	for i := 0; i < 5; i++ {
+		b.recordCall()
+	}
+	require.True(t, b.tryRetry())
+	require.Equal(t, RetryBudgetState{Calls: 15, Retries: 3, MaxRetries: 3}, b.State())
+
+	// The first calls and retries are now outside the window.
+	clock.advance(6 * time.Second)
+	require.Equal(t, RetryBudgetState{Calls: 5, Retries: 1, MaxRetries: 1}, b.State())
+	require.False(t, b.tryRetry())
+
+	clock.advance(time.Minute)
+	require.Equal(t, RetryBudgetState{}, b.State())
+}
+
+func TestRetryBudget_MinRetriesPerSecond(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1000, 0)}
+	b := NewRetryBudget(0, 0.5, 10*time.Second)
+	b.now = clock.now
+
+	for i := 0; i < 5; i++ {
+		require.True(t, b.tryRetry())
+	}
+	require.False(t, b.tryRetry())
+}
+
+func TestRetryBudget_ShortWindow(t *testing.T) {
+	for _, window := range []time.Duration{-time.Second, 0, time.Nanosecond, 5 * time.Nanosecond, time.Millisecond} {
+		b := NewRetryBudget(0.1, 1, window)
+		require.GreaterOrEqual(t, b.window, minBudgetWindow)
+		require.NotPanics(t, func() {
+			b.recordCall()
+			b.tryRetry()
+			_ = b.State()
+		}, "window %v", window)
+	}
+}
+
+func TestUnaryClientInterceptor_RetryBudgetExhausted(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1000, 0)}
+	budget := NewRetryBudget(0.5, 0, time.Minute)
+	budget.now = clock.now
+
+	calls := 0
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		calls++
+		return status.Error(codes.Unavailable, "unavailable")
+	}
+	var callbackErrs []error
+	interceptor := UnaryClientInterceptor(
+		WithMax(5),
+		WithBackoff(BackoffLinear(0)),
+		WithRetryBudget(budget),
+		WithOnRetryCallback(func(ctx context.Context, attempt uint, err error) {
+			callbackErrs = append(callbackErrs, err)
+		}),
+	)
+
+	// First call: 0.5 retries allowed, none made.
+	err := interceptor(context.Background(), "/test/Ping", testpb.GoodPing, &testpb.PingResponse{}, nil, invoker)
+	require.Equal(t, codes.Unavailable, status.Code(err))
+	require.Equal(t, 1, calls)
+	require.Len(t, callbackErrs, 1)
+
+	var exhaustedErr *RetryBudgetExhaustedError
+	require.True(t, errors.As(callbackErrs[0], &exhaustedErr))
+	require.Equal(t, RetryBudgetState{Calls: 1, Retries: 0, MaxRetries: 0}, exhaustedErr.State)
+	require.Equal(t, codes.Unavailable, status.Code(exhaustedErr), "exhausted error should wrap the last error")
+
+	// Second call: one retry allowed.
+	calls, callbackErrs = 0, nil
+	err = interceptor(context.Background(), "/test/Ping", testpb.GoodPing, &testpb.PingResponse{}, nil, invoker)
+	require.Equal(t, codes.Unavailable, status.Code(err))
+	require.Equal(t, 2, calls)
+	require.Len(t, callbackErrs, 2)
+	require.False(t, errors.As(callbackErrs[0], &exhaustedErr), "first callback is a regular retry")
+	require.True(t, errors.As(callbackErrs[1], &exhaustedErr))
+	require.Equal(t, RetryBudgetState{Calls: 2, Retries: 1, MaxRetries: 1}, exhaustedErr.State)
+}
@@ -0,0 +1,87 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package retry
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// errRetryDeadlineExceeded is returned by waitRetryBackoff when the next attempt would start after the
+// retry deadline.
+var errRetryDeadlineExceeded = errors.New("grpc_retry: retry deadline exceeded")
+
+// WithRetryDeadline sets the time after which no more retries are made, counted from the start of the call.
+//
+// Unlike the deadline of the call context, it does not cancel the attempt in flight, but once it is exceeded
+// (or the backoff before the next attempt would exceed it), the error of the last attempt is returned. A value
+// of 0 disables it.
+func WithRetryDeadline(timeout time.Duration) CallOption {
+	return CallOption{applyFunc: func(o *options) {
+		o.retryDeadline = timeout
+	}}
+}
+
+// WithSplitDeadline splits the time left before the deadline of the call context evenly across the attempts
+// left, so that every attempt of a unary call gets a fair share of it. For example, with 3 attempts and 9s left,
+// the first attempt gets 3s, and if it fails after 3s, the second one gets (6s - backoff) / 2.
+//
+// An attempt never gets less than minPerAttempt (unless less is left before the deadline), and retries are
+// skipped once less than minPerAttempt is left, as they would not have a chance to succeed. If
+// `WithPerRetryTimeout` is also set, the shorter of the two timeouts applies. The first attempt is always made.
+//
+// Like with `WithPerRetryTimeout`, the DeadlineExceeded errors of the attempts are retried. It does not apply to
+// streams and hedged calls. A negative value disables it.
+func WithSplitDeadline(minPerAttempt time.Duration) CallOption {
+	return CallOption{applyFunc: func(o *options) {
+		o.splitDeadline = minPerAttempt >= 0
+		o.minAttemptTimeout = max(minPerAttempt, 0)
+	}}
+}
+
+// backoffState holds what the wait before the next attempt of a call depends on, besides the attempt and the
+// last error.
+type backoffState struct {
+	pushback serverPushback
+	// prevWait is the wait before the previous attempt.
+	prevWait time.Duration
+	// deadline is the retry deadline of the call, see WithRetryDeadline. It is zero if there is none.
+	deadline time.Time
+}
+
+func newBackoffState(callOpts *options) backoffState {
+	var s backoffState
+	if callOpts.retryDeadline > 0 {
+		s.deadline = time.Now().Add(callOpts.retryDeadline)
+	}
+	return s
+}
+
+// exceeds returns true if an attempt made after the given wait would start past the retry deadline.
+func (s *backoffState) exceeds(wait time.Duration) bool {
+	return !s.deadline.IsZero() && time.Now().Add(wait).After(s.deadline)
+}
+
+// attemptTimeout returns the timeout of the given attempt of a unary call, or false if there is not enough
+// time left for it, see WithSplitDeadline.
+func attemptTimeout(parentCtx context.Context, callOpts *options, attempt uint) (time.Duration, bool) {
+	timeout := callOpts.perCallTimeout
+	if !callOpts.splitDeadline {
+		return timeout, true
+	}
+	deadline, ok := parentCtx.Deadline()
+	if !ok {
+		return timeout, true
+	}
+	remaining := time.Until(deadline)
+	if attempt > 0 && remaining < callOpts.minAttemptTimeout {
+		return 0, false
+	}
+	share := max(remaining/time.Duration(callOpts.max-attempt), callOpts.minAttemptTimeout)
+	if timeout == 0 || share < timeout {
+		timeout = share
+	}
+	return timeout, true
+}
@@ -0,0 +1,88 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package retry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/testing/testpb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryClientInterceptor_SplitDeadline(t *testing.T) {
+	var timeouts []time.Duration
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		deadline, ok := ctx.Deadline()
+		require.True(t, ok)
+		timeouts = append(timeouts, time.Until(deadline))
+		<-ctx.Done()
+		return status.FromContextError(ctx.Err()).Err()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 900*time.Millisecond)
+	defer cancel()
+
+	interceptor := UnaryClientInterceptor(WithMax(3), WithBackoff(BackoffLinear(0)), WithSplitDeadline(0))
+	err := interceptor(ctx, "/test/Ping", testpb.GoodPing, &testpb.PingResponse{}, nil, invoker)
+	require.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	require.Len(t, timeouts, 3, "attempts timing out should be retried")
+	for _, timeout := range timeouts {
+		require.InDelta(t, 300*time.Millisecond, timeout, float64(100*time.Millisecond), "every attempt should get a third of the time")
+	}
+}
+
+func TestUnaryClientInterceptor_SplitDeadlineSkipsShortAttempts(t *testing.T) {
+	calls := 0
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		calls++
+		deadline, _ := ctx.Deadline()
+		require.Greater(t, time.Until(deadline), 150*time.Millisecond, "first attempt should get the minimum time")
+		time.Sleep(100 * time.Millisecond)
+		return status.Error(codes.Unavailable, "unavailable")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	recorder := &eventRecorder{}
+	interceptor := UnaryClientInterceptor(WithMax(5), WithBackoff(BackoffLinear(0)), WithSplitDeadline(250*time.Millisecond), WithEventHandler(recorder.handle))
+	err := interceptor(ctx, "/test/Ping", testpb.GoodPing, &testpb.PingResponse{}, nil, invoker)
+	require.Equal(t, codes.Unavailable, status.Code(err), "the error of the last attempt should be returned")
+	require.Equal(t, 1, calls, "there is not enough time left for a retry")
+	require.Equal(t, recordedEvent{Kind: EventGiveUp, Attempt: 0, Code: codes.Unavailable, Reason: GiveUpDeadline}, recorder.events[len(recorder.events)-1])
+}
+
+func TestUnaryClientInterceptor_RetryDeadline(t *testing.T) {
+	calls := 0
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		calls++
+		_, ok := ctx.Deadline()
+		require.False(t, ok, "the retry deadline should not apply to the attempts")
+		return status.Error(codes.Unavailable, "unavailable")
+	}
+	recorder := &eventRecorder{}
+	interceptor := UnaryClientInterceptor(WithMax(10), WithBackoff(BackoffLinear(60*time.Millisecond)), WithRetryDeadline(100*time.Millisecond), WithEventHandler(recorder.handle))
+	start := time.Now()
+	err := interceptor(context.Background(), "/test/Ping", testpb.GoodPing, &testpb.PingResponse{}, nil, invoker)
+	require.Equal(t, codes.Unavailable, status.Code(err))
+	require.Equal(t, 2, calls, "the third attempt would start after the retry deadline")
+	require.Less(t, time.Since(start), 100*time.Millisecond, "should not wait for a backoff past the deadline")
+	require.Equal(t, recordedEvent{Kind: EventGiveUp, Attempt: 1, Code: codes.Unavailable, Reason: GiveUpDeadline}, recorder.events[len(recorder.events)-1])
+}
+
+func TestUnaryClientInterceptor_HedgingRetryDeadline(t *testing.T) {
+	calls := 0
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		calls++
+		time.Sleep(50 * time.Millisecond)
+		return status.Error(codes.Unavailable, "unavailable")
+	}
+	interceptor := UnaryClientInterceptor(WithMax(10), WithHedgingDelay(time.Hour), WithRetryDeadline(75*time.Millisecond))
+	err := interceptor(context.Background(), "/test/Ping", testpb.GoodPing, &testpb.PingResponse{}, nil, invoker)
+	require.Equal(t, codes.Unavailable, status.Code(err))
+	require.Equal(t, 2, calls)
+}
@@ -7,7 +7,12 @@ Package retry provides client-side request retry logic for gRPC.
 # Client-Side Request Retry Interceptor
 
 It allows for automatic retry, inside the generated gRPC code of requests based on the gRPC status
-of the reply. It supports unary (1:1), and server stream (1:n) requests.
+of the reply. It supports unary (1:1), server stream (1:n), client stream (n:1) and bidi stream (n:m) requests.
+Streams are retried by replaying the messages sent so far on a new stream, which is bounded by
+`WithMaxReplayMessages` and `WithMaxReplayBytes` (`DefaultMaxReplayBytes` by default for client and bidi streams,
+server streams replaying only their request have no default limit). Client and bidi streams are only retried until the
+first response is received. Server streams can resume from the last message received instead of
+starting over with `WithResume`, and drop the messages they already received with `WithDeduplication`.
 
 By default the interceptors *are disabled*, preventing accidental use of retries. You can easily
 override the number of retries (setting them to more than 0) with a `grpc.ClientOption`, e.g.:
@@ -15,7 +20,37 @@ override the number of retries (setting them to more than 0) with a `grpc.Client
 	myclient.Ping(ctx, goodPing, grpc_retry.WithMax(5))
 
 Other default options are: retry on `ResourceExhausted` and `Unavailable` gRPC codes, use a 50ms
-linear backoff with 10% jitter.
+linear backoff with 10% jitter. Other backoffs can be set with `WithBackoff`, or `WithBackoffStrategy` for
+the strategies of the util/backoff package, such as full jitter and decorrelated jitter.
+
+Instead of a fixed `WithPerRetryTimeout`, `WithSplitDeadline` gives every attempt of a unary call an even
+share of the time left before the deadline of the call, skipping the retries that would not have enough time
+to succeed. `WithRetryDeadline` sets a time limit for the retries, independent of the call context.
+
+Servers can control the wait before the next attempt, which then takes precedence over the backoff, by
+returning the `grpc-retry-pushback-ms` trailer or a google.rpc.RetryInfo error detail. A negative or invalid
+pushback trailer stops the retries. Use `WithServerPushback(false)` to ignore it.
+
+Unary calls to idempotent methods can also be hedged with `WithHedgingDelay`: instead of waiting for a
+failure, another copy of the call is sent if no response arrived within the delay. The first successful
+response wins and the remaining attempts are cancelled. Servers can recognize retried and hedged copies
+with `AttemptFromIncomingContext`.
+
+Retrying calls that are not idempotent is only safe if the server can tell a retry from a new call.
+`WithIdempotencyKey` sends the same random key in the `x-idempotency-key` header with every attempt of a unary
+call, which the interceptors/idempotency server interceptor uses to run the call only once.
+
+To avoid multiplying the load on a struggling backend, share a `RetryBudget` between calls with
+`WithRetryBudget`. Once retries exceed the configured share of recent calls, the last error is returned
+without retrying.
+
+Retry and hedging policies can also be written in the gRPC service config format (methodConfig with
+retryPolicy or hedgingPolicy) and loaded with `ParseServiceConfig` or `LoadServiceConfigFile`. With
+`WithServiceConfig`, the policy matching the method of each call is applied on top of the options of the
+interceptor, while the options of the call still take precedence.
+
+Every attempt and the reason for giving up on a call can be observed with `WithEventHandler`, e.g. to log
+them with `LoggingEventHandler` or to export them as metrics with the RetryMetrics of providers/prometheus.
 
 For chained interceptors, the retry interceptor will call every interceptor that follows it
 whenever when a retry happens.
@@ -0,0 +1,175 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// EventKind is the kind of an `Event`.
+type EventKind int
+
+const (
+	// EventAttemptStart is emitted before every attempt, including the first one.
+	EventAttemptStart EventKind = iota
+	// EventAttemptEnd is emitted when an attempt finishes, successfully or not. For streams, that is when the
+	// stream fails to be established or its RecvMsg returns an error (io.EOF being a success).
+	EventAttemptEnd
+	// EventGiveUp is emitted when a call fails and no more attempts are made, with the reason why.
+	EventGiveUp
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventAttemptStart:
+		return "attempt_start"
+	case EventAttemptEnd:
+		return "attempt_end"
+	case EventGiveUp:
+		return "give_up"
+	default:
+		return "unknown"
+	}
+}
+
+// GiveUpReason tells why the interceptor stopped retrying a call, see `EventGiveUp`.
+type GiveUpReason string
+
+const (
+	// GiveUpNonRetriable means the last error is not retriable, see `WithCodes` and `WithRetriable`.
+	GiveUpNonRetriable GiveUpReason = "non_retriable"
+	// GiveUpMaxAttempts means all the attempts allowed by `WithMax` failed.
+	GiveUpMaxAttempts GiveUpReason = "max_attempts"
+	// GiveUpBudgetExhausted means the retry budget did not allow another attempt, see `WithRetryBudget`.
+	GiveUpBudgetExhausted GiveUpReason = "budget_exhausted"
+	// GiveUpPushback means the server asked not to retry, see `WithServerPushback`.
+	GiveUpPushback GiveUpReason = "server_pushback"
+	// GiveUpDeadline means there was no time left for another attempt, see `WithRetryDeadline` and
+	// `WithSplitDeadline`.
+	GiveUpDeadline GiveUpReason = "deadline"
+	// GiveUpContext means the context of the call was cancelled or its deadline exceeded.
+	GiveUpContext GiveUpReason = "context"
+	// GiveUpNotReplayable means the stream could not be replayed anymore, because its replay buffer overflowed or
+	// a response was already received, see `WithMaxReplayMessages` and `WithMaxReplayBytes`.
+	GiveUpNotReplayable GiveUpReason = "not_replayable"
+)
+
+// Event describes a step of a call made through the retry interceptor, see `WithEventHandler`.
+type Event struct {
+	Kind EventKind
+	// FullMethod is the full gRPC method name of the call, e.g. "/package.Service/Method".
+	FullMethod string
+	// Attempt is the attempt number, starting from 0. For EventGiveUp, it is the last attempt made.
+	Attempt uint
+	// Code and Err are the result of the attempt for EventAttemptEnd, or of the last attempt for EventGiveUp. For
+	// the EventAttemptStart of a retry, they are the result of the attempt that triggered it, which is OK for the
+	// hedged attempts sent because the previous ones did not respond within the hedging delay.
+	Code codes.Code
+	Err  error
+	// Duration is the duration of the attempt, for EventAttemptEnd.
+	Duration time.Duration
+	// Reason is set for EventGiveUp.
+	Reason GiveUpReason
+}
+
+// EventHandler is the type of function called with the events of the calls, see `WithEventHandler`.
+type EventHandler func(ctx context.Context, event Event)
+
+// WithEventHandler adds a handler of the events of every call: the start and end of every attempt, and giving
+// up on a call. This is meant for logging and metrics, e.g. the retry metrics of providers/prometheus.
+//
+// Unlike other options, this one adds to the handlers already set instead of replacing them. Handlers are called
+// synchronously, and concurrently for hedged calls, so they should be fast and safe for concurrent use.
+func WithEventHandler(handler EventHandler) CallOption {
+	return CallOption{applyFunc: func(o *options) {
+		// Make sure we never modify the slice of the options we were copied from.
+		o.eventHandlers = append(o.eventHandlers[:len(o.eventHandlers):len(o.eventHandlers)], handler)
+	}}
+}
+
+// LoggingEventHandler returns an EventHandler logging the events with the given logger, to be used with
+// `WithEventHandler`. Attempts are logged at debug level and giving up on a call at warning level, with the
+// "grpc.service", "grpc.method", "grpc.code" and "grpc.retry.*" fields.
+func LoggingEventHandler(logger logging.Logger) EventHandler {
+	return func(ctx context.Context, e Event) {
+		c := interceptors.NewClientCallMeta(e.FullMethod, nil, nil)
+		fields := logging.Fields{
+			logging.ServiceFieldKey, c.Service,
+			logging.MethodFieldKey, c.Method,
+			"grpc.retry.attempt", strconv.FormatUint(uint64(e.Attempt), 10),
+			"grpc.code", e.Code.String(),
+		}
+		if e.Err != nil {
+			fields = append(fields, "grpc.error", e.Err.Error())
+		}
+		switch e.Kind {
+		case EventAttemptStart:
+			logger.Log(ctx, logging.LevelDebug, "retry attempt started", fields...)
+		case EventAttemptEnd:
+			fields = append(fields, logging.DefaultDurationToFields(e.Duration)...)
+			logger.Log(ctx, logging.LevelDebug, "retry attempt finished", fields...)
+		case EventGiveUp:
+			fields = append(fields, "grpc.retry.reason", string(e.Reason))
+			logger.Log(ctx, logging.LevelWarn, "gave up retrying call", fields...)
+		}
+	}
+}
+
+func (o *options) emit(ctx context.Context, event Event) {
+	for _, h := range o.eventHandlers {
+		h(ctx, event)
+	}
+}
+
+// attemptStarted emits EventAttemptStart and returns the start time of the attempt. cause is the error of the
+// attempt that triggered it, if any.
+func (o *options) attemptStarted(ctx context.Context, method string, attempt uint, cause error) time.Time {
+	if len(o.eventHandlers) == 0 {
+		return time.Time{}
+	}
+	o.emit(ctx, Event{Kind: EventAttemptStart, FullMethod: method, Attempt: attempt, Code: status.Code(cause), Err: cause})
+	return time.Now()
+}
+
+// attemptEnded emits EventAttemptEnd, treating io.EOF as a success.
+func (o *options) attemptEnded(ctx context.Context, method string, attempt uint, start time.Time, err error) {
+	if len(o.eventHandlers) == 0 {
+		return
+	}
+	if errors.Is(err, io.EOF) {
+		err = nil
+	}
+	o.emit(ctx, Event{
+		Kind:       EventAttemptEnd,
+		FullMethod: method,
+		Attempt:    attempt,
+		Code:       status.Code(err),
+		Err:        err,
+		Duration:   time.Since(start),
+	})
+}
+
+// gaveUp emits EventGiveUp.
+func (o *options) gaveUp(ctx context.Context, method string, attempt uint, reason GiveUpReason, err error) {
+	if len(o.eventHandlers) == 0 {
+		return
+	}
+	o.emit(ctx, Event{
+		Kind:       EventGiveUp,
+		FullMethod: method,
+		Attempt:    attempt,
+		Code:       status.Code(err),
+		Err:        err,
+		Reason:     reason,
+	})
+}
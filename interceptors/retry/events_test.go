@@ -0,0 +1,165 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package retry
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/testing/testpb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type recordedEvent struct {
+	Kind    EventKind
+	Attempt uint
+	Code    codes.Code
+	Reason  GiveUpReason
+}
+
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []recordedEvent
+}
+
+func (r *eventRecorder) handle(ctx context.Context, e Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, recordedEvent{Kind: e.Kind, Attempt: e.Attempt, Code: e.Code, Reason: e.Reason})
+}
+
+func TestUnaryClientInterceptor_Events(t *testing.T) {
+	for _, tcase := range []struct {
+		name     string
+		errs     []codes.Code
+		expected []recordedEvent
+	}{
+		{
+			name: "succeeds after retry",
+			errs: []codes.Code{codes.Unavailable, codes.OK},
+			expected: []recordedEvent{
+				{Kind: EventAttemptStart, Attempt: 0},
+				{Kind: EventAttemptEnd, Attempt: 0, Code: codes.Unavailable},
+				{Kind: EventAttemptStart, Attempt: 1, Code: codes.Unavailable},
+				{Kind: EventAttemptEnd, Attempt: 1, Code: codes.OK},
+			},
+		},
+		{
+			name: "non retriable",
+			errs: []codes.Code{codes.Unavailable, codes.NotFound},
+			expected: []recordedEvent{
+				{Kind: EventAttemptStart, Attempt: 0},
+				{Kind: EventAttemptEnd, Attempt: 0, Code: codes.Unavailable},
+				{Kind: EventAttemptStart, Attempt: 1, Code: codes.Unavailable},
+				{Kind: EventAttemptEnd, Attempt: 1, Code: codes.NotFound},
+				{Kind: EventGiveUp, Attempt: 1, Code: codes.NotFound, Reason: GiveUpNonRetriable},
+			},
+		},
+		{
+			name: "max attempts",
+			errs: []codes.Code{codes.Unavailable, codes.Unavailable},
+			expected: []recordedEvent{
+				{Kind: EventAttemptStart, Attempt: 0},
+				{Kind: EventAttemptEnd, Attempt: 0, Code: codes.Unavailable},
+				{Kind: EventAttemptStart, Attempt: 1, Code: codes.Unavailable},
+				{Kind: EventAttemptEnd, Attempt: 1, Code: codes.Unavailable},
+				{Kind: EventGiveUp, Attempt: 1, Code: codes.Unavailable, Reason: GiveUpMaxAttempts},
+			},
+		},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			calls := 0
+			invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+				code := tcase.errs[calls]
+				calls++
+				if code == codes.OK {
+					return nil
+				}
+				return status.Error(code, "failing")
+			}
+			first, second := &eventRecorder{}, &eventRecorder{}
+			interceptor := UnaryClientInterceptor(WithMax(2), WithBackoff(BackoffLinear(0)), WithEventHandler(first.handle))
+			_ = interceptor(context.Background(), "/test/Ping", testpb.GoodPing, &testpb.PingResponse{}, nil, invoker, WithEventHandler(second.handle))
+			require.Equal(t, tcase.expected, first.events)
+			require.Equal(t, tcase.expected, second.events, "call handlers should be added to the interceptor ones")
+		})
+	}
+}
+
+func TestStreamClientInterceptor_Events(t *testing.T) {
+	scripts := []*scriptedClientStream{
+		{err: status.Error(codes.Unavailable, "failover")},
+		{err: io.EOF},
+	}
+	streams := 0
+	streamer := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		stream := scripts[streams]
+		streams++
+		return stream, nil
+	}
+	recorder := &eventRecorder{}
+	interceptor := StreamClientInterceptor(WithMax(3), WithBackoff(BackoffLinear(0)), WithEventHandler(recorder.handle))
+	stream, err := interceptor(context.Background(), &grpc.StreamDesc{ServerStreams: true}, nil, "/test/PingList", streamer)
+	require.NoError(t, err)
+	require.NoError(t, stream.SendMsg(testpb.GoodPingList))
+	require.NoError(t, stream.CloseSend())
+	require.ErrorIs(t, stream.RecvMsg(&testpb.PingListResponse{}), io.EOF)
+	require.ErrorIs(t, stream.RecvMsg(&testpb.PingListResponse{}), io.EOF)
+
+	require.Equal(t, []recordedEvent{
+		{Kind: EventAttemptStart, Attempt: 0},
+		{Kind: EventAttemptEnd, Attempt: 0, Code: codes.Unavailable},
+		{Kind: EventAttemptStart, Attempt: 1, Code: codes.Unavailable},
+		{Kind: EventAttemptEnd, Attempt: 1, Code: codes.OK},
+	}, recorder.events)
+}
+
+func TestLoggingEventHandler(t *testing.T) {
+	type logLine struct {
+		level  logging.Level
+		msg    string
+		fields logging.Fields
+	}
+	var lines []logLine
+	logger := logging.LoggerFunc(func(_ context.Context, level logging.Level, msg string, fields ...any) {
+		lines = append(lines, logLine{level: level, msg: msg, fields: fields})
+	})
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		return status.Error(codes.Unavailable, "failing")
+	}
+	interceptor := UnaryClientInterceptor(WithMax(2), WithBackoff(BackoffLinear(0)), WithEventHandler(LoggingEventHandler(logger)))
+	_ = interceptor(context.Background(), "/test.Service/Ping", testpb.GoodPing, &testpb.PingResponse{}, nil, invoker)
+
+	require.Len(t, lines, 5)
+	for _, l := range lines[:4] {
+		require.Equal(t, logging.LevelDebug, l.level)
+	}
+	require.Equal(t, "retry attempt started", lines[2].msg)
+	require.Equal(t, logging.Fields{
+		logging.ServiceFieldKey, "test.Service",
+		logging.MethodFieldKey, "Ping",
+		"grpc.retry.attempt", "1",
+		"grpc.code", "Unavailable",
+		"grpc.error", "rpc error: code = Unavailable desc = failing",
+	}, lines[2].fields)
+	require.Equal(t, "retry attempt finished", lines[3].msg)
+	require.Contains(t, lines[3].fields, "grpc.time_ms")
+
+	require.Equal(t, logging.LevelWarn, lines[4].level)
+	require.Equal(t, "gave up retrying call", lines[4].msg)
+	require.Equal(t, logging.Fields{
+		logging.ServiceFieldKey, "test.Service",
+		logging.MethodFieldKey, "Ping",
+		"grpc.retry.attempt", "1",
+		"grpc.code", "Unavailable",
+		"grpc.error", "rpc error: code = Unavailable desc = failing",
+		"grpc.retry.reason", "max_attempts",
+	}, lines[4].fields)
+}
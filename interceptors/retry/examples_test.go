@@ -7,9 +7,13 @@ import (
 	"context"
 	"errors"
 	"io"
+	"log"
+	"strconv"
 	"time"
 
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/testing/testpb"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/util/backoff"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 )
@@ -92,3 +96,54 @@ func ExampleWithPerRetryTimeout() {
 func scaleDuration(d time.Duration, factor float64) time.Duration {
 	return time.Duration(float64(d) * factor)
 }
+
+// Example of retry policies loaded from a gRPC service config file, which take precedence over the options
+// of the interceptor for the methods they apply to.
+func ExampleWithServiceConfig() {
+	cfg, err := LoadServiceConfigFile("service_config.json")
+	if err != nil {
+		return
+	}
+	opts := []CallOption{
+		WithMax(3),
+		WithServiceConfig(cfg),
+	}
+	_, _ = grpc.NewClient("myservice.example.com",
+		grpc.WithStreamInterceptor(StreamClientInterceptor(opts...)),
+		grpc.WithUnaryInterceptor(UnaryClientInterceptor(opts...)),
+	)
+}
+
+// Example of logging the attempts and the failures of the calls through a logging.Logger.
+func ExampleWithEventHandler() {
+	logger := logging.LoggerFunc(func(ctx context.Context, lvl logging.Level, msg string, fields ...any) {
+		log.Println(append([]any{msg}, fields...)...)
+	})
+	logEvents := func(ctx context.Context, e Event) {
+		fields := []any{"grpc.method", e.FullMethod, "grpc.retry.attempt", strconv.FormatUint(uint64(e.Attempt), 10)}
+		switch e.Kind {
+		case EventAttemptEnd:
+			if e.Code != codes.OK {
+				fields = append(fields, "grpc.code", e.Code.String(), "grpc.time_ms", strconv.FormatInt(e.Duration.Milliseconds(), 10))
+				logger.Log(ctx, logging.LevelDebug, "attempt failed", fields...)
+			}
+		case EventGiveUp:
+			fields = append(fields, "grpc.code", e.Code.String(), "grpc.retry.reason", string(e.Reason))
+			logger.Log(ctx, logging.LevelWarn, "giving up on call", fields...)
+		}
+	}
+	_, _ = grpc.NewClient("myservice.example.com",
+		grpc.WithUnaryInterceptor(UnaryClientInterceptor(WithMax(3), WithEventHandler(logEvents))),
+	)
+}
+
+// Example with the "full jitter" exponential backoff, starting with 100ms and capped at 5s.
+func ExampleWithBackoffStrategy() {
+	opts := []CallOption{
+		WithBackoffStrategy(backoff.Clamp(backoff.FullJitter(backoff.Exponential(100*time.Millisecond)), 0, 5*time.Second)),
+	}
+	_, _ = grpc.NewClient("myservice.example.com",
+		grpc.WithStreamInterceptor(StreamClientInterceptor(opts...)),
+		grpc.WithUnaryInterceptor(UnaryClientInterceptor(opts...)),
+	)
+}
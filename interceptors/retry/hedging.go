@@ -0,0 +1,167 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package retry
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	grpcMetadata "google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+type hedgeResult struct {
+	attempt uint
+	reply   proto.Message
+	trailer grpcMetadata.MD
+	err     error
+}
+
+// hedgedInvoke sends up to callOpts.max copies of the same unary call, starting a new one every
+// callOpts.hedgingDelay (or immediately after a retriable failure, even with other attempts in flight) until one
+// of them succeeds.
+//
+// Each attempt receives into its own copy of reply, so that the losers cannot race with the winner. Once
+// an attempt finishes successfully, or with an error that should not be retried, all the other attempts
+// are cancelled.
+//
+// A server pushback on a failed attempt delays the next hedged attempt by the given time instead, or stops
+// any further attempts from being made.
+func hedgedInvoke(parentCtx context.Context, method string, req any, reply proto.Message, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, grpcOpts []grpc.CallOption, callOpts *options) error {
+	hedgeCtx, cancel := context.WithCancel(parentCtx)
+	// Cancels all in-flight attempts once we have a winner (or give up).
+	defer cancel()
+
+	results := make(chan hedgeResult, callOpts.max)
+	launched, pending := uint(0), 0
+	// launch sends a new attempt, cause being the error of the attempt that triggered it, if any.
+	launch := func(cause error) {
+		attempt := launched
+		launched++
+		pending++
+		attemptReply := reply.ProtoReflect().New().Interface()
+		go func() {
+			callCtx, callCancel := perCallContext(hedgeCtx, callOpts.perCallTimeout, callOpts, attempt)
+			defer callCancel()
+			var trailer grpcMetadata.MD
+			start := callOpts.attemptStarted(parentCtx, method, attempt, cause)
+			err := invoker(callCtx, method, req, attemptReply, cc, withTrailer(grpcOpts, &trailer, callOpts)...)
+			callOpts.attemptEnded(parentCtx, method, attempt, start, err)
+			results <- hedgeResult{attempt: attempt, reply: attemptReply, trailer: trailer, err: err}
+		}()
+	}
+
+	timer := time.NewTimer(callOpts.hedgingDelay)
+	defer timer.Stop()
+
+	var lastErr error
+	var lastAttempt uint
+	// giveUpReason is the reason to report if all the attempts fail.
+	giveUpReason := GiveUpMaxAttempts
+	bo := newBackoffState(callOpts)
+	// pushedBack is true while the next attempt is delayed by the server pushback.
+	pushedBack := false
+	launch(nil)
+	for pending > 0 || (pushedBack && launched < callOpts.max) {
+		var hedgeC <-chan time.Time
+		if launched < callOpts.max {
+			hedgeC = timer.C
+		}
+		select {
+		case <-parentCtx.Done():
+			logTrace(parentCtx, "grpc_retry hedging, parent context error: %v", parentCtx.Err())
+			err := contextErrToGrpcErr(parentCtx.Err())
+			callOpts.gaveUp(parentCtx, method, launched-1, GiveUpContext, err)
+			return err
+		case <-hedgeC:
+			if bo.exceeds(0) {
+				logTrace(parentCtx, "grpc_retry hedging attempt: %d, retry deadline exceeded", launched)
+				launched = callOpts.max
+				giveUpReason = GiveUpDeadline
+				continue
+			}
+			if !allowRetry(parentCtx, launched, lastErr, callOpts) {
+				// Keep waiting for the attempts in flight, but do not send any more.
+				launched = callOpts.max
+				giveUpReason = GiveUpBudgetExhausted
+				continue
+			}
+			logTrace(parentCtx, "grpc_retry hedging attempt: %d, no response after %v", launched, callOpts.hedgingDelay)
+			callOpts.onRetryCallback(parentCtx, launched, lastErr)
+			launch(nil)
+			pushedBack = false
+			timer.Reset(callOpts.hedgingDelay)
+		case res := <-results:
+			pending--
+			if res.err == nil {
+				proto.Reset(reply)
+				proto.Merge(reply, res.reply)
+				return nil
+			}
+			lastErr, lastAttempt = res.err, res.attempt
+			if !isHedgeRetriable(parentCtx, res.attempt, res.err, callOpts) {
+				reason := GiveUpNonRetriable
+				if parentCtx.Err() != nil {
+					reason = GiveUpContext
+				}
+				callOpts.gaveUp(parentCtx, method, res.attempt, reason, lastErr)
+				return lastErr
+			}
+			pushback := pushbackFromServer(res.err, res.trailer, callOpts)
+			if pushback.stop {
+				logTrace(parentCtx, "grpc_retry hedging attempt: %d, server pushback asked not to retry", res.attempt)
+				callOpts.gaveUp(parentCtx, method, res.attempt, GiveUpPushback, lastErr)
+				return lastErr
+			}
+			if pushback.set {
+				// The server told us when to try again, which overrides both the hedging delay and the
+				// immediate retry below.
+				logTrace(parentCtx, "grpc_retry hedging attempt: %d, server pushback for %v", res.attempt, pushback.delay)
+				if bo.exceeds(pushback.delay) {
+					launched = callOpts.max
+					giveUpReason = GiveUpDeadline
+					continue
+				}
+				pushedBack = true
+				timer.Reset(pushback.delay)
+				continue
+			}
+			if launched < callOpts.max {
+				// There is no point waiting for the hedging delay to replace the failed attempt, even if others are
+				// still in flight. If no more attempts can be made, wait for the ones in flight, if any.
+				if bo.exceeds(0) {
+					launched = callOpts.max
+					giveUpReason = GiveUpDeadline
+					continue
+				}
+				if !allowRetry(parentCtx, launched, lastErr, callOpts) {
+					launched = callOpts.max
+					giveUpReason = GiveUpBudgetExhausted
+					continue
+				}
+				callOpts.onRetryCallback(parentCtx, launched, lastErr)
+				launch(lastErr)
+				timer.Reset(callOpts.hedgingDelay)
+			}
+		}
+	}
+	callOpts.gaveUp(parentCtx, method, lastAttempt, giveUpReason, lastErr)
+	return lastErr
+}
+
+// isHedgeRetriable returns true if the failure of a single hedged attempt is non-fatal, i.e. it does not stop
+// other attempts from being made.
+func isHedgeRetriable(parentCtx context.Context, attempt uint, err error, callOpts *options) bool {
+	if isContextError(err) {
+		if parentCtx.Err() != nil {
+			return false
+		}
+		if callOpts.perCallTimeout != 0 {
+			logTrace(parentCtx, "grpc_retry hedging attempt: %d, context error from retry call", attempt)
+			return true
+		}
+	}
+	return isRetriable(err, callOpts)
+}
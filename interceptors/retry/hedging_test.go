@@ -0,0 +1,188 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package retry
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/testing/testpb"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryClientInterceptor_Hedging(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		calls    int
+		attempts []string
+	)
+	slowAttemptCancelled := make(chan struct{})
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		mu.Lock()
+		calls++
+		attempts = append(attempts, md.Get(AttemptMetadataKey)...)
+		n := calls
+		mu.Unlock()
+		if len(md.Get(AttemptMetadataKey)) == 0 {
+			// The original call hangs, until the hedged one wins.
+			<-ctx.Done()
+			close(slowAttemptCancelled)
+			return status.FromContextError(ctx.Err()).Err()
+		}
+		reply.(*testpb.PingResponse).Value = "hedged"
+		reply.(*testpb.PingResponse).Counter = int32(n)
+		return nil
+	}
+
+	interceptor := UnaryClientInterceptor(WithMax(3), WithHedgingDelay(10*time.Millisecond))
+	reply := &testpb.PingResponse{}
+	err := interceptor(context.Background(), "/test/Ping", testpb.GoodPing, reply, nil, invoker)
+	require.NoError(t, err)
+	require.Equal(t, "hedged", reply.Value)
+	require.EqualValues(t, 2, reply.Counter)
+
+	select {
+	case <-slowAttemptCancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("losing attempt was not cancelled")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, []string{"1"}, attempts)
+}
+
+func TestUnaryClientInterceptor_HedgingFailsOnNonRetriableError(t *testing.T) {
+	calls := 0
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		calls++
+		return status.Error(codes.Internal, "internal")
+	}
+
+	interceptor := UnaryClientInterceptor(WithMax(3), WithHedgingDelay(time.Hour))
+	err := interceptor(context.Background(), "/test/Ping", testpb.GoodPing, &testpb.PingResponse{}, nil, invoker)
+	require.Equal(t, codes.Internal, status.Code(err))
+	require.Equal(t, 1, calls)
+}
+
+func TestUnaryClientInterceptor_HedgingRetriesImmediatelyOnRetriableError(t *testing.T) {
+	calls := 0
+	retryCallbacks := 0
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		calls++
+		return status.Error(codes.Unavailable, "unavailable")
+	}
+
+	interceptor := UnaryClientInterceptor(
+		WithMax(3),
+		WithHedgingDelay(time.Hour),
+		WithOnRetryCallback(func(ctx context.Context, attempt uint, err error) {
+			retryCallbacks++
+		}),
+	)
+	err := interceptor(context.Background(), "/test/Ping", testpb.GoodPing, &testpb.PingResponse{}, nil, invoker)
+	require.Equal(t, codes.Unavailable, status.Code(err))
+	require.Equal(t, 3, calls, "all attempts should have been made without waiting for the hedging delay")
+	require.Equal(t, 2, retryCallbacks)
+}
+
+func TestUnaryClientInterceptor_HedgingRetriesImmediatelyWithAttemptsInFlight(t *testing.T) {
+	const delay = 500 * time.Millisecond
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		switch attempt := md.Get(AttemptMetadataKey); {
+		case len(attempt) == 0:
+			// The original call hangs, so that it is still in flight when the hedged one fails.
+			<-ctx.Done()
+			return status.FromContextError(ctx.Err()).Err()
+		case attempt[0] == "1":
+			return status.Error(codes.Unavailable, "unavailable")
+		default:
+			reply.(*testpb.PingResponse).Value = "attempt " + attempt[0]
+			return nil
+		}
+	}
+
+	interceptor := UnaryClientInterceptor(WithMax(3), WithHedgingDelay(delay))
+	reply := &testpb.PingResponse{}
+	start := time.Now()
+	require.NoError(t, interceptor(context.Background(), "/test/Ping", testpb.GoodPing, reply, nil, invoker))
+	require.Equal(t, "attempt 2", reply.Value)
+	require.Less(t, time.Since(start), delay+delay*3/4, "the failed attempt should have been replaced without waiting for the hedging delay")
+}
+
+// hedgedPingService responds slowly to original calls and quickly to the hedged ones.
+type hedgedPingService struct {
+	testpb.TestServiceServer
+	slowResponse time.Duration
+}
+
+func (s *hedgedPingService) Ping(ctx context.Context, ping *testpb.PingRequest) (*testpb.PingResponse, error) {
+	attempt, ok := AttemptFromIncomingContext(ctx)
+	if !ok {
+		select {
+		case <-time.After(s.slowResponse):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return &testpb.PingResponse{Value: ping.Value, Counter: int32(attempt)}, nil
+}
+
+func TestHedgingSuite(t *testing.T) {
+	service := &hedgedPingService{
+		TestServiceServer: &testpb.TestPingService{},
+		slowResponse:      5 * time.Second,
+	}
+	s := &HedgingSuite{
+		InterceptorTestSuite: &testpb.InterceptorTestSuite{
+			TestService: service,
+			ClientOpts: []grpc.DialOption{
+				grpc.WithUnaryInterceptor(UnaryClientInterceptor(WithMax(2), WithHedgingDelay(retryTimeout))),
+			},
+		},
+	}
+	suite.Run(t, s)
+}
+
+type HedgingSuite struct {
+	*testpb.InterceptorTestSuite
+}
+
+func (s *HedgingSuite) TestUnary_HedgedCallWins() {
+	start := time.Now()
+	out, err := s.Client.Ping(s.SimpleCtx(), testpb.GoodPing)
+	s.Require().NoError(err)
+	s.Require().Equal(testpb.GoodPing.Value, out.Value)
+	s.Require().EqualValues(1, out.Counter, "response should come from the hedged attempt")
+	s.Require().Less(time.Since(start), time.Second, "hedged call should not wait for the slow one")
+}
+
+func (s *HedgingSuite) TestUnary_HedgingDisabledPerCall() {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*retryTimeout)
+	defer cancel()
+	_, err := s.Client.Ping(ctx, testpb.GoodPing, WithHedgingDelay(0), WithMax(1))
+	s.Require().Equal(codes.DeadlineExceeded, status.Code(err), "without hedging the slow call should time out")
+}
+
+func TestAttemptFromIncomingContext(t *testing.T) {
+	_, ok := AttemptFromIncomingContext(context.Background())
+	require.False(t, ok)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(AttemptMetadataKey, "2"))
+	attempt, ok := AttemptFromIncomingContext(ctx)
+	require.True(t, ok)
+	require.EqualValues(t, 2, attempt)
+
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs(AttemptMetadataKey, "not-a-number"))
+	_, ok = AttemptFromIncomingContext(ctx)
+	require.False(t, ok)
+}
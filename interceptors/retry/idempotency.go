@@ -0,0 +1,44 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package retry
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/metadata"
+)
+
+// IdempotencyKeyMetadataKey is the header carrying the idempotency key of a call, see `WithIdempotencyKey`.
+const IdempotencyKeyMetadataKey = "x-idempotency-key"
+
+// WithIdempotencyKey makes every attempt of a unary call carry the same random key in the `x-idempotency-key`
+// header, so that servers can recognize retries and hedged copies of a call and avoid executing it twice, e.g.
+// with the interceptors/idempotency server interceptor. A key already set in the outgoing metadata of the call is
+// kept as is.
+func WithIdempotencyKey() CallOption {
+	return CallOption{applyFunc: func(o *options) {
+		o.idempotencyKey = true
+	}}
+}
+
+// withIdempotencyKey returns ctx with an idempotency key in its outgoing metadata, unless it already has one.
+func withIdempotencyKey(ctx context.Context, callOpts *options) context.Context {
+	if !callOpts.idempotencyKey {
+		return ctx
+	}
+	md := metadata.ExtractOutgoing(ctx)
+	if md.Get(IdempotencyKeyMetadataKey) != "" {
+		return ctx
+	}
+	return md.Clone().Set(IdempotencyKeyMetadataKey, newIdempotencyKey()).ToOutgoing(ctx)
+}
+
+func newIdempotencyKey() string {
+	var b [16]byte
+	// crypto/rand.Read never returns an error.
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
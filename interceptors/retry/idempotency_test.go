@@ -0,0 +1,53 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package retry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/testing/testpb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	grpcMetadata "google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func recordIdempotencyKeys(keys *[]string) grpc.UnaryInvoker {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		md, _ := grpcMetadata.FromOutgoingContext(ctx)
+		*keys = append(*keys, md.Get(IdempotencyKeyMetadataKey)...)
+		return status.Error(codes.Unavailable, "unavailable")
+	}
+}
+
+func TestUnaryClientInterceptor_IdempotencyKey(t *testing.T) {
+	var keys []string
+	interceptor := UnaryClientInterceptor(WithMax(3), WithBackoff(BackoffLinear(0)), WithIdempotencyKey())
+	err := interceptor(context.Background(), "/test/Ping", testpb.GoodPing, &testpb.PingResponse{}, nil, recordIdempotencyKeys(&keys))
+	require.Equal(t, codes.Unavailable, status.Code(err))
+	require.Len(t, keys, 3)
+	require.Len(t, keys[0], 32)
+	require.Equal(t, []string{keys[0], keys[0], keys[0]}, keys, "all attempts should carry the same key")
+
+	var other []string
+	_ = interceptor(context.Background(), "/test/Ping", testpb.GoodPing, &testpb.PingResponse{}, nil, recordIdempotencyKeys(&other))
+	require.NotEqual(t, keys[0], other[0], "every call should get its own key")
+}
+
+func TestUnaryClientInterceptor_IdempotencyKeyOfCaller(t *testing.T) {
+	var keys []string
+	ctx := grpcMetadata.AppendToOutgoingContext(context.Background(), IdempotencyKeyMetadataKey, "my-key")
+	interceptor := UnaryClientInterceptor(WithMax(2), WithBackoff(BackoffLinear(0)), WithIdempotencyKey())
+	_ = interceptor(ctx, "/test/Ping", testpb.GoodPing, &testpb.PingResponse{}, nil, recordIdempotencyKeys(&keys))
+	require.Equal(t, []string{"my-key", "my-key"}, keys)
+}
+
+func TestUnaryClientInterceptor_NoIdempotencyKeyByDefault(t *testing.T) {
+	var keys []string
+	interceptor := UnaryClientInterceptor(WithMax(2), WithBackoff(BackoffLinear(0)))
+	_ = interceptor(context.Background(), "/test/Ping", testpb.GoodPing, &testpb.PingResponse{}, nil, recordIdempotencyKeys(&keys))
+	require.Empty(t, keys)
+}
@@ -7,11 +7,16 @@ import (
 	"context"
 	"time"
 
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/util/backoff"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
+// DefaultMaxReplayBytes is the default size limit of the messages buffered to be replayed when a client or bidi
+// stream is retried. Server streams, which only replay their request, have no default limit.
+const DefaultMaxReplayBytes = 256 * 1024
+
 var (
 	// DefaultRetriableCodes is a set of well known types gRPC codes that should be retri-able.
 	//
@@ -23,7 +28,8 @@ var (
 		max:            0, // disabled
 		perCallTimeout: 0, // disabled
 		includeHeader:  true,
-		backoffFunc:    BackoffLinearWithJitter(50*time.Millisecond /*jitter*/, 0.10),
+		serverPushback: true,
+		backoffFunc:    BackoffLinearWithJitter(50*time.Millisecond /*jitter*/, 0.10).stateful(),
 		onRetryCallback: OnRetryCallback(func(ctx context.Context, attempt uint, err error) {
 			logTrace(ctx, "grpc_retry attempt: %d, backoff for %v", attempt, err)
 		}),
@@ -39,7 +45,31 @@ var (
 // with the next iteration. The context can be used to extract request scoped metadata and context values.
 type BackoffFunc func(ctx context.Context, attempt uint) time.Duration
 
+func (bf BackoffFunc) stateful() statefulBackoffFunc {
+	return func(ctx context.Context, attempt uint, _ time.Duration, _ error) time.Duration {
+		return bf(ctx, attempt)
+	}
+}
+
+// ErrorAwareBackoffFunc is like BackoffFunc, but it is also called with the error of the previous attempt. This
+// allows custom policies to react to the error, e.g. to wait longer on `ResourceExhausted` or to honor error details
+// sent by the server.
+type ErrorAwareBackoffFunc func(ctx context.Context, attempt uint, err error) time.Duration
+
+func (bf ErrorAwareBackoffFunc) stateful() statefulBackoffFunc {
+	return func(ctx context.Context, attempt uint, _ time.Duration, err error) time.Duration {
+		return bf(ctx, attempt, err)
+	}
+}
+
+// statefulBackoffFunc is what all the kinds of backoff are turned into. It is called with the wait before the
+// previous attempt as well as its error.
+type statefulBackoffFunc func(ctx context.Context, attempt uint, prev time.Duration, err error) time.Duration
+
 // OnRetryCallback is the type of function called when a retry occurs.
+//
+// It is also called when a retry was prevented by the retry budget (see `WithRetryBudget`), in which
+// case err is a *RetryBudgetExhaustedError wrapping the error of the last attempt.
 type OnRetryCallback func(ctx context.Context, attempt uint, err error)
 
 // RetriableFunc denotes a family of functions that control which error should be retried.
@@ -62,7 +92,38 @@ func WithMax(maxRetries uint) CallOption {
 // WithBackoff sets the `BackoffFunc` used to control time between retries.
 func WithBackoff(bf BackoffFunc) CallOption {
 	return CallOption{applyFunc: func(o *options) {
-		o.backoffFunc = bf
+		o.backoffFunc = bf.stateful()
+	}}
+}
+
+// WithBackoffStrategy sets the strategy of the backoff package (util/backoff) used to control time between
+// retries, e.g. backoff.DecorrelatedJitter which depends on the previous wait.
+func WithBackoffStrategy(strategy backoff.Func) CallOption {
+	return CallOption{applyFunc: func(o *options) {
+		o.backoffFunc = func(_ context.Context, attempt uint, prev time.Duration, _ error) time.Duration {
+			return strategy(attempt, prev)
+		}
+	}}
+}
+
+// WithErrorAwareBackoff sets the `ErrorAwareBackoffFunc` used to control time between retries.
+//
+// Note that the server pushback (see `WithServerPushback`) takes precedence over the backoff function, which is
+// not called for attempts the server specified the delay for.
+func WithErrorAwareBackoff(bf ErrorAwareBackoffFunc) CallOption {
+	return CallOption{applyFunc: func(o *options) {
+		o.backoffFunc = bf.stateful()
+	}}
+}
+
+// WithServerPushback sets whether the retry delays sent by the server should be honored, which is the default.
+//
+// When enabled, the `grpc-retry-pushback-ms` trailer (see `PushbackMetadataKey`) or, if absent, the
+// google.rpc.RetryInfo status detail of a failed attempt sets the wait before the next attempt instead of the
+// backoff function. A pushback trailer with a negative or invalid value stops retries altogether.
+func WithServerPushback(enabled bool) CallOption {
+	return CallOption{applyFunc: func(o *options) {
+		o.serverPushback = enabled
 	}}
 }
 
@@ -104,6 +165,60 @@ func WithPerRetryTimeout(timeout time.Duration) CallOption {
 	}}
 }
 
+// WithHedgingDelay enables hedging of unary calls on this call, or this interceptor.
+//
+// Instead of waiting for an attempt to fail, the interceptor sends another copy of the call every `delay`
+// while no response has been received, up to `WithMax` attempts in total. The first successful response
+// is returned and all the other attempts are cancelled. An attempt that fails with an error that is
+// retriable (see `WithCodes` and `WithRetriable`) makes the next copy go out immediately, even while other
+// copies are still in flight, while any other error is returned to the caller straight away.
+//
+// Every hedged copy carries the `x-retry-attempt` header (unless disabled), so servers can tell hedged
+// and retried calls apart from original ones, see `AttemptFromIncomingContext`.
+//
+// Please *use with care*, hedging is only safe for idempotent calls and by definition increases the load on
+// the backends. It applies only to unary calls with reply types implementing proto.Message, other calls are
+// retried sequentially as usual. A value of 0 disables hedging.
+func WithHedgingDelay(delay time.Duration) CallOption {
+	return CallOption{applyFunc: func(o *options) {
+		o.hedgingDelay = delay
+	}}
+}
+
+// WithRetryBudget sets the retry budget shared by this call, or all calls of this interceptor.
+//
+// Every call made through the interceptor is recorded in the budget, and every retry (or hedged attempt) has
+// to be allowed by it. Once the budget is exhausted, the interceptor stops retrying and returns the error of
+// the last attempt right away. Use the same budget for all calls to a given backend, e.g. by passing it
+// to the interceptors of its grpc.ClientConn. A nil budget disables it.
+func WithRetryBudget(budget *RetryBudget) CallOption {
+	return CallOption{applyFunc: func(o *options) {
+		o.retryBudget = budget
+	}}
+}
+
+// WithMaxReplayMessages sets the maximum number of messages sent on a stream that are buffered to be replayed
+// on a new stream when retrying. Once more messages are sent, the stream is no longer retried. A value of 0,
+// the default, sets no limit on the number of messages.
+func WithMaxReplayMessages(maxMessages int) CallOption {
+	return CallOption{applyFunc: func(o *options) {
+		o.maxReplayMessages = maxMessages
+	}}
+}
+
+// WithMaxReplayBytes sets the maximum total size of the messages sent on a stream that are buffered to be
+// replayed on a new stream when retrying. Once more bytes are sent, the stream is no longer retried. Defaults
+// to `DefaultMaxReplayBytes` for client and bidi streams, and to no limit for server streams, as before the limit
+// was introduced. A value of 0 sets no limit on the size, which is only safe for short streams.
+//
+// The size of a message is its proto.Size, messages that are not a proto.Message are not counted.
+func WithMaxReplayBytes(maxBytes int) CallOption {
+	return CallOption{applyFunc: func(o *options) {
+		o.maxReplayBytes = maxBytes
+		o.maxReplayBytesSet = true
+	}}
+}
+
 // WithRetriable sets which error should be retried.
 func WithRetriable(retriableFunc RetriableFunc) CallOption {
 	return CallOption{applyFunc: func(o *options) {
@@ -112,12 +227,27 @@ func WithRetriable(retriableFunc RetriableFunc) CallOption {
 }
 
 type options struct {
-	max             uint
-	perCallTimeout  time.Duration
-	includeHeader   bool
-	backoffFunc     BackoffFunc
-	onRetryCallback OnRetryCallback
-	retriableFunc   RetriableFunc
+	max               uint
+	perCallTimeout    time.Duration
+	hedgingDelay      time.Duration
+	includeHeader     bool
+	serverPushback    bool
+	maxReplayMessages int
+	maxReplayBytes    int
+	maxReplayBytesSet bool
+	backoffFunc       statefulBackoffFunc
+	onRetryCallback   OnRetryCallback
+	retriableFunc     RetriableFunc
+	retryBudget       *RetryBudget
+	resumeFunc        ResumeFunc
+	dedupKeyFunc      DeduplicationKeyFunc
+	dedupWindow       int
+	serviceConfig     *ServiceConfig
+	eventHandlers     []EventHandler
+	retryDeadline     time.Duration
+	splitDeadline     bool
+	minAttemptTimeout time.Duration
+	idempotencyKey    bool
 }
 
 // CallOption is a grpc.CallOption that is local to grpc_retry.
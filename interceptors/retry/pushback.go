@@ -0,0 +1,61 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package retry
+
+import (
+	"strconv"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	grpcMetadata "google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// PushbackMetadataKey is the trailer a server can use to tell the client how long to wait before retrying
+// (a non-negative number of milliseconds), or not to retry at all (any other value), as specified in
+// https://github.com/grpc/proposal/blob/master/A6-client-retries.md#pushback.
+const PushbackMetadataKey = "grpc-retry-pushback-ms"
+
+// serverPushback is the server's instruction for the next attempt.
+type serverPushback struct {
+	// delay replaces the backoff before the next attempt, if set is true.
+	delay time.Duration
+	set   bool
+	// stop is true if the server asked not to retry.
+	stop bool
+}
+
+// pushbackFromServer reads the server's pushback from the `grpc-retry-pushback-ms` trailer or, if
+// absent, from the google.rpc.RetryInfo status detail of err.
+func pushbackFromServer(err error, trailer grpcMetadata.MD, callOpts *options) serverPushback {
+	if !callOpts.serverPushback || err == nil {
+		return serverPushback{}
+	}
+	if vals := trailer.Get(PushbackMetadataKey); len(vals) > 0 {
+		ms, parseErr := strconv.ParseInt(vals[0], 10, 64)
+		if parseErr != nil || ms < 0 {
+			return serverPushback{stop: true}
+		}
+		return serverPushback{delay: time.Duration(ms) * time.Millisecond, set: true}
+	}
+	for _, d := range status.Convert(err).Details() {
+		if info, ok := d.(*errdetails.RetryInfo); ok && info.GetRetryDelay().IsValid() {
+			if delay := info.GetRetryDelay().AsDuration(); delay >= 0 {
+				return serverPushback{delay: delay, set: true}
+			}
+		}
+	}
+	return serverPushback{}
+}
+
+// withTrailer returns the gRPC call options that additionally capture the trailer of the call, if
+// server pushback is enabled.
+func withTrailer(grpcOpts []grpc.CallOption, trailer *grpcMetadata.MD, callOpts *options) []grpc.CallOption {
+	if !callOpts.serverPushback {
+		return grpcOpts
+	}
+	// Make sure we never modify the caller's slice.
+	return append(grpcOpts[:len(grpcOpts):len(grpcOpts)], grpc.Trailer(trailer))
+}
@@ -0,0 +1,182 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package retry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/testing/testpb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func retryInfoError(t *testing.T, delay time.Duration) error {
+	st, err := status.New(codes.ResourceExhausted, "slow down").WithDetails(&errdetails.RetryInfo{
+		RetryDelay: durationpb.New(delay),
+	})
+	require.NoError(t, err)
+	return st.Err()
+}
+
+func TestPushbackFromServer(t *testing.T) {
+	callOpts := reuseOrNewWithCallOptions(defaultOptions, nil)
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+
+	for _, tcase := range []struct {
+		name     string
+		err      error
+		trailer  metadata.MD
+		expected serverPushback
+	}{
+		{name: "no pushback", err: unavailable},
+		{name: "no error", trailer: metadata.Pairs(PushbackMetadataKey, "100")},
+		{
+			name:     "trailer",
+			err:      unavailable,
+			trailer:  metadata.Pairs(PushbackMetadataKey, "100"),
+			expected: serverPushback{delay: 100 * time.Millisecond, set: true},
+		},
+		{
+			name:     "zero trailer",
+			err:      unavailable,
+			trailer:  metadata.Pairs(PushbackMetadataKey, "0"),
+			expected: serverPushback{set: true},
+		},
+		{
+			name:     "negative trailer",
+			err:      unavailable,
+			trailer:  metadata.Pairs(PushbackMetadataKey, "-1"),
+			expected: serverPushback{stop: true},
+		},
+		{
+			name:     "invalid trailer",
+			err:      unavailable,
+			trailer:  metadata.Pairs(PushbackMetadataKey, "soon"),
+			expected: serverPushback{stop: true},
+		},
+		{
+			name:     "retry info",
+			err:      retryInfoError(t, 2*time.Second),
+			expected: serverPushback{delay: 2 * time.Second, set: true},
+		},
+		{
+			name:     "trailer overrides retry info",
+			err:      retryInfoError(t, 2*time.Second),
+			trailer:  metadata.Pairs(PushbackMetadataKey, "100"),
+			expected: serverPushback{delay: 100 * time.Millisecond, set: true},
+		},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			require.Equal(t, tcase.expected, pushbackFromServer(tcase.err, tcase.trailer, callOpts))
+		})
+	}
+
+	disabled := reuseOrNewWithCallOptions(defaultOptions, []CallOption{WithServerPushback(false)})
+	require.Equal(t, serverPushback{}, pushbackFromServer(retryInfoError(t, time.Second), metadata.Pairs(PushbackMetadataKey, "-1"), disabled))
+}
+
+// pushbackInvoker fails with the given trailer for the first call and succeeds afterwards.
+func pushbackInvoker(calls *int, err error, trailer metadata.MD) grpc.UnaryInvoker {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		*calls++
+		if *calls > 1 {
+			return nil
+		}
+		for _, opt := range opts {
+			if t, ok := opt.(grpc.TrailerCallOption); ok {
+				*t.TrailerAddr = trailer
+			}
+		}
+		return err
+	}
+}
+
+func TestUnaryClientInterceptor_ServerPushback(t *testing.T) {
+	var waits []time.Duration
+	backoff := WithErrorAwareBackoff(func(ctx context.Context, attempt uint, err error) time.Duration {
+		waits = append(waits, time.Hour)
+		return time.Hour
+	})
+
+	t.Run("trailer delay overrides backoff", func(t *testing.T) {
+		waits = nil
+		calls := 0
+		interceptor := UnaryClientInterceptor(WithMax(3), backoff)
+		invoker := pushbackInvoker(&calls, status.Error(codes.Unavailable, "unavailable"), metadata.Pairs(PushbackMetadataKey, "1"))
+		require.NoError(t, interceptor(context.Background(), "/test/Ping", testpb.GoodPing, &testpb.PingResponse{}, nil, invoker))
+		require.Equal(t, 2, calls)
+		require.Empty(t, waits, "backoff should not be called when the server sets the delay")
+	})
+
+	t.Run("retry info delay overrides backoff", func(t *testing.T) {
+		waits = nil
+		calls := 0
+		interceptor := UnaryClientInterceptor(WithMax(3), WithCodes(codes.ResourceExhausted), backoff)
+		invoker := pushbackInvoker(&calls, retryInfoError(t, time.Millisecond), nil)
+		require.NoError(t, interceptor(context.Background(), "/test/Ping", testpb.GoodPing, &testpb.PingResponse{}, nil, invoker))
+		require.Equal(t, 2, calls)
+		require.Empty(t, waits)
+	})
+
+	t.Run("negative trailer stops retries", func(t *testing.T) {
+		calls := 0
+		interceptor := UnaryClientInterceptor(WithMax(3), backoff)
+		invoker := pushbackInvoker(&calls, status.Error(codes.Unavailable, "unavailable"), metadata.Pairs(PushbackMetadataKey, "-1"))
+		err := interceptor(context.Background(), "/test/Ping", testpb.GoodPing, &testpb.PingResponse{}, nil, invoker)
+		require.Equal(t, codes.Unavailable, status.Code(err))
+		require.Equal(t, 1, calls)
+	})
+
+	t.Run("pushback disabled", func(t *testing.T) {
+		calls := 0
+		interceptor := UnaryClientInterceptor(WithMax(3), WithServerPushback(false), WithBackoff(BackoffLinear(0)))
+		invoker := pushbackInvoker(&calls, status.Error(codes.Unavailable, "unavailable"), metadata.Pairs(PushbackMetadataKey, "-1"))
+		require.NoError(t, interceptor(context.Background(), "/test/Ping", testpb.GoodPing, &testpb.PingResponse{}, nil, invoker))
+		require.Equal(t, 2, calls)
+	})
+
+	t.Run("hedging waits for the pushback", func(t *testing.T) {
+		calls := 0
+		interceptor := UnaryClientInterceptor(WithMax(3), WithHedgingDelay(time.Hour))
+		invoker := pushbackInvoker(&calls, status.Error(codes.Unavailable, "unavailable"), metadata.Pairs(PushbackMetadataKey, "20"))
+		start := time.Now()
+		require.NoError(t, interceptor(context.Background(), "/test/Ping", testpb.GoodPing, &testpb.PingResponse{}, nil, invoker))
+		require.Equal(t, 2, calls)
+		require.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+	})
+
+	t.Run("hedging stops on negative pushback", func(t *testing.T) {
+		calls := 0
+		interceptor := UnaryClientInterceptor(WithMax(3), WithHedgingDelay(time.Hour))
+		invoker := pushbackInvoker(&calls, status.Error(codes.Unavailable, "unavailable"), metadata.Pairs(PushbackMetadataKey, "-1"))
+		err := interceptor(context.Background(), "/test/Ping", testpb.GoodPing, &testpb.PingResponse{}, nil, invoker)
+		require.Equal(t, codes.Unavailable, status.Code(err))
+		require.Equal(t, 1, calls)
+	})
+}
+
+func TestUnaryClientInterceptor_ErrorAwareBackoff(t *testing.T) {
+	var gotErrs []error
+	calls := 0
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		calls++
+		return status.Errorf(codes.Unavailable, "attempt %d", calls)
+	}
+	interceptor := UnaryClientInterceptor(WithMax(3), WithErrorAwareBackoff(func(ctx context.Context, attempt uint, err error) time.Duration {
+		gotErrs = append(gotErrs, err)
+		return 0
+	}))
+	err := interceptor(context.Background(), "/test/Ping", testpb.GoodPing, &testpb.PingResponse{}, nil, invoker)
+	require.Equal(t, codes.Unavailable, status.Code(err))
+	require.Len(t, gotErrs, 2)
+	require.Equal(t, "attempt 1", status.Convert(gotErrs[0]).Message())
+	require.Equal(t, "attempt 2", status.Convert(gotErrs[1]).Message())
+}
@@ -0,0 +1,112 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package retry
+
+import (
+	"context"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// DefaultDeduplicationWindow is the default number of message keys remembered by `WithDeduplication`.
+const DefaultDeduplicationWindow = 1024
+
+// ResumeFunc rewrites the request of a server stream before it is reestablished, so that the new stream
+// resumes after the last message received on the previous one instead of starting over, e.g. by setting a page
+// token or an offset in the request.
+//
+// It is called with the request sent on the previous stream and the last message received, and returns the
+// request to send. The request must not be modified in place; clone it before changing it.
+type ResumeFunc func(ctx context.Context, req any, lastReceived any) any
+
+// DeduplicationKeyFunc returns the key identifying a received message for `WithDeduplication`. Messages
+// with an empty key are never dropped.
+type DeduplicationKeyFunc func(msg any) string
+
+// WithResume sets the function used to resume server streams when they are retried, see `ResumeFunc`.
+//
+// The last message received is cloned after every RecvMsg so that it can be passed to the function. If
+// no message was received before the stream failed, the original request is sent again.
+func WithResume(resumeFunc ResumeFunc) CallOption {
+	return CallOption{applyFunc: func(o *options) {
+		o.resumeFunc = resumeFunc
+	}}
+}
+
+// WithDeduplication drops the received messages whose key, as returned by keyFunc, was already seen on
+// the stream, e.g. the messages sent again by the server after a retry. Only the last `window` keys are
+// remembered; a window of 0 defaults to `DefaultDeduplicationWindow`.
+func WithDeduplication(keyFunc DeduplicationKeyFunc, window int) CallOption {
+	if window <= 0 {
+		window = DefaultDeduplicationWindow
+	}
+	return CallOption{applyFunc: func(o *options) {
+		o.dedupKeyFunc = keyFunc
+		o.dedupWindow = window
+	}}
+}
+
+// dedupSet remembers the last keys added to it.
+type dedupSet struct {
+	keys  map[string]struct{}
+	order []string // ring buffer of keys, oldest at next once full.
+	next  int
+}
+
+func newDedupSet(window int) *dedupSet {
+	return &dedupSet{keys: make(map[string]struct{}, window), order: make([]string, 0, window)}
+}
+
+// add adds the key to the set, returning false if it was already in it.
+func (d *dedupSet) add(key string) bool {
+	if _, ok := d.keys[key]; ok {
+		return false
+	}
+	if len(d.order) < cap(d.order) {
+		d.order = append(d.order, key)
+	} else {
+		delete(d.keys, d.order[d.next])
+		d.order[d.next] = key
+		d.next = (d.next + 1) % len(d.order)
+	}
+	d.keys[key] = struct{}{}
+	return true
+}
+
+// isDuplicate returns true if the received message should be dropped.
+func (s *retryingClientStream) isDuplicate(m any) bool {
+	if s.callOpts.dedupKeyFunc == nil {
+		return false
+	}
+	key := s.callOpts.dedupKeyFunc(m)
+	if key == "" {
+		return false
+	}
+	if s.seenKeys == nil {
+		s.seenKeys = newDedupSet(s.callOpts.dedupWindow)
+	}
+	return !s.seenKeys.add(key)
+}
+
+// recordReceived keeps a copy of the last message received, to resume from it.
+func (s *retryingClientStream) recordReceived(m any) {
+	if s.callOpts.resumeFunc == nil || s.clientStreams {
+		return
+	}
+	if msg, ok := m.(proto.Message); ok {
+		s.lastReceived = proto.Clone(msg)
+		return
+	}
+	s.lastReceived = m
+}
+
+// resumeLocked rewrites the request to resume after the last message received, if any. It must be
+// called with sendMu held.
+func (s *retryingClientStream) resumeLocked(ctx context.Context) {
+	if s.callOpts.resumeFunc == nil || s.clientStreams || s.lastReceived == nil || len(s.bufferedSends) != 1 {
+		return
+	}
+	logTrace(ctx, "grpc_retry resuming stream after the last message received")
+	s.bufferedSends[0] = s.callOpts.resumeFunc(ctx, s.bufferedSends[0], s.lastReceived)
+}
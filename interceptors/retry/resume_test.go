@@ -0,0 +1,106 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package retry
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/testing/testpb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// scriptedClientStream records the request sent and returns the responses, followed by err.
+type scriptedClientStream struct {
+	failingClientStream
+	req       *testpb.PingListRequest
+	responses []*testpb.PingListResponse
+	err       error
+}
+
+func (s *scriptedClientStream) SendMsg(m any) error {
+	s.req = m.(*testpb.PingListRequest)
+	return nil
+}
+
+func (s *scriptedClientStream) RecvMsg(m any) error {
+	if len(s.responses) == 0 {
+		return s.err
+	}
+	proto.Reset(m.(proto.Message))
+	proto.Merge(m.(proto.Message), s.responses[0])
+	s.responses = s.responses[1:]
+	return nil
+}
+
+func TestStreamClientInterceptor_ResumeAndDeduplication(t *testing.T) {
+	responses := func(values ...string) []*testpb.PingListResponse {
+		var out []*testpb.PingListResponse
+		for _, v := range values {
+			out = append(out, &testpb.PingListResponse{Value: v})
+		}
+		return out
+	}
+	scripts := []*scriptedClientStream{
+		{responses: responses("1", "2"), err: status.Error(codes.Unavailable, "failover")},
+		// The server resends the last message after the failover.
+		{responses: responses("2", "3"), err: io.EOF},
+	}
+	var streams []*scriptedClientStream
+	streamer := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		stream := scripts[len(streams)]
+		streams = append(streams, stream)
+		return stream, nil
+	}
+
+	var resumedFrom []string
+	interceptor := StreamClientInterceptor(
+		WithMax(3),
+		WithBackoff(BackoffLinear(0)),
+		WithResume(func(ctx context.Context, req any, lastReceived any) any {
+			resumedFrom = append(resumedFrom, lastReceived.(*testpb.PingListResponse).Value)
+			resumed := proto.Clone(req.(proto.Message)).(*testpb.PingListRequest)
+			resumed.Value = "after-" + lastReceived.(*testpb.PingListResponse).Value
+			return resumed
+		}),
+		WithDeduplication(func(msg any) string {
+			return msg.(*testpb.PingListResponse).Value
+		}, 0),
+	)
+	stream, err := interceptor(context.Background(), &grpc.StreamDesc{ServerStreams: true}, nil, "/test/PingList", streamer)
+	require.NoError(t, err)
+	require.NoError(t, stream.SendMsg(&testpb.PingListRequest{Value: "start"}))
+	require.NoError(t, stream.CloseSend())
+
+	var received []string
+	resp := &testpb.PingListResponse{}
+	for {
+		err := stream.RecvMsg(resp)
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		received = append(received, resp.Value)
+	}
+	require.Equal(t, []string{"1", "2", "3"}, received, "duplicate should have been dropped")
+	require.Equal(t, []string{"2"}, resumedFrom)
+	require.Len(t, streams, 2)
+	require.Equal(t, "start", streams[0].req.Value)
+	require.Equal(t, "after-2", streams[1].req.Value)
+}
+
+func TestDedupSet(t *testing.T) {
+	d := newDedupSet(2)
+	require.True(t, d.add("a"))
+	require.True(t, d.add("b"))
+	require.False(t, d.add("a"))
+	require.True(t, d.add("c"), "evicts a")
+	require.True(t, d.add("a"), "a was evicted")
+	require.False(t, d.add("c"))
+}
@@ -8,7 +8,9 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/metadata"
@@ -16,6 +18,7 @@ import (
 	"google.golang.org/grpc/codes"
 	grpcMetadata "google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
 const (
@@ -30,24 +33,51 @@ func UnaryClientInterceptor(optFuncs ...CallOption) grpc.UnaryClientInterceptor
 	intOpts := reuseOrNewWithCallOptions(defaultOptions, optFuncs)
 	return func(parentCtx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
 		grpcOpts, retryOpts := filterCallOptions(opts)
-		callOpts := reuseOrNewWithCallOptions(intOpts, retryOpts)
+		callOpts := callOptionsForMethod(intOpts, method, retryOpts)
 		// short circuit for simplicity, and avoiding allocations.
 		if callOpts.max == 0 {
 			return invoker(parentCtx, method, req, reply, cc, grpcOpts...)
 		}
+		callOpts.retryBudget.recordCall()
+		parentCtx = withIdempotencyKey(parentCtx, callOpts)
+		if callOpts.hedgingDelay > 0 {
+			if replyMsg, ok := reply.(proto.Message); ok {
+				return hedgedInvoke(parentCtx, method, req, replyMsg, cc, invoker, grpcOpts, callOpts)
+			}
+			logTrace(parentCtx, "grpc_retry cannot hedge %T reply, falling back to sequential retries", reply)
+		}
 		var lastErr error
+		bo := newBackoffState(callOpts)
 		for attempt := uint(0); attempt < callOpts.max; attempt++ {
-			if err := waitRetryBackoff(attempt, parentCtx, callOpts); err != nil {
+			if attempt > 0 && !allowRetry(parentCtx, attempt, lastErr, callOpts) {
+				callOpts.gaveUp(parentCtx, method, attempt-1, GiveUpBudgetExhausted, lastErr)
+				return lastErr
+			}
+			if err := waitRetryBackoff(attempt, parentCtx, lastErr, &bo, callOpts); err != nil {
+				if errors.Is(err, errRetryDeadlineExceeded) {
+					callOpts.gaveUp(parentCtx, method, attempt-1, GiveUpDeadline, lastErr)
+					return lastErr
+				}
+				callOpts.gaveUp(parentCtx, method, attempt-1, GiveUpContext, err)
 				return err
 			}
+			timeout, ok := attemptTimeout(parentCtx, callOpts, attempt)
+			if !ok {
+				logTrace(parentCtx, "grpc_retry attempt: %d, not enough time left before the deadline", attempt)
+				callOpts.gaveUp(parentCtx, method, attempt-1, GiveUpDeadline, lastErr)
+				return lastErr
+			}
 			if attempt > 0 {
 				callOpts.onRetryCallback(parentCtx, attempt, lastErr)
 			}
-			callCtx, cancel := perCallContext(parentCtx, callOpts, attempt)
-			lastErr = invoker(callCtx, method, req, reply, cc, grpcOpts...)
+			var trailer grpcMetadata.MD
+			start := callOpts.attemptStarted(parentCtx, method, attempt, lastErr)
+			callCtx, cancel := perCallContext(parentCtx, timeout, callOpts, attempt)
+			lastErr = invoker(callCtx, method, req, reply, cc, withTrailer(grpcOpts, &trailer, callOpts)...)
 			// Cancel the context immediately after invoking the next call in the chain to avoid
 			// holing onto its memory until this function returns.
 			cancel()
+			callOpts.attemptEnded(parentCtx, method, attempt, start, lastErr)
 			// TODO(mwitkow): Maybe dial and transport errors should be retriable?
 			if lastErr == nil {
 				return nil
@@ -56,18 +86,27 @@ func UnaryClientInterceptor(optFuncs ...CallOption) grpc.UnaryClientInterceptor
 				if parentCtx.Err() != nil {
 					logTrace(parentCtx, "grpc_retry attempt: %d, parent context error: %v", attempt, parentCtx.Err())
 					// its the parent context deadline or cancellation.
+					callOpts.gaveUp(parentCtx, method, attempt, GiveUpContext, lastErr)
 					return lastErr
-				} else if callOpts.perCallTimeout != 0 {
+				} else if timeout != 0 {
 					// We have set a perCallTimeout in the retry middleware, which would result in a context error if
 					// the deadline was exceeded, in which case try again.
 					logTrace(parentCtx, "grpc_retry attempt: %d, context error from retry call", attempt)
+					bo.pushback = serverPushback{}
 					continue
 				}
 			}
 			if !isRetriable(lastErr, callOpts) {
+				callOpts.gaveUp(parentCtx, method, attempt, GiveUpNonRetriable, lastErr)
+				return lastErr
+			}
+			if bo.pushback = pushbackFromServer(lastErr, trailer, callOpts); bo.pushback.stop {
+				logTrace(parentCtx, "grpc_retry attempt: %d, server pushback asked not to retry", attempt)
+				callOpts.gaveUp(parentCtx, method, attempt, GiveUpPushback, lastErr)
 				return lastErr
 			}
 		}
+		callOpts.gaveUp(parentCtx, method, callOpts.max-1, GiveUpMaxAttempts, lastErr)
 		return lastErr
 	}
 }
@@ -77,186 +116,385 @@ func UnaryClientInterceptor(optFuncs ...CallOption) grpc.UnaryClientInterceptor
 // The default configuration of the interceptor is to not retry *at all*. This behaviour can be
 // changed through options (e.g. WithMax) on creation of the interceptor or on call (through grpc.CallOptions).
 //
-// Retry logic is available *only for ServerStreams*, i.e. 1:n streams, as the internal logic needs
-// to buffer the messages sent by the client. If retry is enabled on any other streams (ClientStreams,
-// BidiStreams), the retry interceptor will fail the call.
+// The messages sent by the client are buffered, so that they can be replayed on a new stream when
+// retrying, up to the limits set by `WithMaxReplayMessages` and `WithMaxReplayBytes`. Once the buffer
+// overflows, the stream is no longer retried. Client streaming and bidi streams (ClientStreams) are only
+// retried until the first response message is received, as the server has committed to the stream then.
 func StreamClientInterceptor(optFuncs ...CallOption) grpc.StreamClientInterceptor {
 	intOpts := reuseOrNewWithCallOptions(defaultOptions, optFuncs)
 	return func(parentCtx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
 		grpcOpts, retryOpts := filterCallOptions(opts)
-		callOpts := reuseOrNewWithCallOptions(intOpts, retryOpts)
+		callOpts := callOptionsForMethod(intOpts, method, retryOpts)
 		// short circuit for simplicity, and avoiding allocations.
 		if callOpts.max == 0 {
 			return streamer(parentCtx, desc, cc, method, grpcOpts...)
 		}
-		if desc.ClientStreams {
-			return nil, status.Error(codes.Unimplemented, "grpc_retry: cannot retry on ClientStreams, set grpc_retry.Disable()")
-		}
+		callOpts.retryBudget.recordCall()
 
 		var lastErr error
+		bo := newBackoffState(callOpts)
 		for attempt := uint(0); attempt < callOpts.max; attempt++ {
-			if err := waitRetryBackoff(attempt, parentCtx, callOpts); err != nil {
+			if attempt > 0 && !allowRetry(parentCtx, attempt, lastErr, callOpts) {
+				callOpts.gaveUp(parentCtx, method, attempt-1, GiveUpBudgetExhausted, lastErr)
+				return nil, lastErr
+			}
+			if err := waitRetryBackoff(attempt, parentCtx, lastErr, &bo, callOpts); err != nil {
+				if errors.Is(err, errRetryDeadlineExceeded) {
+					callOpts.gaveUp(parentCtx, method, attempt-1, GiveUpDeadline, lastErr)
+					return nil, lastErr
+				}
+				callOpts.gaveUp(parentCtx, method, attempt-1, GiveUpContext, err)
 				return nil, err
 			}
 			if attempt > 0 {
 				callOpts.onRetryCallback(parentCtx, attempt, lastErr)
 			}
 			var newStreamer grpc.ClientStream
+			start := callOpts.attemptStarted(parentCtx, method, attempt, lastErr)
 			newStreamer, lastErr = streamer(perStreamContext(parentCtx, callOpts, attempt), desc, cc, method, grpcOpts...)
 			if lastErr == nil {
-				retryingStreamer := &serverStreamingRetryingStream{
-					ClientStream: newStreamer,
-					callOpts:     callOpts,
-					parentCtx:    parentCtx,
-					streamerCall: func(ctx context.Context) (grpc.ClientStream, error) {
-						attempt++
+				retryingStreamer := &retryingClientStream{
+					ClientStream:  newStreamer,
+					clientStreams: desc.ClientStreams,
+					method:        method,
+					attempt:       attempt,
+					attemptStart:  start,
+					backoff:       bo,
+					callOpts:      callOpts,
+					parentCtx:     parentCtx,
+					streamerCall: func(ctx context.Context, attempt uint) (grpc.ClientStream, error) {
 						return streamer(perStreamContext(ctx, callOpts, attempt), desc, cc, method, grpcOpts...)
 					},
 				}
 				return retryingStreamer, nil
 			}
+			callOpts.attemptEnded(parentCtx, method, attempt, start, lastErr)
 			if isContextError(lastErr) {
 				if parentCtx.Err() != nil {
 					logTrace(parentCtx, "grpc_retry attempt: %d, parent context error: %v", attempt, parentCtx.Err())
 					// its the parent context deadline or cancellation.
+					callOpts.gaveUp(parentCtx, method, attempt, GiveUpContext, lastErr)
 					return nil, lastErr
 				} else if callOpts.perCallTimeout != 0 {
 					// We have set a perCallTimeout in the retry middleware, which would result in a context error if
 					// the deadline was exceeded, in which case try again.
 					logTrace(parentCtx, "grpc_retry attempt: %d, context error from retry call", attempt)
+					bo.pushback = serverPushback{}
 					continue
 				}
 			}
 			if !isRetriable(lastErr, callOpts) {
+				callOpts.gaveUp(parentCtx, method, attempt, GiveUpNonRetriable, lastErr)
+				return nil, lastErr
+			}
+			// Streams that failed to be established have no trailer, so only the error can carry a pushback.
+			if bo.pushback = pushbackFromServer(lastErr, nil, callOpts); bo.pushback.stop {
+				logTrace(parentCtx, "grpc_retry attempt: %d, server pushback asked not to retry", attempt)
+				callOpts.gaveUp(parentCtx, method, attempt, GiveUpPushback, lastErr)
 				return nil, lastErr
 			}
 		}
+		callOpts.gaveUp(parentCtx, method, callOpts.max-1, GiveUpMaxAttempts, lastErr)
 		return nil, lastErr
 	}
 }
 
-// type serverStreamingRetryingStream is the implementation of grpc.ClientStream that acts as a
+// type retryingClientStream is the implementation of grpc.ClientStream that acts as a
 // proxy to the underlying call. If any of the RecvMsg() calls fail, it will try to reestablish
-// a new ClientStream according to the retry policy.
-type serverStreamingRetryingStream struct {
+// a new ClientStream according to the retry policy, and replay the messages sent so far on it.
+//
+// For ClientStreams (client streaming and bidi calls), retries are only possible until the first
+// response is received, as the server has committed to the stream from then on.
+type retryingClientStream struct {
 	grpc.ClientStream
-	bufferedSends []any // single message that the client can sen
-	wasClosedSend bool  // indicates that CloseSend was closed
+	clientStreams bool
+	method        string
 	parentCtx     context.Context
 	callOpts      *options
-	streamerCall  func(ctx context.Context) (grpc.ClientStream, error)
-	mu            sync.RWMutex
+	streamerCall  func(ctx context.Context, attempt uint) (grpc.ClientStream, error)
+	mu            sync.RWMutex // guards ClientStream
+
+	// attempt, attemptStart and attemptDone describe the current stream, they are only used by RecvMsg.
+	attempt      uint
+	attemptStart time.Time
+	attemptDone  bool
+	gaveUpDone   bool
+	backoff      backoffState
+
+	// sendMu serializes the sends with the replay of bufferedSends on a new stream, so that no message
+	// is either lost or sent twice.
+	sendMu        sync.Mutex
+	bufferedSends []any // messages sent so far, to be replayed on a new stream
+	bufferedBytes int
+	wasClosedSend bool // indicates that CloseSend was closed
+	// replayDisabled is set once the messages sent no longer fit in the replay buffer.
+	replayDisabled atomic.Bool
+	// committed is set once a response was received on ClientStreams.
+	committed atomic.Bool
+
+	// lastReceived and seenKeys are only used by RecvMsg.
+	lastReceived any
+	seenKeys     *dedupSet
 }
 
-func (s *serverStreamingRetryingStream) setStream(clientStream grpc.ClientStream) {
+func (s *retryingClientStream) setStream(clientStream grpc.ClientStream) {
 	s.mu.Lock()
 	s.ClientStream = clientStream
 	s.mu.Unlock()
 }
 
-func (s *serverStreamingRetryingStream) getStream() grpc.ClientStream {
+func (s *retryingClientStream) getStream() grpc.ClientStream {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	return s.ClientStream
 }
 
-func (s *serverStreamingRetryingStream) SendMsg(m any) error {
-	s.mu.Lock()
+// canRetry returns false once the stream can no longer be replayed.
+func (s *retryingClientStream) canRetry() bool {
+	return !s.replayDisabled.Load() && !s.committed.Load()
+}
+
+func (s *retryingClientStream) SendMsg(m any) error {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+	s.bufferSendLocked(m)
+	err := s.getStream().SendMsg(m)
+	if errors.Is(err, io.EOF) && s.canRetry() {
+		// The stream is broken, the next RecvMsg will get its status and retry it, replaying this message.
+		logTrace(s.parentCtx, "grpc_retry stream broken on SendMsg, message buffered for retry")
+		return nil
+	}
+	return err
+}
+
+// bufferSendLocked adds the message to the replay buffer, or disables the retries if the buffer is full.
+// It must be called with sendMu held.
+func (s *retryingClientStream) bufferSendLocked(m any) {
+	if !s.canRetry() {
+		// Nothing will ever be replayed, release the memory.
+		s.bufferedSends, s.bufferedBytes = nil, 0
+		return
+	}
+	size := 0
+	if msg, ok := m.(proto.Message); ok {
+		size = proto.Size(msg)
+	}
+	maxMessages, maxBytes := s.callOpts.maxReplayMessages, s.callOpts.maxReplayBytes
+	if !s.callOpts.maxReplayBytesSet && s.clientStreams {
+		maxBytes = DefaultMaxReplayBytes
+	}
+	if (maxMessages > 0 && len(s.bufferedSends) >= maxMessages) || (maxBytes > 0 && s.bufferedBytes+size > maxBytes) {
+		logTrace(s.parentCtx, "grpc_retry replay buffer full (%d messages, %d bytes), disabling retries for the stream", len(s.bufferedSends)+1, s.bufferedBytes+size)
+		s.replayDisabled.Store(true)
+		s.bufferedSends, s.bufferedBytes = nil, 0
+		return
+	}
 	s.bufferedSends = append(s.bufferedSends, m)
-	s.mu.Unlock()
-	return s.getStream().SendMsg(m)
+	s.bufferedBytes += size
 }
 
-func (s *serverStreamingRetryingStream) CloseSend() error {
-	s.mu.Lock()
+func (s *retryingClientStream) CloseSend() error {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
 	s.wasClosedSend = true
-	s.mu.Unlock()
 	return s.getStream().CloseSend()
 }
 
-func (s *serverStreamingRetryingStream) Header() (grpcMetadata.MD, error) {
+func (s *retryingClientStream) Header() (grpcMetadata.MD, error) {
 	return s.getStream().Header()
 }
 
-func (s *serverStreamingRetryingStream) Trailer() grpcMetadata.MD {
+func (s *retryingClientStream) Trailer() grpcMetadata.MD {
 	return s.getStream().Trailer()
 }
 
-func (s *serverStreamingRetryingStream) RecvMsg(m any) error {
-	attemptRetry, lastErr := s.receiveMsgAndIndicateRetry(m)
+func (s *retryingClientStream) RecvMsg(m any) error {
+	for {
+		if err := s.recvMsgWithRetry(m); err != nil {
+			return err
+		}
+		if !s.isDuplicate(m) {
+			s.recordReceived(m)
+			return nil
+		}
+		logTrace(s.parentCtx, "grpc_retry dropped duplicate message")
+	}
+}
+
+func (s *retryingClientStream) recvMsgWithRetry(m any) error {
+	attemptRetry, pushback, lastErr := s.receiveMsgAndIndicateRetry(m)
+	s.backoff.pushback = pushback
 	if !attemptRetry {
 		return lastErr // success or hard failure
 	}
 	// We start off from attempt 1, because zeroth was already made on normal SendMsg().
 	for attempt := uint(1); attempt < s.callOpts.max; attempt++ {
-		if err := waitRetryBackoff(attempt, s.parentCtx, s.callOpts); err != nil {
+		if !allowRetry(s.parentCtx, attempt, lastErr, s.callOpts) {
+			s.gaveUp(GiveUpBudgetExhausted, lastErr)
+			return lastErr
+		}
+		if err := waitRetryBackoff(attempt, s.parentCtx, lastErr, &s.backoff, s.callOpts); err != nil {
+			if errors.Is(err, errRetryDeadlineExceeded) {
+				s.gaveUp(GiveUpDeadline, lastErr)
+				return lastErr
+			}
+			s.gaveUp(GiveUpContext, err)
 			return err
 		}
 		s.callOpts.onRetryCallback(s.parentCtx, attempt, lastErr)
-		newStream, err := s.reestablishStreamAndResendBuffer(s.parentCtx)
-		if err != nil {
+		if err := s.reestablishStreamAndResendBuffer(s.parentCtx, lastErr); err != nil {
 			// Retry dial and transport errors of establishing stream as grpc doesn't retry.
 			if isRetriable(err, s.callOpts) {
+				s.backoff.pushback = pushbackFromServer(err, nil, s.callOpts)
+				if s.backoff.pushback.stop {
+					s.gaveUp(GiveUpPushback, err)
+					return err
+				}
 				continue
 			}
+			s.gaveUp(GiveUpNonRetriable, err)
 			return err
 		}
 
-		s.setStream(newStream)
-		attemptRetry, lastErr = s.receiveMsgAndIndicateRetry(m)
+		attemptRetry, s.backoff.pushback, lastErr = s.receiveMsgAndIndicateRetry(m)
 
 		if !attemptRetry {
 			return lastErr
 		}
 	}
+	s.gaveUp(GiveUpMaxAttempts, lastErr)
 	return lastErr
 }
 
-func (s *serverStreamingRetryingStream) receiveMsgAndIndicateRetry(m any) (bool, error) {
-	err := s.getStream().RecvMsg(m)
-	if err == nil || errors.Is(err, io.EOF) {
-		return false, err
+func (s *retryingClientStream) receiveMsgAndIndicateRetry(m any) (bool, serverPushback, error) {
+	stream := s.getStream()
+	err := stream.RecvMsg(m)
+	if err == nil {
+		if s.clientStreams {
+			s.committed.Store(true)
+		}
+		return false, serverPushback{}, nil
+	}
+	s.attemptEnded(err)
+	if errors.Is(err, io.EOF) {
+		return false, serverPushback{}, err
+	}
+	if !s.canRetry() {
+		logTrace(s.parentCtx, "grpc_retry stream can no longer be replayed")
+		s.gaveUp(GiveUpNotReplayable, err)
+		return false, serverPushback{}, err
 	}
 	if isContextError(err) {
 		if s.parentCtx.Err() != nil {
 			logTrace(s.parentCtx, "grpc_retry parent context error: %v", s.parentCtx.Err())
-			return false, err
+			s.gaveUp(GiveUpContext, err)
+			return false, serverPushback{}, err
 		} else if s.callOpts.perCallTimeout != 0 {
 			// We have set a perCallTimeout in the retry middleware, which would result in a context error if
 			// the deadline was exceeded, in which case try again.
 			logTrace(s.parentCtx, "grpc_retry context error from retry call")
-			return true, err
+			return true, serverPushback{}, err
 		}
 	}
-	return isRetriable(err, s.callOpts), err
+	if !isRetriable(err, s.callOpts) {
+		s.gaveUp(GiveUpNonRetriable, err)
+		return false, serverPushback{}, err
+	}
+	pushback := pushbackFromServer(err, stream.Trailer(), s.callOpts)
+	if pushback.stop {
+		logTrace(s.parentCtx, "grpc_retry server pushback asked not to retry")
+		s.gaveUp(GiveUpPushback, err)
+		return false, pushback, err
+	}
+	return true, pushback, err
 }
 
-func (s *serverStreamingRetryingStream) reestablishStreamAndResendBuffer(callCtx context.Context) (grpc.ClientStream, error) {
-	s.mu.RLock()
-	bufferedSends := s.bufferedSends
-	s.mu.RUnlock()
-	newStream, err := s.streamerCall(callCtx)
+// attemptEnded emits EventAttemptEnd once for the current stream.
+func (s *retryingClientStream) attemptEnded(err error) {
+	if s.attemptDone {
+		return
+	}
+	s.attemptDone = true
+	s.callOpts.attemptEnded(s.parentCtx, s.method, s.attempt, s.attemptStart, err)
+}
+
+// gaveUp emits EventGiveUp once for the call.
+func (s *retryingClientStream) gaveUp(reason GiveUpReason, err error) {
+	if s.gaveUpDone {
+		return
+	}
+	s.gaveUpDone = true
+	s.callOpts.gaveUp(s.parentCtx, s.method, s.attempt, reason, err)
+}
+
+func (s *retryingClientStream) reestablishStreamAndResendBuffer(callCtx context.Context, cause error) error {
+	// Block the sends until the new stream has caught up with the old one.
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+	s.attempt++
+	s.attemptStart = s.callOpts.attemptStarted(callCtx, s.method, s.attempt, cause)
+	s.attemptDone = false
+	newStream, err := s.streamerCall(callCtx, s.attempt)
 	if err != nil {
 		logTrace(callCtx, "grpc_retry failed redialing new stream: %v", err)
-		return nil, err
+		s.attemptEnded(err)
+		return err
 	}
-	for _, msg := range bufferedSends {
+	s.setStream(newStream)
+	s.resumeLocked(callCtx)
+	for _, msg := range s.bufferedSends {
 		if err := newStream.SendMsg(msg); err != nil {
+			if errors.Is(err, io.EOF) {
+				// The new stream failed already, its status will be returned by the next RecvMsg.
+				return nil
+			}
 			logTrace(callCtx, "grpc_retry failed resending message: %v", err)
-			return nil, err
+			return err
 		}
 	}
-	if err := newStream.CloseSend(); err != nil {
-		logTrace(callCtx, "grpc_retry failed CloseSend on new stream %v", err)
-		return nil, err
+	if s.wasClosedSend {
+		if err := newStream.CloseSend(); err != nil {
+			logTrace(callCtx, "grpc_retry failed CloseSend on new stream %v", err)
+			return err
+		}
 	}
-	return newStream, nil
+	return nil
 }
 
-func waitRetryBackoff(attempt uint, parentCtx context.Context, callOpts *options) error {
+// AttemptFromIncomingContext returns the attempt number the client-side retry interceptor set in the
+// `x-retry-attempt` header of the call handled by the server. Attempt 0 (the original call) does not carry
+// the header, so ok is false for it as well as for calls made without the retry interceptor.
+//
+// Servers can use it to detect retried and hedged copies of a call, e.g. to deprioritize them or to skip
+// side effects that already happened for the original.
+func AttemptFromIncomingContext(ctx context.Context) (attempt uint, ok bool) {
+	vals := grpcMetadata.ValueFromIncomingContext(ctx, AttemptMetadataKey)
+	if len(vals) == 0 {
+		return 0, false
+	}
+	a, err := strconv.ParseUint(vals[0], 10, 0)
+	if err != nil {
+		return 0, false
+	}
+	return uint(a), true
+}
+
+// waitRetryBackoff waits before the given attempt, for the server pushback if any or else for the backoff of the
+// options. It returns errRetryDeadlineExceeded without waiting if the attempt would start past the retry deadline.
+func waitRetryBackoff(attempt uint, parentCtx context.Context, lastErr error, bo *backoffState, callOpts *options) error {
 	var waitTime time.Duration = 0
 	if attempt > 0 {
-		waitTime = callOpts.backoffFunc(parentCtx, attempt)
+		if bo.pushback.set {
+			logTrace(parentCtx, "grpc_retry attempt: %d, server pushback for %v", attempt, bo.pushback.delay)
+			waitTime = bo.pushback.delay
+		} else {
+			waitTime = callOpts.backoffFunc(parentCtx, attempt, bo.prevWait, lastErr)
+		}
+		bo.prevWait = waitTime
+		if bo.exceeds(waitTime) {
+			logTrace(parentCtx, "grpc_retry attempt: %d, backoff for %v would exceed the retry deadline", attempt, waitTime)
+			return errRetryDeadlineExceeded
+		}
 	}
 	if waitTime > 0 {
 		logTrace(parentCtx, "grpc_retry attempt: %d, backoff for %v", attempt, waitTime)
@@ -285,12 +523,12 @@ func isContextError(err error) bool {
 	return code == codes.DeadlineExceeded || code == codes.Canceled
 }
 
-func perCallContext(parentCtx context.Context, callOpts *options, attempt uint) (context.Context, context.CancelFunc) {
+func perCallContext(parentCtx context.Context, timeout time.Duration, callOpts *options, attempt uint) (context.Context, context.CancelFunc) {
 	cancel := context.CancelFunc(func() {})
 
 	ctx := parentCtx
-	if callOpts.perCallTimeout != 0 {
-		ctx, cancel = context.WithTimeout(ctx, callOpts.perCallTimeout)
+	if timeout != 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
 	}
 	if attempt > 0 && callOpts.includeHeader {
 		mdClone := metadata.ExtractOutgoing(ctx).Clone().Set(AttemptMetadataKey, fmt.Sprintf("%d", attempt))
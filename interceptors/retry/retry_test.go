@@ -21,6 +21,7 @@ import (
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
 var (
@@ -91,6 +92,13 @@ func (s *failingService) PingStream(stream testpb.TestService_PingStreamServer)
 	return s.TestServiceServer.PingStream(stream)
 }
 
+func (s *failingService) PingClientStream(stream testpb.TestService_PingClientStreamServer) error {
+	if err := s.maybeFailRequest(); err != nil {
+		return err
+	}
+	return s.TestServiceServer.PingClientStream(stream)
+}
+
 func TestRetrySuite(t *testing.T) {
 	service := &failingService{
 		TestServiceServer: &testpb.TestPingService{},
@@ -296,6 +304,52 @@ func (s *RetrySuite) TestServerStream_CallRetrySucceeds() {
 	<-restarted
 }
 
+func (s *RetrySuite) TestClientStream_SucceedsOnRetriableError() {
+	s.srv.resetFailingConfiguration(3, codes.DataLoss, noSleep) // see retriable_errors
+	stream, err := s.Client.PingClientStream(s.SimpleCtx())
+	s.Require().NoError(err, "establishing the stream should succeed")
+	for i := 0; i < 5; i++ {
+		s.Require().NoError(stream.Send(&testpb.PingClientStreamRequest{Value: "something"}), "sends should not fail while retry is possible")
+	}
+	resp, err := stream.CloseAndRecv()
+	s.Require().NoError(err, "the third stream should succeed")
+	s.Require().EqualValues(5, resp.Counter, "all messages should have been replayed")
+	s.Require().EqualValues(3, s.srv.requestCount(), "three streams should have been made")
+}
+
+func (s *RetrySuite) TestClientStream_FailsWhenReplayBufferOverflows() {
+	s.srv.resetFailingConfiguration(3, codes.DataLoss, noSleep) // see retriable_errors
+	stream, err := s.Client.PingClientStream(s.SimpleCtx(), WithMaxReplayMessages(2))
+	s.Require().NoError(err, "establishing the stream should succeed")
+	for i := 0; i < 3; i++ {
+		if err := stream.Send(&testpb.PingClientStreamRequest{Value: "something"}); err != nil {
+			s.Require().ErrorIs(err, io.EOF)
+			break
+		}
+	}
+	_, err = stream.CloseAndRecv()
+	s.Require().Equal(codes.DataLoss, status.Code(err), "the stream should not be retried")
+	s.Require().EqualValues(1, s.srv.requestCount(), "one stream should have been made")
+}
+
+func (s *RetrySuite) TestBidiStream_SucceedsOnRetriableError() {
+	s.srv.resetFailingConfiguration(3, codes.DataLoss, noSleep) // see retriable_errors
+	stream, err := s.Client.PingStream(s.SimpleCtx())
+	s.Require().NoError(err, "establishing the stream should succeed")
+	for i := 0; i < 3; i++ {
+		s.Require().NoError(stream.Send(testpb.GoodPingStream), "sends should not fail while retry is possible")
+	}
+	s.Require().NoError(stream.CloseSend())
+	for i := 0; i < 3; i++ {
+		pong, err := stream.Recv()
+		s.Require().NoError(err, "the third stream should succeed")
+		s.Require().EqualValues(i, pong.Counter, "responses should come from the replayed messages")
+	}
+	_, err = stream.Recv()
+	s.Require().ErrorIs(err, io.EOF)
+	s.Require().EqualValues(3, s.srv.requestCount(), "three streams should have been made")
+}
+
 func (s *RetrySuite) assertPingListWasCorrect(stream testpb.TestService_PingListClient) {
 	s.T().Helper()
 
@@ -466,6 +520,114 @@ func (s *failingClientStream) RecvMsg(m any) error {
 	return s.RecvMsgErr
 }
 
+// replayClientStream fails the first RecvMsg after a successful one with RecvMsgErr, recording the messages sent.
+type replayClientStream struct {
+	failingClientStream
+	sent      []any
+	closed    bool
+	succeeded bool
+}
+
+func (s *replayClientStream) SendMsg(m any) error {
+	s.sent = append(s.sent, m)
+	return nil
+}
+
+func (s *replayClientStream) CloseSend() error {
+	s.closed = true
+	return nil
+}
+
+func (s *replayClientStream) RecvMsg(m any) error {
+	if s.succeeded {
+		return s.RecvMsgErr
+	}
+	s.succeeded = true
+	return nil
+}
+
+func TestStreamClientInterceptor_ClientStreamsReplay(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+	for _, tcase := range []struct {
+		name            string
+		opts            []CallOption
+		receiveFirst    bool
+		expectedStreams int
+	}{
+		{name: "replays sent messages", expectedStreams: 2},
+		{name: "message limit disables retries", opts: []CallOption{WithMaxReplayMessages(2)}, expectedStreams: 1},
+		{name: "byte limit disables retries", opts: []CallOption{WithMaxReplayBytes(proto.Size(testpb.GoodPingStream) * 2)}, expectedStreams: 1},
+		{name: "response commits the stream", receiveFirst: true, expectedStreams: 1},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			var streams []*replayClientStream
+			streamer := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+				stream := &replayClientStream{failingClientStream: failingClientStream{RecvMsgErr: unavailable}}
+				// Only the first stream fails before a response.
+				stream.succeeded = len(streams) == 0
+				streams = append(streams, stream)
+				return stream, nil
+			}
+			interceptor := StreamClientInterceptor(append([]CallOption{WithMax(3), WithBackoff(BackoffLinear(0))}, tcase.opts...)...)
+			stream, err := interceptor(context.Background(), &grpc.StreamDesc{ClientStreams: true, ServerStreams: true}, nil, "/test/PingStream", streamer)
+			require.NoError(t, err)
+
+			if tcase.receiveFirst {
+				streams[0].succeeded = false
+				require.NoError(t, stream.RecvMsg(&testpb.PingStreamResponse{}))
+			}
+			for i := 0; i < 3; i++ {
+				require.NoError(t, stream.SendMsg(testpb.GoodPingStream))
+			}
+			require.NoError(t, stream.CloseSend())
+
+			err = stream.RecvMsg(&testpb.PingStreamResponse{})
+			require.Len(t, streams, tcase.expectedStreams)
+			if tcase.expectedStreams == 1 {
+				require.ErrorIs(t, err, unavailable)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, streams[1].sent, 3, "all messages should have been replayed")
+			require.True(t, streams[1].closed, "the new stream should have been closed for sending")
+		})
+	}
+}
+
+func TestStreamClientInterceptor_DefaultReplayLimit(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+	large := &testpb.PingRequest{Value: strings.Repeat("a", DefaultMaxReplayBytes)}
+	for _, tcase := range []struct {
+		name            string
+		clientStreams   bool
+		opts            []CallOption
+		expectedStreams int
+	}{
+		{name: "server stream has no default limit", expectedStreams: 2},
+		{name: "server stream with explicit limit", opts: []CallOption{WithMaxReplayBytes(DefaultMaxReplayBytes)}, expectedStreams: 1},
+		{name: "client stream has the default limit", clientStreams: true, expectedStreams: 1},
+		{name: "client stream without limit", clientStreams: true, opts: []CallOption{WithMaxReplayBytes(0)}, expectedStreams: 2},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			var streams []*replayClientStream
+			streamer := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+				stream := &replayClientStream{failingClientStream: failingClientStream{RecvMsgErr: unavailable}}
+				stream.succeeded = len(streams) == 0
+				streams = append(streams, stream)
+				return stream, nil
+			}
+			interceptor := StreamClientInterceptor(append([]CallOption{WithMax(3), WithBackoff(BackoffLinear(0))}, tcase.opts...)...)
+			stream, err := interceptor(context.Background(), &grpc.StreamDesc{ClientStreams: tcase.clientStreams, ServerStreams: true}, nil, "/test/PingList", streamer)
+			require.NoError(t, err)
+			require.NoError(t, stream.SendMsg(large))
+			require.NoError(t, stream.CloseSend())
+
+			_ = stream.RecvMsg(&testpb.PingListResponse{})
+			require.Len(t, streams, tcase.expectedStreams)
+		})
+	}
+}
+
 func TestStreamClientInterceptorAttemptMetadata(t *testing.T) {
 	retryCount := 5
 	attempt := 0
@@ -0,0 +1,222 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package retry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+)
+
+// maxServiceConfigAttempts is the limit gRPC puts on maxAttempts of retry and hedging policies.
+const maxServiceConfigAttempts = 5
+
+// ServiceConfig holds the retry and hedging policies of a gRPC service config, see
+// https://github.com/grpc/grpc/blob/master/doc/service_config.md, and turns them into the CallOptions
+// of every method. Use it with `WithServiceConfig`.
+type ServiceConfig struct {
+	// methodOptions maps "service/method", "service/" and "/" (the default) names to their options.
+	methodOptions map[string][]CallOption
+}
+
+type jsonServiceConfig struct {
+	MethodConfig []struct {
+		Name []struct {
+			Service string `json:"service"`
+			Method  string `json:"method"`
+		} `json:"name"`
+		RetryPolicy   *jsonRetryPolicy   `json:"retryPolicy"`
+		HedgingPolicy *jsonHedgingPolicy `json:"hedgingPolicy"`
+	} `json:"methodConfig"`
+}
+
+type jsonRetryPolicy struct {
+	MaxAttempts          uint         `json:"maxAttempts"`
+	InitialBackoff       jsonDuration `json:"initialBackoff"`
+	MaxBackoff           jsonDuration `json:"maxBackoff"`
+	BackoffMultiplier    float64      `json:"backoffMultiplier"`
+	RetryableStatusCodes []codes.Code `json:"retryableStatusCodes"`
+}
+
+type jsonHedgingPolicy struct {
+	MaxAttempts         uint         `json:"maxAttempts"`
+	HedgingDelay        jsonDuration `json:"hedgingDelay"`
+	NonFatalStatusCodes []codes.Code `json:"nonFatalStatusCodes"`
+}
+
+// jsonDuration is a google.protobuf.Duration in its JSON form, e.g. "1.5s".
+type jsonDuration time.Duration
+
+func (d *jsonDuration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("duration should be a string: %w", err)
+	}
+	if !strings.HasSuffix(s, "s") {
+		return fmt.Errorf("malformed duration %q", s)
+	}
+	dur, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("malformed duration %q: %w", s, err)
+	}
+	*d = jsonDuration(dur)
+	return nil
+}
+
+// ParseServiceConfig parses the retry and hedging policies of a gRPC service config in its JSON form. Other
+// parts of the service config are ignored.
+//
+// As in gRPC, maxAttempts above 5 are treated as 5 and the retries back off for a random time between 0 and
+// min(initialBackoff * backoffMultiplier^(attempt-1), maxBackoff). Methods are matched by their full name, then
+// by their service and then by the default method config (with an empty name), if any.
+func ParseServiceConfig(data []byte) (*ServiceConfig, error) {
+	var raw jsonServiceConfig
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("grpc_retry: invalid service config: %w", err)
+	}
+	cfg := &ServiceConfig{methodOptions: map[string][]CallOption{}}
+	for i, mc := range raw.MethodConfig {
+		if mc.RetryPolicy != nil && mc.HedgingPolicy != nil {
+			return nil, fmt.Errorf("grpc_retry: invalid service config: methodConfig[%d] has both retryPolicy and hedgingPolicy", i)
+		}
+		var opts []CallOption
+		var err error
+		switch {
+		case mc.RetryPolicy != nil:
+			opts, err = mc.RetryPolicy.callOptions()
+		case mc.HedgingPolicy != nil:
+			opts, err = mc.HedgingPolicy.callOptions()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("grpc_retry: invalid service config: methodConfig[%d]: %w", i, err)
+		}
+		if len(mc.Name) == 0 {
+			// A method config without any name is the default one.
+			if err := cfg.add("/", opts); err != nil {
+				return nil, fmt.Errorf("grpc_retry: invalid service config: methodConfig[%d]: %w", i, err)
+			}
+		}
+		for _, n := range mc.Name {
+			if n.Service == "" && n.Method != "" {
+				return nil, fmt.Errorf("grpc_retry: invalid service config: methodConfig[%d]: method %q without a service", i, n.Method)
+			}
+			if err := cfg.add(n.Service+"/"+n.Method, opts); err != nil {
+				return nil, fmt.Errorf("grpc_retry: invalid service config: methodConfig[%d]: %w", i, err)
+			}
+		}
+	}
+	return cfg, nil
+}
+
+// LoadServiceConfigFile reads and parses the gRPC service config in the given file, see `ParseServiceConfig`.
+func LoadServiceConfigFile(path string) (*ServiceConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("grpc_retry: reading service config: %w", err)
+	}
+	return ParseServiceConfig(data)
+}
+
+func (c *ServiceConfig) add(name string, opts []CallOption) error {
+	if _, ok := c.methodOptions[name]; ok {
+		return fmt.Errorf("duplicate name %q", name)
+	}
+	c.methodOptions[name] = opts
+	return nil
+}
+
+// CallOptions returns the options of the policy that applies to the given full method name (e.g.
+// "/package.Service/Method"), or nil if there is none.
+func (c *ServiceConfig) CallOptions(fullMethod string) []CallOption {
+	if c == nil {
+		return nil
+	}
+	name := strings.TrimPrefix(fullMethod, "/")
+	if opts, ok := c.methodOptions[name]; ok {
+		return opts
+	}
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		if opts, ok := c.methodOptions[name[:i+1]]; ok {
+			return opts
+		}
+	}
+	return c.methodOptions["/"]
+}
+
+// WithServiceConfig sets the gRPC service config the retry and hedging policies of every method are taken from.
+//
+// The policy of a method overrides the options of the interceptor, while the options passed to a call override
+// the policy. Methods without a policy in the service config use the options of the interceptor.
+func WithServiceConfig(cfg *ServiceConfig) CallOption {
+	return CallOption{applyFunc: func(o *options) {
+		o.serviceConfig = cfg
+	}}
+}
+
+// callOptionsForMethod applies the service config policy of the method (if any) and then the call options
+// on top of the interceptor options.
+func callOptionsForMethod(intOpts *options, method string, retryOpts []CallOption) *options {
+	callOpts := reuseOrNewWithCallOptions(intOpts, retryOpts)
+	methodOpts := callOpts.serviceConfig.CallOptions(method)
+	if len(methodOpts) == 0 {
+		return callOpts
+	}
+	return reuseOrNewWithCallOptions(intOpts, append(methodOpts[:len(methodOpts):len(methodOpts)], retryOpts...))
+}
+
+func (p *jsonRetryPolicy) callOptions() ([]CallOption, error) {
+	switch {
+	case p.MaxAttempts < 2:
+		return nil, fmt.Errorf("retryPolicy.maxAttempts must be greater than 1, got %d", p.MaxAttempts)
+	case p.InitialBackoff <= 0:
+		return nil, fmt.Errorf("retryPolicy.initialBackoff must be greater than 0")
+	case p.MaxBackoff <= 0:
+		return nil, fmt.Errorf("retryPolicy.maxBackoff must be greater than 0")
+	case p.BackoffMultiplier <= 0:
+		return nil, fmt.Errorf("retryPolicy.backoffMultiplier must be greater than 0")
+	case len(p.RetryableStatusCodes) == 0:
+		return nil, fmt.Errorf("retryPolicy.retryableStatusCodes must not be empty")
+	}
+	return []CallOption{
+		WithMax(min(p.MaxAttempts, maxServiceConfigAttempts)),
+		WithBackoff(backoffServiceConfig(time.Duration(p.InitialBackoff), time.Duration(p.MaxBackoff), p.BackoffMultiplier)),
+		WithCodes(p.RetryableStatusCodes...),
+		WithHedgingDelay(0),
+	}, nil
+}
+
+func (p *jsonHedgingPolicy) callOptions() ([]CallOption, error) {
+	if p.MaxAttempts < 2 {
+		return nil, fmt.Errorf("hedgingPolicy.maxAttempts must be greater than 1, got %d", p.MaxAttempts)
+	}
+	delay := time.Duration(p.HedgingDelay)
+	if delay <= 0 {
+		// All the attempts are sent at once.
+		delay = time.Nanosecond
+	}
+	return []CallOption{
+		WithMax(min(p.MaxAttempts, maxServiceConfigAttempts)),
+		WithHedgingDelay(delay),
+		WithCodes(p.NonFatalStatusCodes...),
+	}, nil
+}
+
+// backoffServiceConfig is the exponential backoff with full jitter of gRPC retry policies: the wait before the
+// n-th retry is random between 0 and min(initial * multiplier^(n-1), maxBackoff).
+func backoffServiceConfig(initial, maxBackoff time.Duration, multiplier float64) BackoffFunc {
+	return func(ctx context.Context, attempt uint) time.Duration {
+		if attempt == 0 {
+			attempt = 1
+		}
+		upper := math.Min(float64(initial)*math.Pow(multiplier, float64(attempt-1)), float64(maxBackoff))
+		return time.Duration(rand.Float64() * upper)
+	}
+}
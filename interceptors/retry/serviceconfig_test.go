@@ -0,0 +1,135 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package retry
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/testing/testpb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const testServiceConfig = `{
+  "loadBalancingConfig": [{"round_robin": {}}],
+  "methodConfig": [
+    {
+      "name": [{"service": "testing.testpb.v1.TestService", "method": "Ping"}],
+      "retryPolicy": {
+        "maxAttempts": 4,
+        "initialBackoff": "0.001s",
+        "maxBackoff": "0.002s",
+        "backoffMultiplier": 2,
+        "retryableStatusCodes": ["UNAVAILABLE", "ABORTED"]
+      }
+    },
+    {
+      "name": [{"service": "testing.testpb.v1.TestService"}],
+      "retryPolicy": {
+        "maxAttempts": 10,
+        "initialBackoff": "0.001s",
+        "maxBackoff": "0.001s",
+        "backoffMultiplier": 1,
+        "retryableStatusCodes": [14]
+      }
+    },
+    {
+      "name": [{}],
+      "hedgingPolicy": {
+        "maxAttempts": 3,
+        "hedgingDelay": "0.5s",
+        "nonFatalStatusCodes": ["UNAVAILABLE"]
+      }
+    }
+  ]
+}`
+
+func TestParseServiceConfig(t *testing.T) {
+	cfg, err := ParseServiceConfig([]byte(testServiceConfig))
+	require.NoError(t, err)
+
+	opts := reuseOrNewWithCallOptions(defaultOptions, cfg.CallOptions("/testing.testpb.v1.TestService/Ping"))
+	require.EqualValues(t, 4, opts.max)
+	require.Zero(t, opts.hedgingDelay)
+	require.True(t, opts.retriableFunc(status.Error(codes.Aborted, "")))
+	require.False(t, opts.retriableFunc(status.Error(codes.ResourceExhausted, "")))
+	for attempt := uint(1); attempt < 5; attempt++ {
+		require.LessOrEqual(t, opts.backoffFunc(context.Background(), attempt, 0, nil), 2*time.Millisecond)
+	}
+
+	opts = reuseOrNewWithCallOptions(defaultOptions, cfg.CallOptions("/testing.testpb.v1.TestService/PingList"))
+	require.EqualValues(t, maxServiceConfigAttempts, opts.max, "maxAttempts should be capped")
+	require.True(t, opts.retriableFunc(status.Error(codes.Unavailable, "")))
+	require.False(t, opts.retriableFunc(status.Error(codes.Aborted, "")))
+
+	opts = reuseOrNewWithCallOptions(defaultOptions, cfg.CallOptions("/other.Service/Method"))
+	require.EqualValues(t, 3, opts.max)
+	require.Equal(t, 500*time.Millisecond, opts.hedgingDelay)
+
+	require.Nil(t, (*ServiceConfig)(nil).CallOptions("/other.Service/Method"))
+}
+
+func TestParseServiceConfig_Invalid(t *testing.T) {
+	for _, tcase := range []struct {
+		name   string
+		config string
+	}{
+		{name: "malformed json", config: `{"methodConfig": [`},
+		{name: "single attempt", config: `{"methodConfig": [{"retryPolicy": {"maxAttempts": 1, "initialBackoff": "1s", "maxBackoff": "1s", "backoffMultiplier": 1, "retryableStatusCodes": ["UNAVAILABLE"]}}]}`},
+		{name: "malformed duration", config: `{"methodConfig": [{"retryPolicy": {"maxAttempts": 2, "initialBackoff": "1m", "maxBackoff": "1s", "backoffMultiplier": 1, "retryableStatusCodes": ["UNAVAILABLE"]}}]}`},
+		{name: "unknown code", config: `{"methodConfig": [{"retryPolicy": {"maxAttempts": 2, "initialBackoff": "1s", "maxBackoff": "1s", "backoffMultiplier": 1, "retryableStatusCodes": ["NOT_A_CODE"]}}]}`},
+		{name: "no codes", config: `{"methodConfig": [{"retryPolicy": {"maxAttempts": 2, "initialBackoff": "1s", "maxBackoff": "1s", "backoffMultiplier": 1}}]}`},
+		{name: "both policies", config: `{"methodConfig": [{"retryPolicy": {"maxAttempts": 2, "initialBackoff": "1s", "maxBackoff": "1s", "backoffMultiplier": 1, "retryableStatusCodes": ["UNAVAILABLE"]}, "hedgingPolicy": {"maxAttempts": 2}}]}`},
+		{name: "method without service", config: `{"methodConfig": [{"name": [{"method": "Ping"}]}]}`},
+		{name: "duplicate name", config: `{"methodConfig": [{"name": [{"service": "a"}]}, {"name": [{"service": "a"}]}]}`},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			_, err := ParseServiceConfig([]byte(tcase.config))
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestLoadServiceConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "service_config.json")
+	require.NoError(t, os.WriteFile(path, []byte(testServiceConfig), 0o600))
+	cfg, err := LoadServiceConfigFile(path)
+	require.NoError(t, err)
+	require.Len(t, cfg.CallOptions("/testing.testpb.v1.TestService/Ping"), 4)
+
+	_, err = LoadServiceConfigFile(filepath.Join(t.TempDir(), "missing.json"))
+	require.Error(t, err)
+}
+
+func TestUnaryClientInterceptor_ServiceConfigPrecedence(t *testing.T) {
+	cfg, err := ParseServiceConfig([]byte(testServiceConfig))
+	require.NoError(t, err)
+
+	calls := 0
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		calls++
+		return status.Error(codes.Aborted, "aborted")
+	}
+	interceptor := UnaryClientInterceptor(WithMax(2), WithServiceConfig(cfg))
+
+	err = interceptor(context.Background(), "/testing.testpb.v1.TestService/Ping", testpb.GoodPing, &testpb.PingResponse{}, nil, invoker)
+	require.Equal(t, codes.Aborted, status.Code(err))
+	require.Equal(t, 4, calls, "method policy should override interceptor options")
+
+	calls = 0
+	err = interceptor(context.Background(), "/testing.testpb.v1.TestService/Ping", testpb.GoodPing, &testpb.PingResponse{}, nil, invoker, WithMax(3))
+	require.Equal(t, codes.Aborted, status.Code(err))
+	require.Equal(t, 3, calls, "call options should override method policy")
+
+	calls = 0
+	err = interceptor(context.Background(), "/testing.testpb.v1.TestService/PingEmpty", testpb.GoodPing, &testpb.PingResponse{}, nil, invoker)
+	require.Equal(t, codes.Aborted, status.Code(err))
+	require.Equal(t, 1, calls, "service policy does not retry Aborted")
+}
@@ -0,0 +1,10 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+/*
+Package kit adapts a github.com/go-kit/log logger to the logging.Logger of interceptors/logging.
+
+It is a separate Go module, so that only the users of go-kit depend on it. Import it with a prefix, e.g.
+`grpckit "github.com/grpc-ecosystem/go-grpc-middleware/providers/kit"`.
+*/
+package kit
@@ -0,0 +1,26 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package kit_test
+
+import (
+	"os"
+
+	"github.com/go-kit/log"
+	grpckit "github.com/grpc-ecosystem/go-grpc-middleware/providers/kit"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
+	"google.golang.org/grpc"
+)
+
+func ExampleInterceptorLogger() {
+	logger := log.NewLogfmtLogger(os.Stderr)
+
+	_ = grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			logging.UnaryServerInterceptor(grpckit.InterceptorLogger(logger)),
+		),
+		grpc.ChainStreamInterceptor(
+			logging.StreamServerInterceptor(grpckit.InterceptorLogger(logger)),
+		),
+	)
+}
@@ -0,0 +1,62 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package kit
+
+import (
+	"context"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// InterceptorLogger adapts l to the logging.Logger interface.
+//
+// The fields bound to l with log.With are logged too, and the level filters set with level.NewFilter apply. Log
+// levels are mapped to the closest go-kit level below, e.g. any level between logging.LevelWarn and
+// logging.LevelError is logged as a warning. Field values are kept typed, except for proto messages, which are
+// encoded as JSON.
+func InterceptorLogger(l log.Logger) logging.Logger {
+	return logging.LoggerFunc(func(_ context.Context, lvl logging.Level, msg string, fields ...any) {
+		kv := make([]any, 0, len(fields)+2)
+		kv = append(kv, "msg", msg)
+		i := logging.Fields(fields).Iterator()
+		for i.Next() {
+			k, v := i.At()
+			if m, ok := v.(proto.Message); ok {
+				v = protoJSON{m}
+			}
+			kv = append(kv, k, v)
+		}
+		_ = kitLevel(l, lvl).Log(kv...)
+	})
+}
+
+func kitLevel(l log.Logger, lvl logging.Level) log.Logger {
+	switch {
+	case lvl < logging.LevelInfo:
+		return level.Debug(l)
+	case lvl < logging.LevelWarn:
+		return level.Info(l)
+	case lvl < logging.LevelError:
+		return level.Warn(l)
+	default:
+		return level.Error(l)
+	}
+}
+
+// protoJSON encodes a proto message as JSON with the JSON logger, and as text with the logfmt logger.
+type protoJSON struct {
+	proto.Message
+}
+
+func (p protoJSON) MarshalJSON() ([]byte, error) {
+	return protojson.Marshal(p.Message)
+}
+
+func (p protoJSON) MarshalText() ([]byte, error) {
+	return protojson.Marshal(p.Message)
+}
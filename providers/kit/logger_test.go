@@ -0,0 +1,74 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package kit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/testing/testpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func lines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+
+	var out []map[string]any
+	for _, l := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if l == "" {
+			continue
+		}
+		m := map[string]any{}
+		require.NoError(t, json.Unmarshal([]byte(l), &m))
+		out = append(out, m)
+	}
+	return out
+}
+
+func TestInterceptorLogger_Levels(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := InterceptorLogger(log.NewJSONLogger(buf))
+
+	for _, lvl := range []logging.Level{logging.LevelDebug - 1, logging.LevelInfo, logging.LevelWarn + 1, logging.LevelError, logging.LevelError + 4} {
+		logger.Log(context.Background(), lvl, "msg")
+	}
+	var got []any
+	for _, l := range lines(t, buf) {
+		got = append(got, l["level"])
+	}
+	assert.Equal(t, []any{"debug", "info", "warn", "error", "error"}, got)
+}
+
+func TestInterceptorLogger_Fields(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := level.NewFilter(log.With(log.NewJSONLogger(buf), "request_id", "abc"), level.AllowInfo())
+	logger := InterceptorLogger(l)
+
+	logger.Log(context.Background(), logging.LevelDebug, "disabled", "key", "value")
+	logger.Log(context.Background(), logging.LevelInfo, "finished call",
+		"grpc.code", "OK",
+		"count", 3,
+		"grpc.request.content", &testpb.PingRequest{Value: "something"},
+	)
+
+	got := lines(t, buf)
+	require.Len(t, got, 1)
+	assert.Equal(t, "finished call", got[0]["msg"])
+	assert.Equal(t, "abc", got[0]["request_id"])
+	assert.Equal(t, "OK", got[0]["grpc.code"])
+	assert.InDelta(t, 3, got[0]["count"], 0)
+	assert.Equal(t, map[string]any{"value": "something"}, got[0]["grpc.request.content"])
+
+	buf.Reset()
+	InterceptorLogger(log.NewLogfmtLogger(buf)).Log(context.Background(), logging.LevelInfo, "msg",
+		"grpc.request.content", &testpb.PingRequest{Value: "something"})
+	assert.Contains(t, buf.String(), `grpc.request.content="{\"value\":\"something\"}"`)
+}
@@ -0,0 +1,11 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+/*
+Package logrus adapts a github.com/sirupsen/logrus logger to the logging.Logger of interceptors/logging.
+
+It is a separate Go module, so that only the users of logrus depend on it. Import it with a prefix to avoid the
+collision with the logrus package, e.g.
+`grpclogrus "github.com/grpc-ecosystem/go-grpc-middleware/providers/logrus"`.
+*/
+package logrus
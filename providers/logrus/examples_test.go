@@ -0,0 +1,24 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package logrus_test
+
+import (
+	grpclogrus "github.com/grpc-ecosystem/go-grpc-middleware/providers/logrus"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+)
+
+func ExampleInterceptorLogger() {
+	logger := logrus.New()
+
+	_ = grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			logging.UnaryServerInterceptor(grpclogrus.InterceptorLogger(logger)),
+		),
+		grpc.ChainStreamInterceptor(
+			logging.StreamServerInterceptor(grpclogrus.InterceptorLogger(logger)),
+		),
+	)
+}
@@ -0,0 +1,23 @@
+module github.com/grpc-ecosystem/go-grpc-middleware/providers/logrus
+
+go 1.24.0
+
+require (
+	github.com/grpc-ecosystem/go-grpc-middleware/v2 v2.1.0
+	github.com/sirupsen/logrus v1.9.0
+	github.com/stretchr/testify v1.11.1
+	google.golang.org/grpc v1.74.2
+	google.golang.org/protobuf v1.36.10
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/net v0.47.0 // indirect
+	golang.org/x/sys v0.38.0 // indirect
+	golang.org/x/text v0.31.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20250603155806-513f23925822 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/grpc-ecosystem/go-grpc-middleware/v2 => ../../
@@ -0,0 +1,64 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package logrus
+
+import (
+	"context"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// InterceptorLogger adapts l to the logging.Logger interface.
+//
+// l can be a *logrus.Logger or a *logrus.Entry, in which case the fields bound to the entry are logged too. The
+// context of the call is set on the entries, so that it is available to the logrus hooks. Log levels are mapped to
+// the closest logrus level below, e.g. any level between logging.LevelWarn and logging.LevelError is logged as a
+// warning. Field values are kept typed, except for proto messages, which are encoded as JSON.
+func InterceptorLogger(l logrus.FieldLogger) logging.Logger {
+	return logging.LoggerFunc(func(ctx context.Context, lvl logging.Level, msg string, fields ...any) {
+		f := make(logrus.Fields, len(fields)/2)
+		i := logging.Fields(fields).Iterator()
+		for i.Next() {
+			k, v := i.At()
+			if m, ok := v.(proto.Message); ok {
+				v = protoJSON{m}
+			}
+			f[k] = v
+		}
+		l.WithFields(f).WithContext(ctx).Log(logrusLevel(lvl), msg)
+	})
+}
+
+func logrusLevel(lvl logging.Level) logrus.Level {
+	switch {
+	case lvl < logging.LevelInfo:
+		return logrus.DebugLevel
+	case lvl < logging.LevelWarn:
+		return logrus.InfoLevel
+	case lvl < logging.LevelError:
+		return logrus.WarnLevel
+	default:
+		return logrus.ErrorLevel
+	}
+}
+
+// protoJSON encodes a proto message as JSON with the JSON formatter, and as a string with the text formatter.
+type protoJSON struct {
+	proto.Message
+}
+
+func (p protoJSON) MarshalJSON() ([]byte, error) {
+	return protojson.Marshal(p.Message)
+}
+
+func (p protoJSON) String() string {
+	b, err := protojson.Marshal(p.Message)
+	if err != nil {
+		return err.Error()
+	}
+	return string(b)
+}
@@ -0,0 +1,65 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package logrus
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/testing/testpb"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type ctxKey struct{}
+
+func TestInterceptorLogger_Levels(t *testing.T) {
+	l, hook := test.NewNullLogger()
+	l.SetLevel(logrus.DebugLevel)
+	logger := InterceptorLogger(l)
+
+	for _, lvl := range []logging.Level{logging.LevelDebug - 1, logging.LevelInfo, logging.LevelWarn + 1, logging.LevelError, logging.LevelError + 4} {
+		logger.Log(context.Background(), lvl, "msg")
+	}
+	var got []logrus.Level
+	for _, e := range hook.AllEntries() {
+		got = append(got, e.Level)
+	}
+	assert.Equal(t, []logrus.Level{logrus.DebugLevel, logrus.InfoLevel, logrus.WarnLevel, logrus.ErrorLevel, logrus.ErrorLevel}, got)
+}
+
+func TestInterceptorLogger_Fields(t *testing.T) {
+	l, hook := test.NewNullLogger()
+	entry := l.WithField("request_id", "abc")
+	logger := InterceptorLogger(entry)
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+	logger.Log(ctx, logging.LevelDebug, "disabled", "key", "value")
+	logger.Log(ctx, logging.LevelInfo, "finished call",
+		"grpc.code", "OK",
+		"count", 3,
+		"grpc.request.content", &testpb.PingRequest{Value: "something"},
+	)
+
+	require.Len(t, hook.AllEntries(), 1)
+	e := hook.LastEntry()
+	assert.Equal(t, "finished call", e.Message)
+	assert.Equal(t, "abc", e.Data["request_id"])
+	assert.Equal(t, "OK", e.Data["grpc.code"])
+	assert.Equal(t, 3, e.Data["count"])
+	assert.Equal(t, "value", e.Context.Value(ctxKey{}))
+
+	buf := &bytes.Buffer{}
+	l.SetOutput(buf)
+	l.SetFormatter(&logrus.JSONFormatter{})
+	logger.Log(ctx, logging.LevelInfo, "msg", "grpc.request.content", &testpb.PingRequest{Value: "something"})
+	out := map[string]any{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+	assert.Equal(t, map[string]any{"value": "something"}, out["grpc.request.content"])
+}
@@ -0,0 +1,120 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package prometheus
+
+import (
+	"context"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/retry"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RetryMetrics represents a collection of metrics about the attempts made by the retry interceptor
+// (interceptors/retry), to be registered on a Prometheus metrics registry.
+type RetryMetrics struct {
+	retriesTotal            *prometheus.CounterVec
+	retriedSuccessesTotal   *prometheus.CounterVec
+	giveUpsTotal            *prometheus.CounterVec
+	attemptHandledHistogram *prometheus.HistogramVec
+}
+
+type retryMetricsConfig struct {
+	counterOpts   counterOptions
+	histogramOpts histogramOptions
+}
+
+// RetryMetricsOption configures the RetryMetrics.
+type RetryMetricsOption func(*retryMetricsConfig)
+
+// WithRetryCounterOptions sets the options of the retry counters.
+func WithRetryCounterOptions(opts ...CounterOption) RetryMetricsOption {
+	return func(o *retryMetricsConfig) {
+		o.counterOpts = opts
+	}
+}
+
+// WithRetryAttemptHistogramOptions sets the options of the attempt handling time histogram.
+func WithRetryAttemptHistogramOptions(opts ...HistogramOption) RetryMetricsOption {
+	return func(o *retryMetricsConfig) {
+		o.histogramOpts = opts
+	}
+}
+
+// NewRetryMetrics returns a new RetryMetrics object. Pass its EventHandler to the retry interceptor with
+// retry.WithEventHandler.
+// NOTE: Remember to register RetryMetrics object using prometheus registry
+// e.g. prometheus.MustRegister(myRetryMetrics).
+func NewRetryMetrics(opts ...RetryMetricsOption) *RetryMetrics {
+	config := &retryMetricsConfig{}
+	for _, o := range opts {
+		o(config)
+	}
+	return &RetryMetrics{
+		retriesTotal: prometheus.NewCounterVec(
+			config.counterOpts.apply(prometheus.CounterOpts{
+				Name: "grpc_client_retries_total",
+				Help: "Total number of retried (and hedged) attempts of RPCs made by the client, by code of the attempt that triggered them.",
+			}), []string{"grpc_service", "grpc_method", "grpc_code"}),
+
+		retriedSuccessesTotal: prometheus.NewCounterVec(
+			config.counterOpts.apply(prometheus.CounterOpts{
+				Name: "grpc_client_retry_successes_total",
+				Help: "Total number of RPCs that succeeded only after being retried by the client.",
+			}), []string{"grpc_service", "grpc_method"}),
+
+		giveUpsTotal: prometheus.NewCounterVec(
+			config.counterOpts.apply(prometheus.CounterOpts{
+				Name: "grpc_client_retry_give_ups_total",
+				Help: "Total number of RPCs that failed and were not retried anymore by the client, by reason.",
+			}), []string{"grpc_service", "grpc_method", "grpc_code", "reason"}),
+
+		attemptHandledHistogram: prometheus.NewHistogramVec(
+			config.histogramOpts.apply(&prometheus.HistogramOpts{
+				Name:    "grpc_client_attempt_handling_seconds",
+				Help:    "Histogram of response latency (seconds) of every attempt of the RPCs made by the client.",
+				Buckets: prometheus.DefBuckets,
+			}), []string{"grpc_service", "grpc_method", "grpc_code"}),
+	}
+}
+
+// Describe sends the super-set of all possible descriptors of metrics
+// collected by this Collector to the provided channel and returns once
+// the last descriptor has been sent.
+func (m *RetryMetrics) Describe(ch chan<- *prometheus.Desc) {
+	m.retriesTotal.Describe(ch)
+	m.retriedSuccessesTotal.Describe(ch)
+	m.giveUpsTotal.Describe(ch)
+	m.attemptHandledHistogram.Describe(ch)
+}
+
+// Collect is called by the Prometheus registry when collecting
+// metrics. The implementation sends each collected metric via the
+// provided channel and returns once the last metric has been sent.
+func (m *RetryMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.retriesTotal.Collect(ch)
+	m.retriedSuccessesTotal.Collect(ch)
+	m.giveUpsTotal.Collect(ch)
+	m.attemptHandledHistogram.Collect(ch)
+}
+
+// EventHandler returns the retry.EventHandler recording the metrics, to be used with retry.WithEventHandler.
+func (m *RetryMetrics) EventHandler() retry.EventHandler {
+	return func(_ context.Context, e retry.Event) {
+		c := interceptors.NewClientCallMeta(e.FullMethod, nil, nil)
+		switch e.Kind {
+		case retry.EventAttemptStart:
+			if e.Attempt > 0 {
+				m.retriesTotal.WithLabelValues(c.Service, c.Method, e.Code.String()).Inc()
+			}
+		case retry.EventAttemptEnd:
+			m.attemptHandledHistogram.WithLabelValues(c.Service, c.Method, e.Code.String()).Observe(e.Duration.Seconds())
+			if e.Attempt > 0 && e.Err == nil {
+				m.retriedSuccessesTotal.WithLabelValues(c.Service, c.Method).Inc()
+			}
+		case retry.EventGiveUp:
+			m.giveUpsTotal.WithLabelValues(c.Service, c.Method, e.Code.String(), string(e.Reason)).Inc()
+		}
+	}
+}
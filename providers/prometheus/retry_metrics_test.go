@@ -0,0 +1,70 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package prometheus
+
+import (
+	"testing"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/retry"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/testing/testpb"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+func TestRetryMetricsSuite(t *testing.T) {
+	m := NewRetryMetrics()
+	suite.Run(t, &RetryMetricsTestSuite{
+		InterceptorTestSuite: &testpb.InterceptorTestSuite{
+			TestService: &testpb.TestPingService{},
+			ClientOpts: []grpc.DialOption{
+				grpc.WithUnaryInterceptor(retry.UnaryClientInterceptor(
+					retry.WithMax(3),
+					retry.WithBackoff(retry.BackoffLinear(0)),
+					retry.WithCodes(codes.Unavailable),
+					retry.WithEventHandler(m.EventHandler()),
+				)),
+			},
+		},
+		retryMetrics: m,
+	})
+}
+
+type RetryMetricsTestSuite struct {
+	*testpb.InterceptorTestSuite
+	retryMetrics *RetryMetrics
+}
+
+func (s *RetryMetricsTestSuite) SetupTest() {
+	s.retryMetrics.retriesTotal.Reset()
+	s.retryMetrics.retriedSuccessesTotal.Reset()
+	s.retryMetrics.giveUpsTotal.Reset()
+	s.retryMetrics.attemptHandledHistogram.Reset()
+}
+
+func (s *RetryMetricsTestSuite) TestUnaryWithoutRetry() {
+	_, err := s.Client.PingEmpty(s.SimpleCtx(), &testpb.PingEmptyRequest{})
+	s.Require().NoError(err)
+
+	requireValue(s.T(), 0, s.retryMetrics.retriesTotal.WithLabelValues(testpb.TestServiceFullName, "PingEmpty", "OK"))
+	requireValueHistCount(s.T(), 1, s.retryMetrics.attemptHandledHistogram.WithLabelValues(testpb.TestServiceFullName, "PingEmpty", "OK"))
+}
+
+func (s *RetryMetricsTestSuite) TestUnaryGivesUpAfterRetries() {
+	_, err := s.Client.PingError(s.SimpleCtx(), &testpb.PingErrorRequest{ErrorCodeReturned: uint32(codes.Unavailable)})
+	s.Require().Error(err)
+
+	requireValue(s.T(), 2, s.retryMetrics.retriesTotal.WithLabelValues(testpb.TestServiceFullName, "PingError", "Unavailable"))
+	requireValue(s.T(), 0, s.retryMetrics.retriedSuccessesTotal.WithLabelValues(testpb.TestServiceFullName, "PingError"))
+	requireValue(s.T(), 1, s.retryMetrics.giveUpsTotal.WithLabelValues(testpb.TestServiceFullName, "PingError", "Unavailable", string(retry.GiveUpMaxAttempts)))
+	requireValueHistCount(s.T(), 3, s.retryMetrics.attemptHandledHistogram.WithLabelValues(testpb.TestServiceFullName, "PingError", "Unavailable"))
+}
+
+func (s *RetryMetricsTestSuite) TestUnaryNonRetriableError() {
+	_, err := s.Client.PingError(s.SimpleCtx(), &testpb.PingErrorRequest{ErrorCodeReturned: uint32(codes.FailedPrecondition)})
+	s.Require().Error(err)
+
+	requireValue(s.T(), 0, s.retryMetrics.retriesTotal.WithLabelValues(testpb.TestServiceFullName, "PingError", "FailedPrecondition"))
+	requireValue(s.T(), 1, s.retryMetrics.giveUpsTotal.WithLabelValues(testpb.TestServiceFullName, "PingError", "FailedPrecondition", string(retry.GiveUpNonRetriable)))
+}
@@ -0,0 +1,10 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+/*
+Package zap adapts a go.uber.org/zap logger to the logging.Logger of interceptors/logging.
+
+It is a separate Go module, so that only the users of zap depend on it. Import it with a prefix to avoid the collision
+with the zap package, e.g. `grpczap "github.com/grpc-ecosystem/go-grpc-middleware/providers/zap"`.
+*/
+package zap
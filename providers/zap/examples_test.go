@@ -0,0 +1,24 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package zap_test
+
+import (
+	grpczap "github.com/grpc-ecosystem/go-grpc-middleware/providers/zap"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+func ExampleInterceptorLogger() {
+	logger := zap.NewExample()
+
+	_ = grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			logging.UnaryServerInterceptor(grpczap.InterceptorLogger(logger)),
+		),
+		grpc.ChainStreamInterceptor(
+			logging.StreamServerInterceptor(grpczap.InterceptorLogger(logger)),
+		),
+	)
+}
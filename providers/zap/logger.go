@@ -0,0 +1,109 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package zap
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+type loggerKey struct{}
+
+// ToContext returns a copy of ctx with l, e.g. a logger with request scoped fields, which is then used instead of the
+// logger of `InterceptorLogger` for the log lines of calls with that context.
+func ToContext(ctx context.Context, l *zap.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, withCallerSkip(l))
+}
+
+// withCallerSkip skips the function of `InterceptorLogger`, to report the caller of the interceptor.
+func withCallerSkip(l *zap.Logger) *zap.Logger {
+	return l.WithOptions(zap.AddCallerSkip(1))
+}
+
+// InterceptorLogger adapts l to the logging.Logger interface.
+//
+// Log levels are mapped to the closest zap level below, e.g. any level between logging.LevelWarn and
+// logging.LevelError is logged as a warning. Strings, numbers, booleans, durations, times and errors are logged with
+// their typed zap field, and proto messages are encoded as JSON.
+func InterceptorLogger(l *zap.Logger) logging.Logger {
+	l = withCallerSkip(l)
+	return logging.LoggerFunc(func(ctx context.Context, lvl logging.Level, msg string, fields ...any) {
+		logger := l
+		if cl, ok := ctx.Value(loggerKey{}).(*zap.Logger); ok {
+			logger = cl
+		}
+		ce := logger.Check(zapLevel(lvl), msg)
+		if ce == nil {
+			return
+		}
+		ce.Write(zapFields(fields)...)
+	})
+}
+
+func zapLevel(lvl logging.Level) zapcore.Level {
+	switch {
+	case lvl < logging.LevelInfo:
+		return zapcore.DebugLevel
+	case lvl < logging.LevelWarn:
+		return zapcore.InfoLevel
+	case lvl < logging.LevelError:
+		return zapcore.WarnLevel
+	default:
+		return zapcore.ErrorLevel
+	}
+}
+
+func zapFields(fields []any) []zap.Field {
+	f := make([]zap.Field, 0, len(fields)/2)
+	i := logging.Fields(fields).Iterator()
+	for i.Next() {
+		k, v := i.At()
+		switch v := v.(type) {
+		case string:
+			f = append(f, zap.String(k, v))
+		case int:
+			f = append(f, zap.Int(k, v))
+		case int64:
+			f = append(f, zap.Int64(k, v))
+		case uint64:
+			f = append(f, zap.Uint64(k, v))
+		case float64:
+			f = append(f, zap.Float64(k, v))
+		case bool:
+			f = append(f, zap.Bool(k, v))
+		case time.Duration:
+			f = append(f, zap.Duration(k, v))
+		case time.Time:
+			f = append(f, zap.Time(k, v))
+		case error:
+			f = append(f, zap.NamedError(k, v))
+		case proto.Message:
+			f = append(f, zap.Reflect(k, protoJSON{v}))
+		case fmt.Stringer:
+			f = append(f, zap.Stringer(k, v))
+		default:
+			f = append(f, zap.Any(k, v))
+		}
+	}
+	return f
+}
+
+// protoJSON encodes a proto message with protojson, only if the log line is written.
+type protoJSON struct {
+	proto.Message
+}
+
+func (p protoJSON) MarshalJSON() ([]byte, error) {
+	return protojson.Marshal(p.Message)
+}
+
+var _ json.Marshaler = protoJSON{}
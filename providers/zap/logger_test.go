@@ -0,0 +1,91 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package zap
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/testing/testpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestInterceptorLogger_Levels(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := InterceptorLogger(zap.New(core))
+
+	for _, lvl := range []logging.Level{logging.LevelDebug - 1, logging.LevelDebug, logging.LevelInfo, logging.LevelWarn, logging.LevelWarn + 1, logging.LevelError, logging.LevelError + 4} {
+		logger.Log(context.Background(), lvl, "msg")
+	}
+	var got []zapcore.Level
+	for _, e := range logs.All() {
+		got = append(got, e.Level)
+	}
+	assert.Equal(t, []zapcore.Level{
+		zapcore.DebugLevel, zapcore.DebugLevel, zapcore.InfoLevel, zapcore.WarnLevel, zapcore.WarnLevel, zapcore.ErrorLevel, zapcore.ErrorLevel,
+	}, got)
+}
+
+func TestInterceptorLogger_Fields(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := InterceptorLogger(zap.New(core))
+
+	logger.Log(context.Background(), logging.LevelDebug, "disabled", "key", "value")
+	logger.Log(context.Background(), logging.LevelInfo, "finished call",
+		"grpc.code", "OK",
+		"count", 3,
+		"took", time.Second,
+		"err", errors.New("boom"),
+		"grpc.request.content", &testpb.PingRequest{Value: "something"},
+	)
+
+	require.Equal(t, 1, logs.Len())
+	entry := logs.All()[0]
+	assert.Equal(t, "finished call", entry.Message)
+	ctx := entry.ContextMap()
+	assert.Equal(t, "OK", ctx["grpc.code"])
+	assert.Equal(t, int64(3), ctx["count"])
+	assert.Equal(t, time.Second, ctx["took"])
+	assert.Equal(t, "boom", ctx["err"])
+
+	for _, f := range entry.Context {
+		if f.Key == "grpc.request.content" {
+			b, err := f.Interface.(protoJSON).MarshalJSON()
+			require.NoError(t, err)
+			assert.JSONEq(t, `{"value":"something"}`, string(b))
+		}
+	}
+}
+
+func TestInterceptorLogger_ContextLogger(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := InterceptorLogger(zap.NewNop())
+
+	ctx := ToContext(context.Background(), zap.New(core).With(zap.String("request_id", "abc")))
+	logger.Log(ctx, logging.LevelInfo, "msg")
+
+	require.Equal(t, 1, logs.Len())
+	assert.Equal(t, "abc", logs.All()[0].ContextMap()["request_id"])
+}
+
+func TestInterceptorLogger_Caller(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := InterceptorLogger(zap.New(core, zap.AddCaller()))
+
+	logger.Log(context.Background(), logging.LevelInfo, "msg")
+	logger.Log(ToContext(context.Background(), zap.New(core, zap.AddCaller())), logging.LevelInfo, "msg")
+
+	require.Equal(t, 2, logs.Len())
+	for _, e := range logs.All() {
+		require.True(t, e.Caller.Defined)
+		assert.NotContains(t, e.Caller.File, "providers/zap/logger.go", "the adapter should not be reported as the caller")
+	}
+}
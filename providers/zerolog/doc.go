@@ -0,0 +1,11 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+/*
+Package zerolog adapts a github.com/rs/zerolog logger to the logging.Logger of interceptors/logging.
+
+It is a separate Go module, so that only the users of zerolog depend on it. Import it with a prefix to avoid the
+collision with the zerolog package, e.g.
+`grpczerolog "github.com/grpc-ecosystem/go-grpc-middleware/providers/zerolog"`.
+*/
+package zerolog
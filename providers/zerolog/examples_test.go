@@ -0,0 +1,26 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package zerolog_test
+
+import (
+	"os"
+
+	grpczerolog "github.com/grpc-ecosystem/go-grpc-middleware/providers/zerolog"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+)
+
+func ExampleInterceptorLogger() {
+	logger := zerolog.New(os.Stderr)
+
+	_ = grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			logging.UnaryServerInterceptor(grpczerolog.InterceptorLogger(logger)),
+		),
+		grpc.ChainStreamInterceptor(
+			logging.StreamServerInterceptor(grpczerolog.InterceptorLogger(logger)),
+		),
+	)
+}
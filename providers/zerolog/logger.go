@@ -0,0 +1,79 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package zerolog
+
+import (
+	"context"
+	"time"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
+	"github.com/rs/zerolog"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// InterceptorLogger adapts l to the logging.Logger interface.
+//
+// If the context of a call carries a logger (see zerolog.Logger.WithContext), it is used instead of l, so that the
+// fields bound to it are logged too. Log levels are mapped to the closest zerolog level below, e.g. any level between
+// logging.LevelWarn and logging.LevelError is logged as a warning. Strings, numbers, booleans, durations, times and
+// errors are logged with their typed zerolog field, and proto messages are encoded as JSON.
+func InterceptorLogger(l zerolog.Logger) logging.Logger {
+	return logging.LoggerFunc(func(ctx context.Context, lvl logging.Level, msg string, fields ...any) {
+		logger := &l
+		if cl := zerolog.Ctx(ctx); cl != zerolog.DefaultContextLogger && cl.GetLevel() != zerolog.Disabled {
+			logger = cl
+		}
+		e := logger.WithLevel(zerologLevel(lvl))
+		if e == nil {
+			return
+		}
+		i := logging.Fields(fields).Iterator()
+		for i.Next() {
+			k, v := i.At()
+			switch v := v.(type) {
+			case string:
+				e = e.Str(k, v)
+			case int:
+				e = e.Int(k, v)
+			case int64:
+				e = e.Int64(k, v)
+			case uint64:
+				e = e.Uint64(k, v)
+			case float64:
+				e = e.Float64(k, v)
+			case bool:
+				e = e.Bool(k, v)
+			case time.Duration:
+				e = e.Dur(k, v)
+			case time.Time:
+				e = e.Time(k, v)
+			case error:
+				e = e.AnErr(k, v)
+			case proto.Message:
+				if b, err := protojson.Marshal(v); err == nil {
+					e = e.RawJSON(k, b)
+				} else {
+					e = e.Str(k, err.Error())
+				}
+			default:
+				e = e.Interface(k, v)
+			}
+		}
+		e.Msg(msg)
+	})
+}
+
+func zerologLevel(lvl logging.Level) zerolog.Level {
+	switch {
+	case lvl < logging.LevelInfo:
+		return zerolog.DebugLevel
+	case lvl < logging.LevelWarn:
+		return zerolog.InfoLevel
+	case lvl < logging.LevelError:
+		return zerolog.WarnLevel
+	default:
+		return zerolog.ErrorLevel
+	}
+}
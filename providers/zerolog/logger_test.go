@@ -0,0 +1,84 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package zerolog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/testing/testpb"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func lines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+
+	var out []map[string]any
+	for _, l := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if l == "" {
+			continue
+		}
+		m := map[string]any{}
+		require.NoError(t, json.Unmarshal([]byte(l), &m))
+		out = append(out, m)
+	}
+	return out
+}
+
+func TestInterceptorLogger_Levels(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := InterceptorLogger(zerolog.New(buf))
+
+	for _, lvl := range []logging.Level{logging.LevelDebug - 1, logging.LevelInfo, logging.LevelWarn + 1, logging.LevelError, logging.LevelError + 4} {
+		logger.Log(context.Background(), lvl, "msg")
+	}
+	var got []any
+	for _, l := range lines(t, buf) {
+		got = append(got, l["level"])
+	}
+	assert.Equal(t, []any{"debug", "info", "warn", "error", "error"}, got)
+}
+
+func TestInterceptorLogger_Fields(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := InterceptorLogger(zerolog.New(buf).Level(zerolog.InfoLevel))
+
+	logger.Log(context.Background(), logging.LevelDebug, "disabled", "key", "value")
+	logger.Log(context.Background(), logging.LevelInfo, "finished call",
+		"grpc.code", "OK",
+		"count", 3,
+		"took", time.Second,
+		"err", errors.New("boom"),
+		"grpc.request.content", &testpb.PingRequest{Value: "something"},
+	)
+
+	got := lines(t, buf)
+	require.Len(t, got, 1)
+	assert.Equal(t, "finished call", got[0]["message"])
+	assert.Equal(t, "OK", got[0]["grpc.code"])
+	assert.InDelta(t, 3, got[0]["count"], 0)
+	assert.InDelta(t, 1000, got[0]["took"], 0)
+	assert.Equal(t, "boom", got[0]["err"])
+	assert.Equal(t, map[string]any{"value": "something"}, got[0]["grpc.request.content"])
+}
+
+func TestInterceptorLogger_ContextLogger(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := InterceptorLogger(zerolog.Nop())
+
+	ctx := zerolog.New(buf).With().Str("request_id", "abc").Logger().WithContext(context.Background())
+	logger.Log(ctx, logging.LevelInfo, "msg")
+
+	got := lines(t, buf)
+	require.Len(t, got, 1)
+	assert.Equal(t, "abc", got[0]["request_id"])
+}
@@ -0,0 +1,166 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+// Package backoff implements backoff strategies to wait between attempts, e.g. retries or rate limited calls.
+//
+// A strategy is a Func returning the wait before an attempt, given the wait before the previous one. Strategies
+// can be combined, e.g. Clamp(FullJitter(Exponential(100*time.Millisecond)), 0, 10*time.Second) is the "full
+// jitter" backoff described in https://aws.amazon.com/blogs/architecture/exponential-backoff-and-jitter/. Use a
+// Sequence to keep track of the attempts and the previous wait.
+package backoff
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// maxDuration is the longest wait returned by the strategies, instead of overflowing.
+const maxDuration = time.Duration(math.MaxInt64)
+
+// Func returns the wait before the given attempt, starting from 1 for the first retry, given the wait before
+// the previous attempt (0 for the first retry).
+type Func func(attempt uint, prev time.Duration) time.Duration
+
+// Rand is a source of random numbers, e.g. a *rand.Rand. Its Float64 returns a number in [0.0,1.0).
+type Rand interface {
+	Float64() float64
+}
+
+type globalRand struct{}
+
+func (globalRand) Float64() float64 {
+	return rand.Float64()
+}
+
+// Option configures the strategies using randomness.
+type Option func(*config)
+
+type config struct {
+	rand Rand
+}
+
+// WithRand sets the random source of the strategy, which defaults to the global one of math/rand. Use a seeded
+// source for deterministic tests. The source must be safe for concurrent use if the strategy is.
+func WithRand(r Rand) Option {
+	return func(c *config) {
+		c.rand = r
+	}
+}
+
+func newConfig(opts []Option) *config {
+	c := &config{rand: globalRand{}}
+	for _, o := range opts {
+		o(c)
+	}
+	return c
+}
+
+// Constant always waits for d.
+func Constant(d time.Duration) Func {
+	return func(uint, time.Duration) time.Duration {
+		return d
+	}
+}
+
+// Exponential waits for base * 2^(attempt-1), i.e. base before the first retry and twice as long before every
+// next one. It never overflows, but should be clamped with Clamp to avoid waiting for ages.
+func Exponential(base time.Duration) Func {
+	return func(attempt uint, _ time.Duration) time.Duration {
+		if attempt == 0 {
+			attempt = 1
+		}
+		return mul(base, math.Exp2(float64(attempt-1)))
+	}
+}
+
+// Fibonacci waits for base * F(attempt), where F is the Fibonacci sequence 1, 1, 2, 3, 5, 8... which grows
+// slower than Exponential. It never overflows.
+func Fibonacci(base time.Duration) Func {
+	return func(attempt uint, _ time.Duration) time.Duration {
+		a, b := 0.0, 1.0
+		for i := uint(0); i < attempt && !math.IsInf(b, 1); i++ {
+			a, b = b, a+b
+		}
+		return mul(base, math.Max(a, 1))
+	}
+}
+
+// FullJitter waits for a random time between 0 and what f would, which spreads the attempts of concurrent
+// clients the most.
+func FullJitter(f Func, opts ...Option) Func {
+	c := newConfig(opts)
+	return func(attempt uint, prev time.Duration) time.Duration {
+		return mul(f(attempt, prev), c.rand.Float64())
+	}
+}
+
+// DecorrelatedJitter waits for a random time between base and three times the previous wait, capped at
+// maxWait, as in min(maxWait, random(base, prev*3)). The first retry, with no previous wait, waits for a random
+// time between base and three times base.
+func DecorrelatedJitter(base, maxWait time.Duration, opts ...Option) Func {
+	c := newConfig(opts)
+	return func(_ uint, prev time.Duration) time.Duration {
+		if prev < base {
+			prev = base
+		}
+		upper := mul(prev, 3)
+		return min(base+mul(upper-base, c.rand.Float64()), maxWait)
+	}
+}
+
+// WithJitter adds or subtracts a random fraction (up to jitter) of the wait of f. For example, for 10s and a
+// jitter of 0.1, it waits for a time within [9s, 11s].
+func WithJitter(f Func, jitter float64, opts ...Option) Func {
+	c := newConfig(opts)
+	return func(attempt uint, prev time.Duration) time.Duration {
+		return mul(f(attempt, prev), 1+jitter*(c.rand.Float64()*2-1))
+	}
+}
+
+// Clamp keeps the wait of f within [minWait, maxWait]. A maxWait of 0 means no upper bound.
+func Clamp(f Func, minWait, maxWait time.Duration) Func {
+	return func(attempt uint, prev time.Duration) time.Duration {
+		d := max(f(attempt, prev), minWait)
+		if maxWait > 0 {
+			d = min(d, maxWait)
+		}
+		return d
+	}
+}
+
+// mul multiplies d by factor, saturating instead of overflowing.
+func mul(d time.Duration, factor float64) time.Duration {
+	f := float64(d) * factor
+	if f >= float64(maxDuration) {
+		return maxDuration
+	}
+	if f <= 0 {
+		return 0
+	}
+	return time.Duration(f)
+}
+
+// Sequence keeps track of the attempts of a strategy. It is not safe for concurrent use.
+type Sequence struct {
+	f       Func
+	attempt uint
+	prev    time.Duration
+}
+
+// NewSequence returns a Sequence of the waits of f.
+func NewSequence(f Func) *Sequence {
+	return &Sequence{f: f}
+}
+
+// Next returns the wait before the next attempt.
+func (s *Sequence) Next() time.Duration {
+	s.attempt++
+	s.prev = s.f(s.attempt, s.prev)
+	return s.prev
+}
+
+// Reset starts the sequence over, e.g. after a successful attempt.
+func (s *Sequence) Reset() {
+	s.attempt, s.prev = 0, 0
+}
@@ -0,0 +1,95 @@
+// Copyright (c) The go-grpc-middleware Authors.
+// Licensed under the Apache License 2.0.
+
+package backoff_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/util/backoff"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixedRand always returns the same number.
+type fixedRand float64
+
+func (r fixedRand) Float64() float64 {
+	return float64(r)
+}
+
+func waits(f backoff.Func, n int) []time.Duration {
+	seq := backoff.NewSequence(f)
+	var out []time.Duration
+	for i := 0; i < n; i++ {
+		out = append(out, seq.Next())
+	}
+	return out
+}
+
+func TestConstant(t *testing.T) {
+	assert.Equal(t, []time.Duration{time.Second, time.Second, time.Second}, waits(backoff.Constant(time.Second), 3))
+}
+
+func TestExponential(t *testing.T) {
+	assert.Equal(t, []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond}, waits(backoff.Exponential(100*time.Millisecond), 4))
+	for attempt := uint(60); attempt < 200; attempt++ {
+		require.Equal(t, time.Duration(math.MaxInt64), backoff.Exponential(time.Second)(attempt, 0), "should saturate instead of overflowing")
+	}
+}
+
+func TestFibonacci(t *testing.T) {
+	assert.Equal(t, []time.Duration{time.Second, time.Second, 2 * time.Second, 3 * time.Second, 5 * time.Second, 8 * time.Second}, waits(backoff.Fibonacci(time.Second), 6))
+	require.Equal(t, time.Duration(math.MaxInt64), backoff.Fibonacci(time.Second)(5000, 0), "should saturate instead of overflowing")
+}
+
+func TestFullJitter(t *testing.T) {
+	f := backoff.FullJitter(backoff.Exponential(time.Second), backoff.WithRand(fixedRand(0.5)))
+	assert.Equal(t, []time.Duration{500 * time.Millisecond, time.Second, 2 * time.Second}, waits(f, 3))
+
+	f = backoff.FullJitter(backoff.Exponential(time.Second))
+	for attempt := uint(1); attempt < 100; attempt++ {
+		d := f(attempt, 0)
+		require.GreaterOrEqual(t, d, time.Duration(0))
+		require.LessOrEqual(t, d, backoff.Exponential(time.Second)(attempt, 0))
+	}
+}
+
+func TestDecorrelatedJitter(t *testing.T) {
+	f := backoff.DecorrelatedJitter(time.Second, 20*time.Second, backoff.WithRand(fixedRand(0.5)))
+	// random(base, prev*3) is base + (prev*3 - base) / 2 here.
+	assert.Equal(t, []time.Duration{2 * time.Second, 3500 * time.Millisecond, 5750 * time.Millisecond, 9125 * time.Millisecond, 14187500 * time.Microsecond, 20 * time.Second}, waits(f, 6))
+
+	f = backoff.DecorrelatedJitter(time.Second, 20*time.Second, backoff.WithRand(fixedRand(0)))
+	assert.Equal(t, []time.Duration{time.Second, time.Second}, waits(f, 2))
+}
+
+func TestWithJitter(t *testing.T) {
+	assert.Equal(t, 11*time.Second, backoff.WithJitter(backoff.Constant(10*time.Second), 0.1, backoff.WithRand(fixedRand(1)))(1, 0))
+	assert.Equal(t, 9*time.Second, backoff.WithJitter(backoff.Constant(10*time.Second), 0.1, backoff.WithRand(fixedRand(0)))(1, 0))
+
+	f := backoff.WithJitter(backoff.Constant(10*time.Second), 0.1)
+	for i := 0; i < 1000; i++ {
+		d := f(1, 0)
+		require.GreaterOrEqual(t, d, 9*time.Second)
+		require.LessOrEqual(t, d, 11*time.Second)
+	}
+}
+
+func TestClamp(t *testing.T) {
+	f := backoff.Clamp(backoff.Exponential(time.Second), 2*time.Second, 5*time.Second)
+	assert.Equal(t, []time.Duration{2 * time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second}, waits(f, 4))
+
+	f = backoff.Clamp(backoff.Exponential(time.Second), 0, 0)
+	assert.Equal(t, 8*time.Second, f(4, 0), "no upper bound")
+}
+
+func TestSequenceReset(t *testing.T) {
+	seq := backoff.NewSequence(backoff.Exponential(time.Second))
+	assert.Equal(t, time.Second, seq.Next())
+	assert.Equal(t, 2*time.Second, seq.Next())
+	seq.Reset()
+	assert.Equal(t, time.Second, seq.Next())
+}
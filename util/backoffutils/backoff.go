@@ -2,23 +2,37 @@
 // Licensed under the Apache License 2.0.
 
 // Package backoffutils implements common backoff features.
+//
+// Deprecated: use the strategies of the backoff package (github.com/grpc-ecosystem/go-grpc-middleware/v2/util/backoff) instead.
 package backoffutils
 
 import (
-	"math/rand"
+	"math/bits"
 	"time"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/util/backoff"
 )
 
 // JitterUp adds random jitter to the duration.
 //
 // This adds or subtracts time from the duration within a given jitter fraction.
 // For example for 10s and jitter 0.1, it will return a time within [9s, 11s])
+//
+// Deprecated: use backoff.WithJitter instead.
 func JitterUp(duration time.Duration, jitter float64) time.Duration {
-	multiplier := jitter * (rand.Float64()*2 - 1)
-	return time.Duration(float64(duration) * (1 + multiplier))
+	return backoff.WithJitter(backoff.Constant(duration), jitter)(1, 0)
 }
 
-// ExponentBase2 computes 2^(a-1) where a >= 1. If a is 0, the result is 0.
+// ExponentBase2 computes 2^(a-1) where a >= 1. If a is 0, the result is 0. The result saturates at
+// the largest power of 2 that fits in a uint instead of overflowing.
+//
+// Deprecated: use backoff.Exponential instead.
 func ExponentBase2(a uint) uint {
-	return (1 << a) >> 1
+	if a == 0 {
+		return 0
+	}
+	if a > bits.UintSize {
+		a = bits.UintSize
+	}
+	return 1 << (a - 1)
 }
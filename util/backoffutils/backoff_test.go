@@ -46,3 +46,12 @@ func TestJitterUp(t *testing.T) {
 	assert.NotEqual(t, 0, highCount, "at least one sample should reach to >%s", high)
 	assert.NotEqual(t, 0, lowCount, "at least one sample should to <%s", low)
 }
+
+func TestExponentBase2(t *testing.T) {
+	assert.Equal(t, uint(0), backoffutils.ExponentBase2(0))
+	assert.Equal(t, uint(1), backoffutils.ExponentBase2(1))
+	assert.Equal(t, uint(8), backoffutils.ExponentBase2(4))
+	for a := uint(64); a < 128; a++ {
+		assert.NotZero(t, backoffutils.ExponentBase2(a), "should not overflow to 0 for %d", a)
+	}
+}